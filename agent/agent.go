@@ -167,6 +167,20 @@ const (
 	AgentServiceName  = "AGENT_SERVICE_NAME"
 	MongoOplogSize    = "MONGO_OPLOG_SIZE"
 	NUMACtlPreference = "NUMA_CTL_PREFERENCE"
+
+	// APIPingTimeout, APIPingTimeoutAdaptive and APIPingTimeoutMax
+	// configure how a controller's API server times out agent
+	// connections that stop pinging. See apiserver.PingTimeoutConfig.
+	APIPingTimeout         = "API_PING_TIMEOUT"
+	APIPingTimeoutAdaptive = "API_PING_TIMEOUT_ADAPTIVE"
+	APIPingTimeoutMax      = "API_PING_TIMEOUT_MAX"
+
+	// UnitOperationTimeout, UnitHookTimeout and UnitActionTimeout
+	// configure how long a unit agent lets a hook or action run before
+	// killing it. See operation.FactoryParams.
+	UnitOperationTimeout = "UNIT_OPERATION_TIMEOUT"
+	UnitHookTimeout      = "UNIT_HOOK_TIMEOUT"
+	UnitActionTimeout    = "UNIT_ACTION_TIMEOUT"
 )
 
 // The Config interface is the sole way that the agent gets access to the
@@ -324,6 +324,34 @@ func (c *Client) DestroyRelation(endpoints ...string) error {
 	return c.facade.FacadeCall("DestroyRelation", params, nil)
 }
 
+// RelationDetails returns the relation between the specified endpoints,
+// as DestroyRelation would resolve it, without changing it.
+func (c *Client) RelationDetails(endpoints ...string) (params.RelationDetails, error) {
+	if c.facade.BestAPIVersion() < 4 {
+		return params.RelationDetails{}, errors.NotSupportedf("RelationDetails on this controller")
+	}
+	var details params.RelationDetails
+	args := params.RelationEndpoints{Endpoints: endpoints}
+	if err := c.facade.FacadeCall("RelationDetails", args, &details); err != nil {
+		return params.RelationDetails{}, errors.Trace(err)
+	}
+	return details, nil
+}
+
+// ForceDestroyRelation removes the relation between the specified
+// endpoints. Any units in the relation's scope are removed from it
+// straight away, without running their hooks.
+func (c *Client) ForceDestroyRelation(endpoints ...string) error {
+	if c.facade.BestAPIVersion() < 4 {
+		return errors.NotSupportedf("forced DestroyRelation on this controller")
+	}
+	params := params.DestroyRelation{
+		Endpoints: endpoints,
+		Force:     true,
+	}
+	return c.facade.FacadeCall("DestroyRelation", params, nil)
+}
+
 // Consume adds a remote application to the model.
 func (c *Client) Consume(remoteApplication, alias string) (string, error) {
 	var consumeRes params.ConsumeApplicationResults
@@ -4,6 +4,7 @@
 package application_test
 
 import (
+	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 	"gopkg.in/juju/charm.v6-unstable"
@@ -207,3 +208,58 @@ func (s *applicationSuite) TestConsume(c *gc.C) {
 	c.Assert(name, gc.Equals, "result")
 	c.Assert(called, jc.IsTrue)
 }
+
+func (s *applicationSuite) TestForceDestroyRelation(c *gc.C) {
+	var called bool
+	application.PatchFacadeCall(s, s.client, func(request string, a, response interface{}) error {
+		called = true
+		c.Assert(request, gc.Equals, "DestroyRelation")
+		c.Assert(a, jc.DeepEquals, params.DestroyRelation{
+			Endpoints: []string{"wordpress", "mysql"},
+			Force:     true,
+		})
+		return nil
+	})
+	err := s.client.ForceDestroyRelation("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(called, jc.IsTrue)
+}
+
+func (s *applicationSuite) TestForceDestroyRelationNotSupported(c *gc.C) {
+	application.PatchBestAPIVersion(s, s.client, 3)
+	application.PatchFacadeCall(s, s.client, func(request string, a, response interface{}) error {
+		c.Fatalf("unexpected call to %s", request)
+		return nil
+	})
+	err := s.client.ForceDestroyRelation("wordpress", "mysql")
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+}
+
+func (s *applicationSuite) TestRelationDetailsNotSupported(c *gc.C) {
+	application.PatchBestAPIVersion(s, s.client, 3)
+	application.PatchFacadeCall(s, s.client, func(request string, a, response interface{}) error {
+		c.Fatalf("unexpected call to %s", request)
+		return nil
+	})
+	_, err := s.client.RelationDetails("wordpress", "mysql")
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+}
+
+func (s *applicationSuite) TestRelationDetails(c *gc.C) {
+	var called bool
+	application.PatchFacadeCall(s, s.client, func(request string, a, response interface{}) error {
+		called = true
+		c.Assert(request, gc.Equals, "RelationDetails")
+		c.Assert(a, jc.DeepEquals, params.RelationEndpoints{
+			Endpoints: []string{"wordpress", "mysql"},
+		})
+		result := response.(*params.RelationDetails)
+		result.Id = 3
+		result.UnitCount = 2
+		return nil
+	})
+	details, err := s.client.RelationDetails("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(details, jc.DeepEquals, params.RelationDetails{Id: 3, UnitCount: 2})
+	c.Assert(called, jc.IsTrue)
+}
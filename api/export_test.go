@@ -21,6 +21,8 @@ var (
 	BestVersion         = bestVersion
 	FacadeVersions      = &facadeVersions
 	DialAPI             = dialAPI
+	MaxClockSkew        = &maxClockSkew
+	WarnClockSkew       = warnClockSkew
 )
 
 // RPCConnection defines the methods that are called on the rpc.Conn instance.
@@ -17,7 +17,7 @@ var facadeVersions = map[string]int{
 	"AllModelWatcher":              2,
 	"AllWatcher":                   1,
 	"Annotations":                  2,
-	"Application":                  3,
+	"Application":                  4,
 	"ApplicationScaler":            1,
 	"ApplicationOffers":            1,
 	"Backups":                      1,
@@ -73,6 +73,7 @@ var facadeVersions = map[string]int{
 	"RelationUnitsWatcher":         1,
 	"RemoteRelations":              1,
 	"Resources":                    1,
+	"ResourceURLs":                 1,
 	"ResourcesHookContext":         1,
 	"Resumer":                      2,
 	"RetryStrategy":                1,
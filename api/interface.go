@@ -11,6 +11,7 @@ import (
 	"github.com/juju/version"
 	"golang.org/x/net/websocket"
 	"gopkg.in/juju/names.v2"
+	"gopkg.in/macaroon-bakery.v1/bakery"
 	"gopkg.in/macaroon-bakery.v1/httpbakery"
 	"gopkg.in/macaroon.v1"
 
@@ -226,6 +227,11 @@ type Connection interface {
 	// associated with.
 	CookieURL() *url.URL
 
+	// MacaroonDischargeInfo returns the public key of the controller's
+	// bakery service for external users and the URL of the identity
+	// service that discharges their macaroons' third-party caveats.
+	MacaroonDischargeInfo() (*bakery.PublicKey, string, error)
+
 	// These methods expose a bunch of worker-specific facades, and basically
 	// just should not exist; but removing them is too noisy for a single CL.
 	// Client in particular is intimately coupled with State -- and the others
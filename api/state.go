@@ -8,11 +8,13 @@ import (
 	"net/url"
 	"runtime/debug"
 	"strconv"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/utils/featureflag"
 	"github.com/juju/version"
 	"gopkg.in/juju/names.v2"
+	"gopkg.in/macaroon-bakery.v1/bakery"
 	"gopkg.in/macaroon-bakery.v1/httpbakery"
 	"gopkg.in/macaroon.v1"
 
@@ -139,9 +141,29 @@ func (st *state) Login(tag names.Tag, password, nonce string, macaroons []macaro
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if result.ServerTime != nil {
+		warnClockSkew(st.clock.Now(), *result.ServerTime)
+	}
 	return nil
 }
 
+// maxClockSkew is the largest difference between the local clock and
+// the controller's that Login does not warn about. Macaroon and
+// certificate validation may fail when the clocks differ by more.
+var maxClockSkew = 5 * time.Minute
+
+// warnClockSkew logs a warning if the local time now differs from the
+// controller's time serverTime by more than maxClockSkew.
+func warnClockSkew(now, serverTime time.Time) {
+	skew := now.Sub(serverTime)
+	switch {
+	case skew > maxClockSkew:
+		logger.Warningf("local clock is %v ahead of the controller's", skew)
+	case skew < -maxClockSkew:
+		logger.Warningf("local clock is %v behind the controller's", -skew)
+	}
+}
+
 type loginResultParams struct {
 	tag              names.Tag
 	modelTag         string
@@ -213,6 +235,22 @@ func (st *state) CookieURL() *url.URL {
 	return &copy
 }
 
+// MacaroonDischargeInfo returns the public key of the controller's bakery
+// service for external users and the URL of the identity service that
+// discharges their macaroons' third-party caveats. Like the rest of
+// the Admin facade, it is only available before logging in.
+func (st *state) MacaroonDischargeInfo() (*bakery.PublicKey, string, error) {
+	var result params.MacaroonDischargeInfoResult
+	if err := st.APICall("Admin", 4, "", "MacaroonDischargeInfo", nil, &result); err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	var publicKey bakery.PublicKey
+	if err := publicKey.UnmarshalText([]byte(result.PublicKey)); err != nil {
+		return nil, "", errors.Annotate(err, "cannot decode public key")
+	}
+	return &publicKey, result.DischargeURL, nil
+}
+
 // slideAddressToFront moves the address at the location (serverIndex, addrIndex) to be
 // the first address of the first server.
 func slideAddressToFront(servers [][]network.HostPort, serverIndex, addrIndex int) {
@@ -5,7 +5,9 @@ package api_test
 
 import (
 	stdtesting "testing"
+	"time"
 
+	"github.com/juju/loggo"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 	"gopkg.in/juju/names.v2"
@@ -35,6 +37,12 @@ type slideSuite struct {
 
 var _ = gc.Suite(&slideSuite{})
 
+type clockSkewSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&clockSkewSuite{})
+
 func (s *stateSuite) TestCloseMultipleOk(c *gc.C) {
 	c.Assert(s.APIState.Close(), gc.IsNil)
 	c.Assert(s.APIState.Close(), gc.IsNil)
@@ -300,3 +308,30 @@ func (s *slideSuite) TestSlideToFrontBoth(c *gc.C) {
 	api.SlideAddressToFront(servers, 1, 1)
 	c.Check(servers, gc.DeepEquals, expected)
 }
+
+func (s *clockSkewSuite) TestWarnClockSkew(c *gc.C) {
+	var logWriter loggo.TestWriter
+	c.Assert(loggo.RegisterWriter("clockskew-tests", &logWriter), jc.ErrorIsNil)
+	defer func() {
+		loggo.RemoveWriter("clockskew-tests")
+		logWriter.Clear()
+	}()
+	s.PatchValue(api.MaxClockSkew, 5*time.Minute)
+
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	api.WarnClockSkew(now, now.Add(-time.Minute))
+	api.WarnClockSkew(now, now.Add(time.Minute))
+	api.WarnClockSkew(now, now.Add(-10*time.Minute))
+	api.WarnClockSkew(now, now.Add(10*time.Minute))
+
+	var messages []string
+	for _, entry := range logWriter.Log() {
+		if entry.Level == loggo.WARNING {
+			messages = append(messages, entry.Message)
+		}
+	}
+	c.Assert(messages, jc.DeepEquals, []string{
+		"local clock is 10m0s ahead of the controller's",
+		"local clock is 10m0s behind the controller's",
+	})
+}
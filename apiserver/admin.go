@@ -43,9 +43,21 @@ var MaintenanceNoLoginError = errors.New("login failed - maintenance in progress
 var errAlreadyLoggedIn = errors.New("already logged in")
 
 // login is the internal version of the Login API call.
-func (a *admin) login(req params.LoginRequest, loginVersion int) (params.LoginResult, error) {
+func (a *admin) login(req params.LoginRequest, loginVersion int) (result params.LoginResult, err error) {
 	var fail params.LoginResult
 
+	// failure records why the login failed, if it does.
+	failure := loginFailureOther
+	a.srv.loginMetrics.attempted()
+	defer func() {
+		switch {
+		case err != nil:
+			a.srv.loginMetrics.failed(failure)
+		case result.DischargeRequired == nil:
+			a.srv.loginMetrics.succeeded()
+		}
+	}()
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	if a.loggedIn {
@@ -54,7 +66,9 @@ func (a *admin) login(req params.LoginRequest, loginVersion int) (params.LoginRe
 	}
 
 	// apiRoot is the API root exposed to the client after authentication.
-	var apiRoot rpc.Root = newAPIRoot(a.root.state, a.srv.statePool, a.root.resources, a.root)
+	root := newAPIRoot(a.root.state, a.srv.statePool, a.root.resources, a.root)
+	root.breaker = a.srv.breaker
+	var apiRoot rpc.Root = root
 
 	// Use the login validation function, if one was specified.
 	if a.srv.validator != nil {
@@ -69,6 +83,7 @@ func (a *admin) login(req params.LoginRequest, loginVersion int) (params.LoginRe
 		case nil:
 			// in this case no need to wrap authed api so we do nothing
 		default:
+			failure = loginFailureMaintenance
 			return fail, errors.Trace(err)
 		}
 	}
@@ -83,6 +98,7 @@ func (a *admin) login(req params.LoginRequest, loginVersion int) (params.LoginRe
 			// Users are not rate limited, all other entities are.
 			if !a.srv.limiter.Acquire() {
 				logger.Debugf("rate limiting for agent %s", req.AuthTag)
+				failure = loginFailureRateLimited
 				return fail, common.ErrTryAgain
 			}
 			defer a.srv.limiter.Release()
@@ -108,6 +124,7 @@ func (a *admin) login(req params.LoginRequest, loginVersion int) (params.LoginRe
 			// is complete due to incomplete or updating data. Mask
 			// transitory and potentially confusing errors from failed
 			// logins with a more helpful one.
+			failure = loginFailureMaintenance
 			return fail, MaintenanceNoLoginError
 		}
 		// Here we have a special case.  The machine agents that manage
@@ -119,6 +136,9 @@ func (a *admin) login(req params.LoginRequest, loginVersion int) (params.LoginRe
 		// machine has the manage state job.  If all those parts are valid, we
 		// can then check the credentials against the controller model
 		// machine.
+		if errors.Cause(err) == common.ErrBadCreds {
+			failure = loginFailureBadCredentials
+		}
 		if kind != names.MachineTagKind {
 			return fail, errors.Trace(err)
 		}
@@ -129,6 +149,7 @@ func (a *admin) login(req params.LoginRequest, loginVersion int) (params.LoginRe
 		if err != nil {
 			return fail, errors.Trace(err)
 		}
+		failure = loginFailureOther
 		// If we are here, then the entity will refer to a controller
 		// machine in the controller model, and we don't need a pinger
 		// for it as we already have one running in the machine agent api
@@ -136,6 +157,8 @@ func (a *admin) login(req params.LoginRequest, loginVersion int) (params.LoginRe
 		controllerMachineLogin = true
 	}
 	a.root.entity = entity
+	root.limiter = a.srv.requestLimiter
+	root.limitEntity = entity.Tag().String()
 	a.apiObserver.Login(entity.Tag(), a.root.state.ModelTag(), controllerMachineLogin, req.UserData)
 
 	// We have authenticated the user; enable the appropriate API
@@ -143,7 +166,7 @@ func (a *admin) login(req params.LoginRequest, loginVersion int) (params.LoginRe
 	a.loggedIn = true
 
 	if !controllerMachineLogin {
-		if err := startPingerIfAgent(a.srv.pingClock, a.root, entity); err != nil {
+		if err := startPingerIfAgent(a.srv.pingClock, a.srv.pingTimeout, a.root, entity); err != nil {
 			return fail, errors.Trace(err)
 		}
 	}
@@ -189,11 +212,13 @@ func (a *admin) login(req params.LoginRequest, loginVersion int) (params.LoginRe
 		}
 	}
 
+	serverTime := a.srv.clock.Now().UTC()
 	loginResult := params.LoginResult{
 		Servers:       params.FromNetworkHostsPorts(hostPorts),
 		ControllerTag: model.ControllerTag().String(),
 		UserInfo:      maybeUserInfo,
 		ServerVersion: jujuversion.Current.String(),
+		ServerTime:    &serverTime,
 	}
 
 	if controllerOnlyLogin {
@@ -204,6 +229,13 @@ func (a *admin) login(req params.LoginRequest, loginVersion int) (params.LoginRe
 		loginResult.Facades = filterFacades(isModelFacade)
 		apiRoot = restrictRoot(apiRoot, modelFacadesOnly)
 	}
+	if req.ReadOnly {
+		// This applies on top of any other restriction, whatever
+		// the access the entity has been granted.
+		loginResult.Facades = readOnlyFacades(loginResult.Facades)
+		apiRoot = restrictRoot(apiRoot, readOnlyMethodsOnly)
+	}
+	loginResult.ReadReplica = readReplicaHint(a.srv.preferredReplica, loginResult.Facades)
 
 	a.root.rpcConn.ServeRoot(apiRoot, serverError)
 
@@ -292,15 +324,17 @@ func filterFacades(allowFacade func(name string) bool) []params.FacadeVersions {
 }
 
 func (a *admin) checkCreds(req params.LoginRequest, lookForModelUser bool) (state.Entity, *time.Time, error) {
-	return doCheckCreds(a.root.state, req, lookForModelUser, a.authenticator())
+	return doCheckCreds(a.root.state, req, lookForModelUser, a.authenticator(a.root.state))
 }
 
 func (a *admin) checkControllerMachineCreds(req params.LoginRequest) (state.Entity, error) {
-	return checkControllerMachineCreds(a.srv.state, req, a.authenticator())
+	return checkControllerMachineCreds(a.srv.state, req, a.authenticator(a.srv.state))
 }
 
-func (a *admin) authenticator() authentication.EntityAuthenticator {
-	return a.srv.authCtxt.authenticator(a.root.serverHost)
+// authenticator returns the authenticator for a login to the model of
+// the given state.
+func (a *admin) authenticator(st *state.State) authentication.EntityAuthenticator {
+	return a.srv.authCtxt.authenticator(a.root.serverHost, st.ModelUUID(), a.root.peerCertificates)
 }
 
 func (a *admin) maintenanceInProgress() bool {
@@ -551,7 +585,7 @@ func (shim presenceShim) Start() (presence.Pinger, error) {
 	return pinger, nil
 }
 
-func startPingerIfAgent(clock clock.Clock, root *apiHandler, entity state.Entity) error {
+func startPingerIfAgent(clock clock.Clock, config PingTimeoutConfig, root *apiHandler, entity state.Entity) error {
 	// worker runs presence.Pingers -- absence of which will cause
 	// embarrassing "agent is lost" messages to show up in status --
 	// until it's stopped. It's stored in resources purely for the
@@ -589,7 +623,7 @@ func startPingerIfAgent(clock clock.Clock, root *apiHandler, entity state.Entity
 			logger.Errorf("error closing the RPC connection: %v", err)
 		}
 	}
-	pingTimeout := newPingTimeout(action, clock, maxClientPingInterval)
+	pingTimeout := config.newPinger(action, clock)
 	return root.getResources().RegisterNamed("pingTimeout", pingTimeout)
 }
 
@@ -4,6 +4,7 @@
 package apiserver_test
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/url"
@@ -15,6 +16,7 @@ import (
 	"github.com/juju/loggo"
 	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils"
+	"github.com/juju/utils/set"
 	gc "gopkg.in/check.v1"
 	"gopkg.in/juju/names.v2"
 	"gopkg.in/macaroon-bakery.v1/httpbakery"
@@ -327,6 +329,79 @@ func (s *loginSuite) TestDelayLogins(c *gc.C) {
 	c.Logf("done")
 }
 
+func (s *loginSuite) TestShutdownWaitsForInFlightCalls(c *gc.C) {
+	info, srv := s.newMachineAndServer(c)
+	defer assertStop(c, srv)
+	delayChan, cleanup := apiserver.DelayLogins()
+	defer cleanup()
+
+	errResults, wg := startNLogins(c, 1, info)
+	defer wg.Wait()
+	// Give the login time to reach the server.
+	time.Sleep(coretesting.ShortWait)
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- srv.Shutdown(context.Background())
+	}()
+	select {
+	case err := <-shutdownErr:
+		c.Fatalf("shutdown finished while a login was in flight: %v", err)
+	case <-time.After(coretesting.ShortWait):
+	}
+
+	// Once the login finishes, the connection is closed and the
+	// shutdown completes.
+	delayChan <- struct{}{}
+	select {
+	case err := <-errResults:
+		c.Check(err, jc.ErrorIsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for login to finish")
+	}
+	select {
+	case err := <-shutdownErr:
+		c.Check(err, jc.ErrorIsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for shutdown")
+	}
+
+	// New connections are refused.
+	_, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.NotNil)
+
+	// Shutdown can only be called once.
+	err = srv.Shutdown(context.Background())
+	c.Assert(err, gc.ErrorMatches, "apiserver shutdown already started")
+}
+
+func (s *loginSuite) TestShutdownDeadlineClosesConnections(c *gc.C) {
+	info, srv := s.newMachineAndServer(c)
+	defer assertStop(c, srv)
+	delayChan, cleanup := apiserver.DelayLogins()
+	defer cleanup()
+
+	errResults, wg := startNLogins(c, 1, info)
+	defer wg.Wait()
+	// The blocked login is released after the test, once its
+	// connection has been closed.
+	defer func() { delayChan <- struct{}{} }()
+	// Give the login time to reach the server.
+	time.Sleep(coretesting.ShortWait)
+
+	ctx, cancel := context.WithTimeout(context.Background(), coretesting.ShortWait)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	c.Assert(err, gc.ErrorMatches, "forcibly closed 1 API connections: context deadline exceeded")
+
+	select {
+	case err := <-errResults:
+		c.Check(err, gc.NotNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for login to fail")
+	}
+}
+
 func (s *loginSuite) TestLoginRateLimited(c *gc.C) {
 	info, srv := s.newMachineAndServer(c)
 	defer assertStop(c, srv)
@@ -533,6 +608,210 @@ func (s *loginSuite) TestFailedLoginDuringMaintenance(c *gc.C) {
 	checkLogin(names.NewMachineTag("99999"))
 }
 
+func (s *loginSuite) loginResultFacades(c *gc.C, modelTag names.ModelTag, readOnly bool) []params.FacadeVersions {
+	info, srv := newServer(c, s.State)
+	s.AddCleanup(func(c *gc.C) { assertStop(c, srv) })
+	apiserver.SetAdminAPIVersions(srv, 3)
+	info.ModelTag = modelTag
+
+	st := s.openAPIWithoutLogin(c, info)
+	request := &params.LoginRequest{
+		AuthTag:     s.AdminUserTag(c).String(),
+		Credentials: "dummy-secret",
+		ReadOnly:    readOnly,
+	}
+	var response params.LoginResult
+	err := st.APICall("Admin", 3, "", "Login", request, &response)
+	c.Assert(err, jc.ErrorIsNil)
+	return response.Facades
+}
+
+func facadeNames(facades []params.FacadeVersions) set.Strings {
+	result := set.NewStrings()
+	for _, facade := range facades {
+		result.Add(facade.Name)
+	}
+	return result
+}
+
+func (s *loginSuite) TestLoginResultFacadesModel(c *gc.C) {
+	facades := s.loginResultFacades(c, s.State.ModelTag(), false)
+	var expect []params.FacadeVersions
+	for _, facade := range apiserver.DescribeFacades() {
+		if apiserver.IsModelFacade(facade.Name) {
+			expect = append(expect, facade)
+		}
+	}
+	c.Assert(facades, jc.DeepEquals, expect)
+	c.Check(facadeNames(facades).Contains("Client"), jc.IsTrue)
+	c.Check(facadeNames(facades).Contains("ModelManager"), jc.IsFalse)
+}
+
+func (s *loginSuite) TestLoginResultFacadesController(c *gc.C) {
+	facades := s.loginResultFacades(c, names.ModelTag{}, false)
+	var expect []params.FacadeVersions
+	for _, facade := range apiserver.DescribeFacades() {
+		if apiserver.IsControllerFacade(facade.Name) {
+			expect = append(expect, facade)
+		}
+	}
+	c.Assert(facades, jc.DeepEquals, expect)
+	c.Check(facadeNames(facades).Contains("ModelManager"), jc.IsTrue)
+	c.Check(facadeNames(facades).Contains("Client"), jc.IsFalse)
+}
+
+func (s *loginSuite) TestLoginResultFacadesReadOnly(c *gc.C) {
+	facades := s.loginResultFacades(c, s.State.ModelTag(), true)
+	c.Assert(facadeNames(facades).SortedValues(), jc.DeepEquals, []string{
+		"AllWatcher", "Client", "Pinger",
+	})
+	for _, facade := range facades {
+		c.Check(facade.Versions, gc.Not(gc.HasLen), 0)
+	}
+}
+
+func (s *loginSuite) TestLoginResultServerTime(c *gc.C) {
+	info, srv := newServer(c, s.State)
+	defer assertStop(c, srv)
+	info.ModelTag = s.State.ModelTag()
+
+	st := s.openAPIWithoutLogin(c, info)
+	request := &params.LoginRequest{
+		AuthTag:     s.AdminUserTag(c).String(),
+		Credentials: "dummy-secret",
+	}
+	before := time.Now()
+	var response params.LoginResult
+	err := st.APICall("Admin", 3, "", "Login", request, &response)
+	c.Assert(err, jc.ErrorIsNil)
+	after := time.Now()
+
+	c.Assert(response.ServerTime, gc.NotNil)
+	c.Check(response.ServerTime.Before(before.Add(-time.Second)), jc.IsFalse)
+	c.Check(response.ServerTime.After(after.Add(time.Second)), jc.IsFalse)
+}
+
+func (s *loginSuite) TestReadOnlyLogin(c *gc.C) {
+	info, srv := newServer(c, s.State)
+	defer assertStop(c, srv)
+	info.ModelTag = s.State.ModelTag()
+
+	st := s.openAPIWithoutLogin(c, info)
+	request := &params.LoginRequest{
+		AuthTag:     s.AdminUserTag(c).String(),
+		Credentials: "dummy-secret",
+		ReadOnly:    true,
+	}
+	var response params.LoginResult
+	err := st.APICall("Admin", 3, "", "Login", request, &response)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// The admin user may normally do anything, but this connection
+	// is restricted to read-only calls.
+	var statusResult params.FullStatus
+	err = st.APICall("Client", 1, "", "FullStatus", params.StatusParams{}, &statusResult)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = st.APICall("Client", 1, "", "SetModelConstraints", params.SetConstraints{}, nil)
+	c.Assert(err, gc.ErrorMatches, `read-only login cannot call Client.SetModelConstraints: permission denied \(unauthorized access\)`)
+	c.Assert(err, jc.Satisfies, params.IsCodeUnauthorized)
+}
+
+func (s *loginSuite) TestReadOnlyLoginChecksCredentials(c *gc.C) {
+	info, srv := newServer(c, s.State)
+	defer assertStop(c, srv)
+	info.ModelTag = s.State.ModelTag()
+
+	st := s.openAPIWithoutLogin(c, info)
+	request := &params.LoginRequest{
+		AuthTag:     s.AdminUserTag(c).String(),
+		Credentials: "wrong password",
+		ReadOnly:    true,
+	}
+	var response params.LoginResult
+	err := st.APICall("Admin", 3, "", "Login", request, &response)
+	c.Assert(err, gc.ErrorMatches, `invalid entity name or password \(unauthorized access\)`)
+	c.Assert(err, jc.Satisfies, params.IsCodeUnauthorized)
+}
+
+func (s *loginSuite) assertLoginMetrics(c *gc.C, srv *apiserver.Server, attempts, successes uint64, failures map[string]uint64) {
+	gotAttempts, gotSuccesses, gotFailures := apiserver.LoginMetrics(srv)
+	c.Check(gotAttempts, gc.Equals, attempts)
+	c.Check(gotSuccesses, gc.Equals, successes)
+	c.Check(gotFailures, jc.DeepEquals, failures)
+}
+
+func (s *loginSuite) TestLoginMetrics(c *gc.C) {
+	info, srv := newServer(c, s.State)
+	defer assertStop(c, srv)
+	info.ModelTag = s.State.ModelTag()
+	adminUser := s.AdminUserTag(c)
+
+	st := s.openAPIWithoutLogin(c, info)
+	err := st.Login(adminUser, "dummy-secret", "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	st = s.openAPIWithoutLogin(c, info)
+	err = st.Login(adminUser, "wrong password", "", nil)
+	c.Assert(err, jc.Satisfies, params.IsCodeUnauthorized)
+
+	s.assertLoginMetrics(c, srv, 2, 1, map[string]uint64{
+		"bad-credentials": 1,
+	})
+
+	apiserver.ResetLoginMetrics(srv)
+	s.assertLoginMetrics(c, srv, 0, 0, map[string]uint64{})
+}
+
+func (s *loginSuite) TestLoginMetricsDuringMaintenance(c *gc.C) {
+	cfg := defaultServerConfig(c, s.State)
+	cfg.Validator = func(params.LoginRequest) error {
+		return errors.New("something")
+	}
+	info, srv := newServerWithConfig(c, s.State, cfg)
+	defer assertStop(c, srv)
+	info.ModelTag = s.State.ModelTag()
+
+	st := s.openAPIWithoutLogin(c, info)
+	err := st.Login(s.AdminUserTag(c), "dummy-secret", "", nil)
+	c.Assert(err, gc.ErrorMatches, "something")
+
+	s.assertLoginMetrics(c, srv, 1, 0, map[string]uint64{
+		"maintenance": 1,
+	})
+}
+
+func (s *loginSuite) TestLoginMetricsRateLimited(c *gc.C) {
+	info, srv := s.newMachineAndServer(c)
+	defer assertStop(c, srv)
+	delayChan, cleanup := apiserver.DelayLogins()
+	defer cleanup()
+
+	// Max out the rate limit, with one extra login to be rejected.
+	errResults, wg := startNLogins(c, apiserver.LoginRateLimit+1, info)
+	select {
+	case err := <-errResults:
+		c.Check(err, jc.Satisfies, params.IsCodeTryAgain)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for login to get rejected.")
+	}
+	for i := 0; i < apiserver.LoginRateLimit; i++ {
+		delayChan <- struct{}{}
+	}
+	wg.Wait()
+	close(errResults)
+	for err := range errResults {
+		c.Check(err, jc.ErrorIsNil)
+	}
+
+	// The delayed logins are counted just like any others.
+	s.assertLoginMetrics(c, srv,
+		uint64(apiserver.LoginRateLimit+1),
+		uint64(apiserver.LoginRateLimit),
+		map[string]uint64{"rate-limited": 1},
+	)
+}
+
 type validationChecker func(c *gc.C, err error, st api.Connection)
 
 func (s *baseLoginSuite) checkLoginWithValidator(c *gc.C, validator apiserver.LoginValidator, checker validationChecker) {
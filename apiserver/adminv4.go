@@ -0,0 +1,57 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/observer"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// adminAPIV4 is the same as adminAPIV3, with the addition of
+// MacaroonDischargeInfo.
+type adminAPIV4 struct {
+	*adminAPIV3
+}
+
+func newAdminAPIV4(srv *Server, root *apiHandler, apiObserver observer.Observer) interface{} {
+	return &adminAPIV4{
+		newAdminAPIV3(srv, root, apiObserver).(*adminAPIV3),
+	}
+}
+
+// Admin returns an object that provides API access to methods that can be
+// called even when not authenticated.
+func (r *adminAPIV4) Admin(id string) (*adminAPIV4, error) {
+	if id != "" {
+		// Safeguard id for possible future use.
+		return nil, common.ErrBadId
+	}
+	return r, nil
+}
+
+// Login logs in with the provided credentials.  All subsequent requests on the
+// connection will act as the authenticated user.
+func (a *adminAPIV4) Login(req params.LoginRequest) (params.LoginResult, error) {
+	return a.login(req, 4)
+}
+
+// MacaroonDischargeInfo returns the public key of the controller's bakery
+// service for external users, and the URL of the identity service that
+// discharges the third-party caveats in its macaroons, so that clients
+// can discharge them without configuring the identity service
+// themselves. It returns an error if macaroon authentication is not
+// configured.
+func (a *adminAPIV4) MacaroonDischargeInfo() (params.MacaroonDischargeInfoResult, error) {
+	publicKey, dischargeURL, err := a.srv.authCtxt.macaroonDischargeInfo()
+	if err != nil {
+		return params.MacaroonDischargeInfoResult{}, errors.Trace(err)
+	}
+	return params.MacaroonDischargeInfoResult{
+		PublicKey:    publicKey.String(),
+		DischargeURL: dischargeURL,
+	}, nil
+}
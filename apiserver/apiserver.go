@@ -23,6 +23,7 @@ import (
 	"github.com/juju/pubsub"
 	"github.com/juju/utils"
 	"github.com/juju/utils/clock"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/crypto/acme"
 	"golang.org/x/crypto/acme/autocert"
 	"golang.org/x/net/websocket"
@@ -35,6 +36,7 @@ import (
 	"github.com/juju/juju/apiserver/common/apihttp"
 	"github.com/juju/juju/apiserver/observer"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/controller"
 	"github.com/juju/juju/rpc"
 	"github.com/juju/juju/rpc/jsoncodec"
 	"github.com/juju/juju/state"
@@ -43,14 +45,19 @@ import (
 var logger = loggo.GetLogger("juju.apiserver")
 
 // loginRateLimit defines how many concurrent Login requests we will
-// accept
-const loginRateLimit = 10
+// accept until the limit has been read from the controller config.
+const loginRateLimit = controller.DefaultAPILoginRateLimit
+
+// entityRequestLimit defines how many concurrent API calls each entity
+// may make until the limit has been read from the controller config.
+const entityRequestLimit = controller.DefaultAPIEntityRequestLimit
 
 // Server holds the server side of the API.
 type Server struct {
 	tomb              tomb.Tomb
 	clock             clock.Clock
 	pingClock         clock.Clock
+	pingTimeout       PingTimeoutConfig
 	wg                sync.WaitGroup
 	state             *state.State
 	statePool         *state.StatePool
@@ -58,7 +65,10 @@ type Server struct {
 	tag               names.Tag
 	dataDir           string
 	logDir            string
-	limiter           utils.Limiter
+	limiter           *loginLimiter
+	requestLimiter    *entityRequestLimiter
+	loginMetrics      *loginMetrics
+	breaker           *backendBreaker
 	validator         LoginValidator
 	adminAPIFactories map[int]adminAPIFactory
 	modelUUID         string
@@ -70,8 +80,22 @@ type Server struct {
 	certChanged       <-chan params.StateServingInfo
 	tlsConfig         *tls.Config
 	allowModelAccess  bool
+	preferredReplica  bool
 	logSinkWriter     io.WriteCloser
 
+	// draining is closed when Shutdown is called.
+	draining chan struct{}
+
+	// connMu guards conns and drained.
+	connMu sync.Mutex
+
+	// conns holds the open API connections.
+	conns map[*websocket.Conn]struct{}
+
+	// drained, if not nil, is closed when the last API connection
+	// closes during Shutdown.
+	drained chan struct{}
+
 	// mu guards the fields below it.
 	mu sync.Mutex
 
@@ -97,6 +121,7 @@ type LoginValidator func(params.LoginRequest) error
 type ServerConfig struct {
 	Clock       clock.Clock
 	PingClock   clock.Clock
+	PingTimeout PingTimeoutConfig
 	Cert        string
 	Key         string
 	Tag         names.Tag
@@ -121,6 +146,10 @@ type ServerConfig struct {
 	// they don't have access to the controller.
 	AllowModelAccess bool
 
+	// PreferredReadReplica holds whether clients should be told that
+	// this controller is a preferred target for read-only calls.
+	PreferredReadReplica bool
+
 	// NewObserver is a function which will return an observer. This
 	// is used per-connection to instantiate a new observer to be
 	// notified of key events during API requests.
@@ -134,6 +163,11 @@ type ServerConfig struct {
 	// is to support registering the handlers underneath the
 	// "/introspection" prefix.
 	RegisterIntrospectionHandlers func(func(string, http.Handler))
+
+	// PrometheusRegisterer, if non-nil, is the prometheus.Registerer
+	// in which the server's login metrics collector will be
+	// registered.
+	PrometheusRegisterer prometheus.Registerer
 }
 
 func (c *ServerConfig) Validate() error {
@@ -149,6 +183,9 @@ func (c *ServerConfig) Validate() error {
 	if c.StatePool == nil {
 		return errors.NotValidf("missing StatePool")
 	}
+	if err := c.PingTimeout.Validate(); err != nil {
+		return errors.Annotate(err, "validating PingTimeout")
+	}
 
 	return nil
 }
@@ -191,24 +228,32 @@ func newServer(s *state.State, lis net.Listener, cfg ServerConfig) (_ *Server, e
 	}
 
 	srv := &Server{
-		clock:       cfg.Clock,
-		pingClock:   cfg.pingClock(),
-		lis:         lis,
-		newObserver: cfg.NewObserver,
-		state:       s,
-		statePool:   stPool,
-		tag:         cfg.Tag,
-		dataDir:     cfg.DataDir,
-		logDir:      cfg.LogDir,
-		limiter:     utils.NewLimiter(loginRateLimit),
-		validator:   cfg.Validator,
+		clock:          cfg.Clock,
+		pingClock:      cfg.pingClock(),
+		pingTimeout:    cfg.PingTimeout,
+		lis:            lis,
+		newObserver:    cfg.NewObserver,
+		state:          s,
+		statePool:      stPool,
+		tag:            cfg.Tag,
+		dataDir:        cfg.DataDir,
+		logDir:         cfg.LogDir,
+		limiter:        newLoginLimiter(loginRateLimit),
+		requestLimiter: newEntityRequestLimiter(entityRequestLimit),
+		loginMetrics:   newLoginMetrics(),
+		breaker:        newBackendBreaker(cfg.Clock, backendFailureThreshold, backendCooldown),
+		validator:      cfg.Validator,
 		adminAPIFactories: map[int]adminAPIFactory{
 			3: newAdminAPIV3,
+			4: newAdminAPIV4,
 		},
 		centralHub:                    cfg.Hub,
 		certChanged:                   cfg.CertChanged,
 		allowModelAccess:              cfg.AllowModelAccess,
+		preferredReplica:              cfg.PreferredReadReplica,
 		registerIntrospectionHandlers: cfg.RegisterIntrospectionHandlers,
+		draining:                      make(chan struct{}),
+		conns:                         make(map[*websocket.Conn]struct{}),
 	}
 
 	srv.tlsConfig = srv.newTLSConfig(cfg)
@@ -228,12 +273,36 @@ func newServer(s *state.State, lis net.Listener, cfg ServerConfig) (_ *Server, e
 	}
 	srv.logSinkWriter = logSinkWriter
 
+	if cfg.PrometheusRegisterer != nil {
+		cfg.PrometheusRegisterer.Unregister(srv.loginMetrics)
+		if err := cfg.PrometheusRegisterer.Register(srv.loginMetrics); err != nil {
+			return nil, errors.Annotate(err, "registering login metrics")
+		}
+	}
+
 	go srv.run()
 	return srv, nil
 }
 
+// agentServerName is the TLS server name with which agents, and other
+// clients that know the controller's CA certificate, dial the API server.
+const agentServerName = "juju-apiserver"
+
 func (srv *Server) newTLSConfig(cfg ServerConfig) *tls.Config {
 	tlsConfig := utils.SecureTLSConfig()
+	// Ask agents for a certificate, so that they may log in with one,
+	// leaving checking it to the login request. Agents always dial with
+	// the agentServerName SNI; other clients, browsers in particular,
+	// are not asked, so they never prompt the user for a certificate.
+	tlsConfig.GetConfigForClient = func(clientHello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if clientHello.ServerName != agentServerName {
+			return nil, nil
+		}
+		agentConfig := tlsConfig.Clone()
+		agentConfig.GetConfigForClient = nil
+		agentConfig.ClientAuth = tls.RequestClientCert
+		return agentConfig, nil
+	}
 	if cfg.AutocertDNSName == "" {
 		// No official DNS name, no certificate.
 		tlsConfig.GetCertificate = func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
@@ -355,6 +424,12 @@ func (srv *Server) run() {
 		srv.tomb.Kill(srv.processModelRemovals())
 	}()
 
+	srv.wg.Add(1)
+	go func() {
+		defer srv.wg.Done()
+		srv.tomb.Kill(srv.refreshLimits())
+	}()
+
 	// for pat based handlers, they are matched in-order of being
 	// registered, first match wins. So more specific ones have to be
 	// registered first.
@@ -480,6 +555,12 @@ func (srv *Server) endpoints() []apihttp.Endpoint {
 			stateAuthFunc: httpCtxt.stateForMigrationImporting,
 		},
 	)
+	add("/model/:modeluuid/applications/:application/resources/:resource/signed",
+		&signedResourceDownloadHandler{
+			ctxt:  httpCtxt,
+			clock: srv.clock,
+		},
+	)
 	add("/model/:modeluuid/tools/:version",
 		&toolsDownloadHandler{
 			ctxt: httpCtxt,
@@ -615,6 +696,10 @@ func (srv *Server) trackRequests(handler http.Handler) http.Handler {
 			// shutting down, do not consider this request as in progress,
 			// just send a 503 and return.
 			http.Error(w, "apiserver shutdown in progress", 503)
+		case <-srv.draining:
+			// Likewise, no new requests are accepted once Shutdown
+			// has been called.
+			http.Error(w, "apiserver shutdown in progress", 503)
 		default:
 			// If we get here then the tomb was not killed therefore the
 			// listener is still open. It is safe to increment the
@@ -651,7 +736,11 @@ func (srv *Server) apiHandler(w http.ResponseWriter, req *http.Request) {
 		Handler: func(conn *websocket.Conn) {
 			modelUUID := req.URL.Query().Get(":modeluuid")
 			logger.Tracef("got a request for model %q", modelUUID)
-			if err := srv.serveConn(conn, modelUUID, apiObserver, req.Host); err != nil {
+			var peerCertificates []*x509.Certificate
+			if req.TLS != nil {
+				peerCertificates = req.TLS.PeerCertificates
+			}
+			if err := srv.serveConn(conn, modelUUID, apiObserver, req.Host, peerCertificates); err != nil {
 				logger.Errorf("error serving RPCs: %v", err)
 			}
 		},
@@ -659,7 +748,18 @@ func (srv *Server) apiHandler(w http.ResponseWriter, req *http.Request) {
 	wsServer.ServeHTTP(w, req)
 }
 
-func (srv *Server) serveConn(wsConn *websocket.Conn, modelUUID string, apiObserver observer.Observer, host string) error {
+func (srv *Server) serveConn(
+	wsConn *websocket.Conn,
+	modelUUID string,
+	apiObserver observer.Observer,
+	host string,
+	peerCertificates []*x509.Certificate,
+) error {
+	if !srv.addConn(wsConn) {
+		wsConn.Close()
+		return errors.New("apiserver shutdown in progress")
+	}
+	defer srv.removeConn(wsConn)
 	codec := jsoncodec.NewWebsocket(wsConn)
 
 	conn := rpc.NewConn(codec, apiObserver)
@@ -684,6 +784,9 @@ func (srv *Server) serveConn(wsConn *websocket.Conn, modelUUID string, apiObserv
 		defer releaser()
 		h, err = newAPIHandler(srv, st, conn, modelUUID, host)
 	}
+	if err == nil {
+		h.peerCertificates = peerCertificates
+	}
 
 	if err != nil {
 		conn.ServeRoot(&errRoot{errors.Trace(err)}, serverError)
@@ -698,17 +801,31 @@ func (srv *Server) serveConn(wsConn *websocket.Conn, modelUUID string, apiObserv
 	select {
 	case <-conn.Dead():
 	case <-srv.tomb.Dying():
+	case <-srv.draining:
+		// Closing the connection lets the calls in flight finish,
+		// while refusing new ones.
 	}
 	return conn.Close()
 }
 
+// mongoPinger pings mongo periodically and feeds the results into the
+// backend breaker, so that facade calls fail fast while mongo is
+// unreachable. No pings are made while the breaker is open. If mongo
+// has still not recovered when the breaker half-opens, mongoPinger
+// returns an error, so that the server is restarted.
 func (srv *Server) mongoPinger() error {
 	session := srv.state.MongoSession().Copy()
 	defer session.Close()
 	for {
-		if err := session.Ping(); err != nil {
-			logger.Infof("got error pinging mongo: %v", err)
-			return errors.Annotate(err, "error pinging mongo")
+		if srv.breaker.State() != breakerOpen {
+			err := session.Ping()
+			if err != nil {
+				logger.Infof("got error pinging mongo: %v", err)
+				session.Refresh()
+			}
+			if err := srv.breaker.RecordPing(err); err != nil {
+				return errors.Annotate(err, "error pinging mongo")
+			}
 		}
 		select {
 		case <-srv.clock.After(mongoPingInterval):
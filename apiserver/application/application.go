@@ -29,6 +29,7 @@ import (
 	jjj "github.com/juju/juju/juju"
 	"github.com/juju/juju/permission"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/multiwatcher"
 )
 
 var logger = loggo.GetLogger("juju.apiserver.application")
@@ -42,6 +43,9 @@ func init() {
 
 	// Version 3 adds support for cross model relations.
 	common.RegisterStandardFacade("Application", 3, newAPI)
+
+	// Version 4 adds forced DestroyRelation and RelationDetails.
+	common.RegisterStandardFacade("Application", 4, newAPIv4)
 }
 
 // API implements the application interface and is the concrete
@@ -60,6 +64,24 @@ type API struct {
 	stateCharm func(Charm) *state.Charm
 }
 
+// APIv4 implements version 4 of the application facade, which adds
+// forced DestroyRelation and RelationDetails.
+type APIv4 struct {
+	*API
+}
+
+func newAPIv4(
+	st *state.State,
+	resources facade.Resources,
+	authorizer facade.Authorizer,
+) (*APIv4, error) {
+	api, err := newAPI(st, resources, authorizer)
+	if err != nil {
+		return nil, err
+	}
+	return &APIv4{api}, nil
+}
+
 func newAPI(
 	st *state.State,
 	resources facade.Resources,
@@ -1212,15 +1234,55 @@ func (api *API) consumeOne(possibleURL, alias string) (string, error) {
 	return remoteApp.Name(), nil
 }
 
+// RelationDetails returns the relation between the specified endpoints,
+// as DestroyRelation would resolve it, without changing it.
+func (api *APIv4) RelationDetails(args params.RelationEndpoints) (params.RelationDetails, error) {
+	if err := api.checkCanRead(); err != nil {
+		return params.RelationDetails{}, errors.Trace(err)
+	}
+	eps, err := api.backend.InferEndpoints(args.Endpoints...)
+	if err != nil {
+		return params.RelationDetails{}, err
+	}
+	rel, err := api.backend.EndpointsRelation(eps...)
+	if err != nil {
+		return params.RelationDetails{}, err
+	}
+	details := params.RelationDetails{
+		Id:        rel.Id(),
+		Key:       rel.String(),
+		UnitCount: rel.UnitCount(),
+	}
+	for _, ep := range rel.Endpoints() {
+		details.Endpoints = append(details.Endpoints, multiwatcher.Endpoint{
+			ApplicationName: ep.ApplicationName,
+			Relation:        multiwatcher.NewCharmRelation(ep.Relation),
+		})
+	}
+	return details, nil
+}
+
 // DestroyRelation removes the relation between the specified endpoints.
+// args.Force was added in version 4, so it is ignored.
 func (api *API) DestroyRelation(args params.DestroyRelation) error {
+	return api.destroyRelation(args.Endpoints, false)
+}
+
+// DestroyRelation removes the relation between the specified endpoints.
+// If args.Force is set, any units in the relation's scope are removed
+// from it straight away, without running their hooks.
+func (api *APIv4) DestroyRelation(args params.DestroyRelation) error {
+	return api.destroyRelation(args.Endpoints, args.Force)
+}
+
+func (api *API) destroyRelation(endpoints []string, force bool) error {
 	if err := api.checkCanWrite(); err != nil {
 		return err
 	}
 	if err := api.check.RemoveAllowed(); err != nil {
 		return errors.Trace(err)
 	}
-	eps, err := api.backend.InferEndpoints(args.Endpoints...)
+	eps, err := api.backend.InferEndpoints(endpoints...)
 	if err != nil {
 		return err
 	}
@@ -1228,5 +1290,8 @@ func (api *API) DestroyRelation(args params.DestroyRelation) error {
 	if err != nil {
 		return err
 	}
+	if force {
+		return rel.DestroyWithForce()
+	}
 	return rel.Destroy()
 }
@@ -32,6 +32,7 @@ import (
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/instance"
 	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/rpc/rpcreflect"
 	"github.com/juju/juju/state"
 	statestorage "github.com/juju/juju/state/storage"
 	"github.com/juju/juju/status"
@@ -2823,6 +2824,106 @@ func (s *serviceSuite) TestSuccessfullyDestroyRelationSwapped(c *gc.C) {
 	s.assertDestroyRelation(c, endpoints)
 }
 
+func (s *serviceSuite) TestForceDestroyRelationWithUnitInScope(c *gc.C) {
+	endpoints := []string{"wordpress", "mysql"}
+	relation := s.setupDestroyRelationScenario(c, endpoints)
+	wordpress, err := s.State.Application("wordpress")
+	c.Assert(err, jc.ErrorIsNil)
+	unit, err := wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	ru, err := relation.Unit(unit)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ru.EnterScope(nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	apiv4 := &application.APIv4{API: s.applicationAPI}
+	err = apiv4.DestroyRelation(params.DestroyRelation{
+		Endpoints: endpoints,
+		Force:     true,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(relation.Refresh(), jc.Satisfies, errors.IsNotFound)
+	inScope, err := ru.InScope()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(inScope, jc.IsFalse)
+}
+
+func (s *serviceSuite) TestDestroyRelationIgnoresForceBeforeVersion4(c *gc.C) {
+	endpoints := []string{"wordpress", "mysql"}
+	relation := s.setupDestroyRelationScenario(c, endpoints)
+	wordpress, err := s.State.Application("wordpress")
+	c.Assert(err, jc.ErrorIsNil)
+	unit, err := wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	ru, err := relation.Unit(unit)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ru.EnterScope(nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.applicationAPI.DestroyRelation(params.DestroyRelation{
+		Endpoints: endpoints,
+		Force:     true,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	err = relation.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(relation.Life(), gc.Equals, state.Dying)
+	inScope, err := ru.InScope()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(inScope, jc.IsTrue)
+}
+
+func (s *serviceSuite) TestRelationDetailsOnlyInVersion4(c *gc.C) {
+	for version := 1; version <= 4; version++ {
+		facadeType, err := common.Facades.GetType("Application", version)
+		c.Assert(err, jc.ErrorIsNil)
+		_, err = rpcreflect.ObjTypeOf(facadeType).Method("RelationDetails")
+		if version < 4 {
+			c.Check(err, gc.Equals, rpcreflect.ErrMethodNotFound, gc.Commentf("version %d", version))
+		} else {
+			c.Check(err, jc.ErrorIsNil)
+		}
+	}
+}
+
+func (s *serviceSuite) TestRelationDetails(c *gc.C) {
+	endpoints := []string{"wordpress", "mysql"}
+	relation := s.setupDestroyRelationScenario(c, endpoints)
+	wordpress, err := s.State.Application("wordpress")
+	c.Assert(err, jc.ErrorIsNil)
+	unit, err := wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	ru, err := relation.Unit(unit)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ru.EnterScope(nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	apiv4 := &application.APIv4{API: s.applicationAPI}
+	details, err := apiv4.RelationDetails(params.RelationEndpoints{Endpoints: endpoints})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(details.Id, gc.Equals, relation.Id())
+	c.Assert(details.Key, gc.Equals, "wordpress:db mysql:server")
+	c.Assert(details.UnitCount, gc.Equals, 1)
+	c.Assert(details.Endpoints, gc.HasLen, 2)
+	var names []string
+	for _, ep := range details.Endpoints {
+		names = append(names, ep.ApplicationName+":"+ep.Relation.Name)
+	}
+	c.Assert(names, jc.SameContents, []string{"wordpress:db", "mysql:server"})
+
+	// The relation is left alone.
+	err = relation.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(relation.Life(), gc.Equals, state.Alive)
+}
+
+func (s *serviceSuite) TestRelationDetailsNoRelation(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	apiv4 := &application.APIv4{API: s.applicationAPI}
+	_, err := apiv4.RelationDetails(params.RelationEndpoints{Endpoints: []string{"wordpress", "mysql"}})
+	c.Assert(err, gc.ErrorMatches, `relation "wordpress:db mysql:server" not found`)
+}
+
 func (s *serviceSuite) TestNoRelation(c *gc.C) {
 	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
 	endpoints := []string{"wordpress", "mysql"}
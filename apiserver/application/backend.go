@@ -90,7 +90,12 @@ type Machine interface {
 // the same names.
 type Relation interface {
 	Destroy() error
+	DestroyWithForce() error
 	Endpoint(string) (state.Endpoint, error)
+	Endpoints() []state.Endpoint
+	Id() int
+	String() string
+	UnitCount() int
 }
 
 // Unit defines a subset of the functionality provided by the
@@ -4,12 +4,14 @@
 package apiserver
 
 import (
+	"crypto/x509"
 	"net/http"
 	"net/url"
 	"sync"
 	"time"
 
 	"github.com/juju/errors"
+	utilscert "github.com/juju/utils/cert"
 	"github.com/juju/utils/clock"
 	"gopkg.in/juju/names.v2"
 	"gopkg.in/macaroon-bakery.v1/bakery"
@@ -20,6 +22,7 @@ import (
 	"github.com/juju/juju/apiserver/authentication"
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cert"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/bakerystorage"
 )
@@ -128,26 +131,38 @@ func (ctxt *authContext) CheckLocalLoginRequest(req *http.Request, tag names.Use
 }
 
 // authenticator returns an authenticator.EntityAuthenticator for the API
-// connection associated with the specified API server host.
-func (ctxt *authContext) authenticator(serverHost string) authenticator {
-	return authenticator{ctxt: ctxt, serverHost: serverHost}
+// connection associated with the specified API server host, on which
+// the client presented the given TLS certificates to log in to the
+// model with the given UUID.
+func (ctxt *authContext) authenticator(serverHost, modelUUID string, peerCertificates []*x509.Certificate) authenticator {
+	return authenticator{
+		ctxt:             ctxt,
+		serverHost:       serverHost,
+		modelUUID:        modelUUID,
+		peerCertificates: peerCertificates,
+	}
 }
 
 // authenticator implements authenticator.EntityAuthenticator, delegating
 // to the appropriate authenticator based on the tag kind.
 type authenticator struct {
-	ctxt       *authContext
-	serverHost string
+	ctxt             *authContext
+	serverHost       string
+	modelUUID        string
+	peerCertificates []*x509.Certificate
 }
 
 // Authenticate implements authentication.EntityAuthenticator
 // by choosing the right kind of authentication for the given
-// tag.
+// tag, or certificate authentication if the request asks for it.
 func (a authenticator) Authenticate(
 	entityFinder authentication.EntityFinder,
 	tag names.Tag,
 	req params.LoginRequest,
 ) (state.Entity, error) {
+	if req.CertAuth {
+		return a.certAuth().Authenticate(entityFinder, tag, req)
+	}
 	auth, err := a.authenticatorForTag(tag)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -155,6 +170,17 @@ func (a authenticator) Authenticate(
 	return auth.Authenticate(entityFinder, tag, req)
 }
 
+// certAuth returns an authenticator that authenticates agents by the
+// TLS client certificate presented on the connection.
+func (a authenticator) certAuth() *certAuthenticator {
+	return &certAuthenticator{
+		st:               a.ctxt.st,
+		clock:            a.ctxt.clock,
+		modelUUID:        a.modelUUID,
+		peerCertificates: a.peerCertificates,
+	}
+}
+
 // authenticatorForTag returns the authenticator appropriate
 // to use for a login with the given possibly-nil tag.
 func (a authenticator) authenticatorForTag(tag names.Tag) (authentication.EntityAuthenticator, error) {
@@ -193,6 +219,111 @@ func (a authenticator) localUserAuth() *authentication.UserAuthenticator {
 	}
 }
 
+// certAuthenticator implements authentication.EntityAuthenticator by
+// checking the TLS client certificate presented on the connection. The
+// certificate must have been issued by the controller's CA for client
+// authentication, must be current and not revoked, must have been
+// issued for this controller and the model being logged in to, and its
+// subject's common name must be the tag of the entity logging in. Only
+// machine and unit agents may log in this way.
+type certAuthenticator struct {
+	st               *state.State
+	clock            clock.Clock
+	modelUUID        string
+	peerCertificates []*x509.Certificate
+}
+
+// Authenticate implements authentication.EntityAuthenticator.
+func (a *certAuthenticator) Authenticate(
+	entityFinder authentication.EntityFinder,
+	tag names.Tag,
+	req params.LoginRequest,
+) (state.Entity, error) {
+	if tag == nil {
+		return nil, errors.Annotate(common.ErrBadRequest, "certificate login requires an entity tag")
+	}
+	switch tag.Kind() {
+	case names.MachineTagKind, names.UnitTagKind:
+	default:
+		return nil, errors.Annotatef(common.ErrBadRequest, "certificate login not supported for %s", tag.Kind())
+	}
+	if len(a.peerCertificates) == 0 {
+		return nil, errors.Trace(common.ErrNoCreds)
+	}
+	clientCert := a.peerCertificates[0]
+	if err := a.verify(clientCert); err != nil {
+		logger.Debugf("rejecting client certificate for %s: %v", tag, err)
+		return nil, errors.Trace(common.ErrBadCreds)
+	}
+	if clientCert.Subject.CommonName != tag.String() {
+		logger.Debugf("client certificate for %q used to log in as %s", clientCert.Subject.CommonName, tag)
+		return nil, errors.Trace(common.ErrBadCreds)
+	}
+	// The tag only names the entity within a model, so the certificate
+	// must also have been issued for this controller and model.
+	controllerUUID, modelUUID := cert.ClientScope(clientCert)
+	if controllerUUID != a.st.ControllerUUID() || modelUUID != a.modelUUID {
+		logger.Debugf(
+			"client certificate for %s in model %q of controller %q used to log in to model %q",
+			tag, modelUUID, controllerUUID, a.modelUUID,
+		)
+		return nil, errors.Trace(common.ErrBadCreds)
+	}
+	entity, err := entityFinder.FindEntity(tag)
+	if errors.IsNotFound(err) {
+		return nil, errors.Trace(common.ErrBadCreds)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// As with password logins, check that a machine agent is the one
+	// the machine was provisioned with.
+	if machine, ok := entity.(*state.Machine); ok {
+		if !machine.CheckProvisioned(req.Nonce) {
+			return nil, errors.NotProvisionedf("machine %v", machine.Id())
+		}
+	}
+	return entity, nil
+}
+
+// verify checks that the given client certificate chains to the
+// controller's CA, is valid now for client authentication, and has not
+// been revoked.
+func (a *certAuthenticator) verify(clientCert *x509.Certificate) error {
+	caCertPEM, err := getControllerCACert(a.st)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	caCert, err := utilscert.ParseCert(caCertPEM)
+	if err != nil {
+		return errors.Annotate(err, "cannot parse controller CA certificate")
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range a.peerCertificates[1:] {
+		intermediates.AddCert(intermediate)
+	}
+	if _, err := clientCert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   a.clock.Now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return errors.Trace(err)
+	}
+	controllerCfg, err := a.st.ControllerConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, serial := range controllerCfg.RevokedClientCertificates() {
+		if clientCert.SerialNumber.Cmp(serial) == 0 {
+			return errors.Errorf("certificate %x has been revoked", clientCert.SerialNumber)
+		}
+	}
+	return nil
+}
+
 // externalMacaroonAuth returns an authenticator that can authenticate macaroon-based
 // logins for external users. If it fails once, it will always fail.
 func (ctxt *authContext) externalMacaroonAuth() (authentication.EntityAuthenticator, error) {
@@ -205,6 +336,24 @@ func (ctxt *authContext) externalMacaroonAuth() (authentication.EntityAuthentica
 	return ctxt._macaroonAuth, nil
 }
 
+// macaroonDischargeInfo returns the public key of the bakery service
+// that verifies macaroon-based logins for external users, and the URL of
+// the identity service that discharges the third-party caveats in their
+// macaroons. It returns an error with errMacaroonAuthNotConfigured as its
+// cause if no identity service is configured.
+func (ctxt *authContext) macaroonDischargeInfo() (*bakery.PublicKey, string, error) {
+	auth, err := ctxt.externalMacaroonAuth()
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	macaroonAuth := auth.(*authentication.ExternalMacaroonAuthenticator)
+	svc, ok := macaroonAuth.Service.(*bakery.Service)
+	if !ok {
+		return nil, "", errors.Errorf("unexpected bakery service type %T", macaroonAuth.Service)
+	}
+	return svc.PublicKey(), macaroonAuth.IdentityLocation, nil
+}
+
 var errMacaroonAuthNotConfigured = errors.New("macaroon authentication is not configured")
 
 // newExternalMacaroonAuth returns an authenticator that can authenticate
@@ -4,15 +4,26 @@
 package apiserver_test
 
 import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	utilscert "github.com/juju/utils/cert"
 	gc "gopkg.in/check.v1"
 	"gopkg.in/juju/names.v2"
 
 	"github.com/juju/juju/apiserver"
 	"github.com/juju/juju/apiserver/authentication"
+	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/controller"
 	"github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
 	"github.com/juju/juju/testing/factory"
 )
 
@@ -73,3 +84,133 @@ func (s *agentAuthenticatorSuite) TestNotSupportedTag(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "unexpected login entity tag: invalid request")
 	c.Assert(authenticator, gc.IsNil)
 }
+
+type certAuthenticatorSuite struct {
+	testing.JujuConnSuite
+	machine *state.Machine
+}
+
+var _ = gc.Suite(&certAuthenticatorSuite{})
+
+func (s *certAuthenticatorSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	apiserver.PatchGetControllerCACert(s, coretesting.CACert)
+	s.machine = s.Factory.MakeMachine(c, &factory.MachineParams{Nonce: "fake_nonce"})
+}
+
+func (s *certAuthenticatorSuite) clientCert(c *gc.C, caCert, caKey string, expiry time.Time, tag names.Tag) *x509.Certificate {
+	return s.scopedClientCert(c, caCert, caKey, expiry, tag, s.State.ControllerUUID(), s.State.ModelUUID())
+}
+
+func (s *certAuthenticatorSuite) scopedClientCert(
+	c *gc.C, caCert, caKey string, expiry time.Time, tag names.Tag, controllerUUID, modelUUID string,
+) *x509.Certificate {
+	certPEM, _, err := cert.NewClient(caCert, caKey, expiry, tag.String(), controllerUUID, modelUUID)
+	c.Assert(err, jc.ErrorIsNil)
+	clientCert, err := utilscert.ParseCert(certPEM)
+	c.Assert(err, jc.ErrorIsNil)
+	return clientCert
+}
+
+func (s *certAuthenticatorSuite) authenticate(c *gc.C, tag names.Tag, peerCertificates ...*x509.Certificate) (state.Entity, error) {
+	_, srv := newServer(c, s.State)
+	defer assertStop(c, srv)
+	authenticator := apiserver.ServerAuthenticator(srv, s.State.ModelUUID(), peerCertificates)
+	return authenticator.Authenticate(s.State, tag, params.LoginRequest{
+		AuthTag:  tag.String(),
+		Nonce:    "fake_nonce",
+		CertAuth: true,
+	})
+}
+
+func (s *certAuthenticatorSuite) TestValidCertificate(c *gc.C) {
+	clientCert := s.clientCert(c, coretesting.CACert, coretesting.CAKey, time.Now().Add(time.Hour), s.machine.Tag())
+	entity, err := s.authenticate(c, s.machine.Tag(), clientCert)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(entity.Tag(), gc.Equals, s.machine.Tag())
+}
+
+func (s *certAuthenticatorSuite) TestNoCertificate(c *gc.C) {
+	_, err := s.authenticate(c, s.machine.Tag())
+	c.Assert(errors.Cause(err), gc.Equals, common.ErrNoCreds)
+}
+
+func (s *certAuthenticatorSuite) TestCertificateForOtherEntity(c *gc.C) {
+	other := s.Factory.MakeMachine(c, nil)
+	clientCert := s.clientCert(c, coretesting.CACert, coretesting.CAKey, time.Now().Add(time.Hour), other.Tag())
+	_, err := s.authenticate(c, s.machine.Tag(), clientCert)
+	c.Assert(errors.Cause(err), gc.Equals, common.ErrBadCreds)
+}
+
+func (s *certAuthenticatorSuite) TestCertificateForOtherModel(c *gc.C) {
+	clientCert := s.scopedClientCert(
+		c, coretesting.CACert, coretesting.CAKey, time.Now().Add(time.Hour), s.machine.Tag(),
+		s.State.ControllerUUID(), utils.MustNewUUID().String(),
+	)
+	_, err := s.authenticate(c, s.machine.Tag(), clientCert)
+	c.Assert(errors.Cause(err), gc.Equals, common.ErrBadCreds)
+}
+
+func (s *certAuthenticatorSuite) TestCertificateForOtherController(c *gc.C) {
+	clientCert := s.scopedClientCert(
+		c, coretesting.CACert, coretesting.CAKey, time.Now().Add(time.Hour), s.machine.Tag(),
+		utils.MustNewUUID().String(), s.State.ModelUUID(),
+	)
+	_, err := s.authenticate(c, s.machine.Tag(), clientCert)
+	c.Assert(errors.Cause(err), gc.Equals, common.ErrBadCreds)
+}
+
+func (s *certAuthenticatorSuite) TestUnitCertificateForOtherModel(c *gc.C) {
+	unit := s.Factory.MakeUnit(c, nil)
+	clientCert := s.scopedClientCert(
+		c, coretesting.CACert, coretesting.CAKey, time.Now().Add(time.Hour), unit.Tag(),
+		s.State.ControllerUUID(), utils.MustNewUUID().String(),
+	)
+	_, err := s.authenticate(c, unit.Tag(), clientCert)
+	c.Assert(errors.Cause(err), gc.Equals, common.ErrBadCreds)
+}
+
+func (s *certAuthenticatorSuite) TestExpiredCertificate(c *gc.C) {
+	clientCert := s.clientCert(c, coretesting.CACert, coretesting.CAKey, time.Now().Add(-time.Hour), s.machine.Tag())
+	_, err := s.authenticate(c, s.machine.Tag(), clientCert)
+	c.Assert(errors.Cause(err), gc.Equals, common.ErrBadCreds)
+}
+
+func (s *certAuthenticatorSuite) TestCertificateFromOtherCA(c *gc.C) {
+	otherCACert, otherCAKey, err := cert.NewCA("other", "1", time.Now().Add(time.Hour))
+	c.Assert(err, jc.ErrorIsNil)
+	clientCert := s.clientCert(c, otherCACert, otherCAKey, time.Now().Add(time.Hour), s.machine.Tag())
+	_, err = s.authenticate(c, s.machine.Tag(), clientCert)
+	c.Assert(errors.Cause(err), gc.Equals, common.ErrBadCreds)
+}
+
+func (s *certAuthenticatorSuite) TestRevokedCertificate(c *gc.C) {
+	clientCert := s.clientCert(c, coretesting.CACert, coretesting.CAKey, time.Now().Add(time.Hour), s.machine.Tag())
+	err := s.State.UpdateControllerConfig(map[string]interface{}{
+		controller.RevokedClientCertificates: []interface{}{fmt.Sprintf("%x", clientCert.SerialNumber)},
+	}, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.authenticate(c, s.machine.Tag(), clientCert)
+	c.Assert(errors.Cause(err), gc.Equals, common.ErrBadCreds)
+}
+
+func (s *certAuthenticatorSuite) TestWrongNonce(c *gc.C) {
+	clientCert := s.clientCert(c, coretesting.CACert, coretesting.CAKey, time.Now().Add(time.Hour), s.machine.Tag())
+	_, srv := newServer(c, s.State)
+	defer assertStop(c, srv)
+	authenticator := apiserver.ServerAuthenticator(srv, s.State.ModelUUID(), []*x509.Certificate{clientCert})
+	_, err := authenticator.Authenticate(s.State, s.machine.Tag(), params.LoginRequest{
+		AuthTag:  s.machine.Tag().String(),
+		Nonce:    "wrong_nonce",
+		CertAuth: true,
+	})
+	c.Assert(err, jc.Satisfies, errors.IsNotProvisioned)
+}
+
+func (s *certAuthenticatorSuite) TestUserNotSupported(c *gc.C) {
+	user := s.Factory.MakeUser(c, nil)
+	clientCert := s.clientCert(c, coretesting.CACert, coretesting.CAKey, time.Now().Add(time.Hour), user.Tag())
+	_, err := s.authenticate(c, user.Tag(), clientCert)
+	c.Assert(err, gc.ErrorMatches, "certificate login not supported for user: invalid request")
+}
@@ -17,6 +17,7 @@ import (
 
 func init() {
 	common.RegisterStandardFacade("Backups", 1, newAPI)
+	common.RegisterUpgradeSafeMethods("Backups", "FinishRestore")
 }
 
 type stateShim struct {
@@ -0,0 +1,126 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/utils/clock"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+var (
+	// backendFailureThreshold defines how many consecutive failed
+	// mongo pings trip the backend breaker open.
+	backendFailureThreshold = 5
+
+	// backendCooldown defines how long the backend breaker stays open
+	// before it lets a single mongo ping through to probe for
+	// recovery.
+	backendCooldown = 30 * time.Second
+)
+
+// breakerState describes whether a backendBreaker lets calls through.
+type breakerState string
+
+const (
+	// breakerClosed lets all calls through.
+	breakerClosed breakerState = "closed"
+
+	// breakerOpen fails all calls without attempting them, and stops
+	// mongo being pinged until the cooldown has passed.
+	breakerOpen breakerState = "open"
+
+	// breakerHalfOpen still fails all calls, but lets the next mongo
+	// ping through to probe whether mongo has recovered.
+	breakerHalfOpen breakerState = "half-open"
+)
+
+// errBackendUnavailable is returned to clients while the backend
+// breaker is not closed.
+var errBackendUnavailable = &params.Error{
+	Message: "backend unavailable, try again later",
+	Code:    params.CodeBackendUnavailable,
+}
+
+// backendBreaker is a circuit breaker guarding access to the mongo
+// backend. It is driven only by the API server's mongo pings, so that
+// failing calls to other services, such as the charm store or a cloud
+// provider, cannot trip it. Once threshold consecutive pings have
+// failed it opens and fails calls immediately; after cooldown it
+// half-opens and the next ping probes whether mongo recovered.
+type backendBreaker struct {
+	clock     clock.Clock
+	threshold int
+	cooldown  time.Duration
+
+	// mu guards the fields below it.
+	mu       sync.Mutex
+	open     bool
+	failures int
+	openedAt time.Time
+}
+
+// newBackendBreaker returns a closed backendBreaker.
+func newBackendBreaker(clock clock.Clock, threshold int, cooldown time.Duration) *backendBreaker {
+	return &backendBreaker{
+		clock:     clock,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *backendBreaker) State() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state()
+}
+
+func (b *backendBreaker) state() breakerState {
+	switch {
+	case !b.open:
+		return breakerClosed
+	case b.clock.Now().Sub(b.openedAt) < b.cooldown:
+		return breakerOpen
+	}
+	return breakerHalfOpen
+}
+
+// Allow returns an error if a call to the backend should not be
+// attempted.
+func (b *backendBreaker) Allow() error {
+	if b.State() != breakerClosed {
+		return errBackendUnavailable
+	}
+	return nil
+}
+
+// RecordPing records the result of a mongo ping. It returns the ping
+// error if the ping was the half-open probe, meaning that mongo has
+// not recovered within the cooldown.
+func (b *backendBreaker) RecordPing(err error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		if b.open {
+			logger.Infof("backend breaker closed, backend recovered")
+		}
+		b.open = false
+		b.failures = 0
+		return nil
+	}
+	if b.state() == breakerHalfOpen {
+		return err
+	}
+	b.failures++
+	if !b.open && b.failures >= b.threshold {
+		logger.Warningf("backend breaker open after %d failed mongo pings", b.failures)
+		b.open = true
+		b.openedAt = b.clock.Now()
+	}
+	return nil
+}
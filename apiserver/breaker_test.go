@@ -0,0 +1,143 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/juju/errors"
+	jujutesting "github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/testing"
+)
+
+type breakerSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&breakerSuite{})
+
+var errPing = errors.New("no reachable servers")
+
+func (s *breakerSuite) TestClosedAllowsCalls(c *gc.C) {
+	clock := jujutesting.NewClock(time.Now())
+	breaker := apiserver.NewBackendBreaker(clock, 3, time.Minute)
+	c.Assert(breaker.State(), gc.Equals, apiserver.BreakerClosed)
+
+	for i := 0; i < 2; i++ {
+		c.Assert(breaker.RecordPing(errPing), jc.ErrorIsNil)
+	}
+	c.Assert(breaker.State(), gc.Equals, apiserver.BreakerClosed)
+	c.Assert(breaker.Allow(), jc.ErrorIsNil)
+
+	// A successful ping resets the failure count.
+	c.Assert(breaker.RecordPing(nil), jc.ErrorIsNil)
+	for i := 0; i < 2; i++ {
+		c.Assert(breaker.RecordPing(errPing), jc.ErrorIsNil)
+	}
+	c.Assert(breaker.State(), gc.Equals, apiserver.BreakerClosed)
+}
+
+func (s *breakerSuite) TestOpensAfterThreshold(c *gc.C) {
+	clock := jujutesting.NewClock(time.Now())
+	breaker := apiserver.NewBackendBreaker(clock, 3, time.Minute)
+	for i := 0; i < 3; i++ {
+		c.Assert(breaker.RecordPing(errPing), jc.ErrorIsNil)
+	}
+	c.Assert(breaker.State(), gc.Equals, apiserver.BreakerOpen)
+
+	err := breaker.Allow()
+	c.Assert(err, gc.ErrorMatches, "backend unavailable, try again later")
+	c.Assert(params.IsCodeBackendUnavailable(err), jc.IsTrue)
+
+	clock.Advance(time.Minute - time.Second)
+	c.Assert(breaker.State(), gc.Equals, apiserver.BreakerOpen)
+	c.Assert(breaker.Allow(), gc.NotNil)
+}
+
+func (s *breakerSuite) TestHalfOpenProbeSuccessCloses(c *gc.C) {
+	clock := jujutesting.NewClock(time.Now())
+	breaker := apiserver.NewBackendBreaker(clock, 1, time.Minute)
+	c.Assert(breaker.RecordPing(errPing), jc.ErrorIsNil)
+	c.Assert(breaker.State(), gc.Equals, apiserver.BreakerOpen)
+
+	clock.Advance(time.Minute)
+	c.Assert(breaker.State(), gc.Equals, apiserver.BreakerHalfOpen)
+
+	// Calls still fail until the probe ping succeeds.
+	c.Assert(breaker.Allow(), gc.ErrorMatches, "backend unavailable, try again later")
+
+	c.Assert(breaker.RecordPing(nil), jc.ErrorIsNil)
+	c.Assert(breaker.State(), gc.Equals, apiserver.BreakerClosed)
+	c.Assert(breaker.Allow(), jc.ErrorIsNil)
+}
+
+func (s *breakerSuite) TestHalfOpenProbeFailureIsFatal(c *gc.C) {
+	clock := jujutesting.NewClock(time.Now())
+	breaker := apiserver.NewBackendBreaker(clock, 1, time.Minute)
+	c.Assert(breaker.RecordPing(errPing), jc.ErrorIsNil)
+
+	clock.Advance(time.Minute)
+	err := breaker.RecordPing(errPing)
+	c.Assert(err, gc.Equals, errPing)
+	c.Assert(breaker.Allow(), gc.NotNil)
+}
+
+func (s *breakerSuite) TestExternalServiceErrorsDoNotTrip(c *gc.C) {
+	clock := jujutesting.NewClock(time.Now())
+	breaker := apiserver.NewBackendBreaker(clock, 1, time.Minute)
+	caller := apiserver.NewBreakerCaller(c, breaker, externalFacade{}, "Fetch")
+
+	// The charm store, a cloud provider or a remote controller being
+	// unreachable says nothing about mongo.
+	for i := 0; i < 3; i++ {
+		_, err := caller.Call("", reflect.Value{})
+		c.Assert(err, gc.ErrorMatches, "dial tcp: i/o timeout")
+		_, ok := err.(net.Error)
+		c.Assert(ok, jc.IsTrue)
+	}
+	c.Assert(breaker.State(), gc.Equals, apiserver.BreakerClosed)
+}
+
+func (s *breakerSuite) TestOpenBreakerFailsCalls(c *gc.C) {
+	clock := jujutesting.NewClock(time.Now())
+	breaker := apiserver.NewBackendBreaker(clock, 1, time.Minute)
+	caller := apiserver.NewBreakerCaller(c, breaker, externalFacade{}, "Fetch")
+	c.Assert(breaker.RecordPing(errPing), jc.ErrorIsNil)
+
+	_, err := caller.Call("", reflect.Value{})
+	c.Assert(params.IsCodeBackendUnavailable(err), jc.IsTrue)
+}
+
+type externalFacade struct{}
+
+func (externalFacade) Fetch() error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func (s *breakerSuite) TestWatcherNextNotGuarded(c *gc.C) {
+	clock := jujutesting.NewClock(time.Now())
+	breaker := apiserver.NewBackendBreaker(clock, 1, time.Minute)
+	root := apiserver.TestingAPIRootWithBreaker(breaker)
+
+	caller, err := root.FindMethod("NotifyWatcher", 1, "Next")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(apiserver.CallerBreaker(caller), gc.IsNil)
+
+	caller, err = root.FindMethod("NotifyWatcher", 1, "Stop")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(apiserver.CallerBreaker(caller), gc.Equals, breaker)
+}
@@ -169,3 +169,28 @@ func gatherLog(f func()) []loggo.Entry {
 	f()
 	return tw.Log()
 }
+
+func (s *certSuite) TestClientCertificateRequestedOnlyFromAgents(c *gc.C) {
+	srv := s.newServer(c, s.sampleConfig(c))
+	apiInfo := s.APIInfo(srv)
+	certPool, err := api.CreateCertPool(coretesting.CACert)
+	c.Assert(err, jc.ErrorIsNil)
+
+	requested := func(serverName string) bool {
+		var requested bool
+		conn, err := tls.Dial("tcp", apiInfo.Addrs[0], &tls.Config{
+			ServerName:         serverName,
+			RootCAs:            certPool,
+			InsecureSkipVerify: serverName != "juju-apiserver",
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				requested = true
+				return &tls.Certificate{}, nil
+			},
+		})
+		c.Assert(err, jc.ErrorIsNil)
+		conn.Close()
+		return requested
+	}
+	c.Assert(requested("juju-apiserver"), jc.IsTrue)
+	c.Assert(requested("somewhere.example"), jc.IsFalse)
+}
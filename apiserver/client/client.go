@@ -31,6 +31,11 @@ import (
 
 func init() {
 	common.RegisterStandardFacade("Client", 1, newClient)
+	common.RegisterUpgradeSafeMethods("Client",
+		"FullStatus",          // for "juju status"
+		"FindTools",           // for "juju upgrade-juju", before we can reset upgrade to re-run
+		"AbortCurrentUpgrade", // for "juju upgrade-juju", so that we can reset upgrade to re-run
+	)
 }
 
 var logger = loggo.GetLogger("juju.apiserver.client")
@@ -182,7 +182,7 @@ func ServerErrorAndStatus(err error) (*params.Error, int) {
 		status = http.StatusForbidden
 	case params.CodeDischargeRequired:
 		status = http.StatusUnauthorized
-	case params.CodeRetry:
+	case params.CodeRetry, params.CodeBackendUnavailable:
 		status = http.StatusServiceUnavailable
 	}
 	return err1, status
@@ -25,3 +25,9 @@ func SanitizeFacades(patcher Patcher) {
 	emptyFacades := &facade.Registry{}
 	patcher.PatchValue(&Facades, emptyFacades)
 }
+
+// SanitizeUpgradeSafeMethods patches the upgrade-safe method registry
+// so that tests can register methods without affecting the real one.
+func SanitizeUpgradeSafeMethods(patcher Patcher) {
+	patcher.PatchValue(&upgradeSafeMethods, map[FacadeMethod]bool{})
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/juju/errors"
@@ -272,3 +273,53 @@ func ResolveAPIEndpoints(newArgs func(apihttp.HandlerConstraints) apihttp.NewHan
 	}
 	return endpoints
 }
+
+// FacadeMethod identifies a method of an API facade, regardless of
+// the facade's version.
+type FacadeMethod struct {
+	Facade string
+	Method string
+}
+
+// upgradeSafeMethods holds the facade methods that may be called while
+// the controller is being upgraded.
+var upgradeSafeMethods = map[FacadeMethod]bool{}
+
+// RegisterUpgradeSafeMethods records that the named methods of the
+// named facade may be called while the controller is being upgraded;
+// all other methods are blocked until the upgrade has completed. Like
+// the facade registration functions, it is meant to be called during
+// init().
+func RegisterUpgradeSafeMethods(facadeName string, methodNames ...string) {
+	for _, methodName := range methodNames {
+		upgradeSafeMethods[FacadeMethod{facadeName, methodName}] = true
+	}
+}
+
+// IsUpgradeSafeMethod reports whether the given method of the given
+// facade may be called while the controller is being upgraded.
+func IsUpgradeSafeMethod(facadeName, methodName string) bool {
+	return upgradeSafeMethods[FacadeMethod{facadeName, methodName}]
+}
+
+// UpgradeSafeMethods returns all the methods registered with
+// RegisterUpgradeSafeMethods, ordered by facade and method name.
+func UpgradeSafeMethods() []FacadeMethod {
+	methods := make([]FacadeMethod, 0, len(upgradeSafeMethods))
+	for method := range upgradeSafeMethods {
+		methods = append(methods, method)
+	}
+	sort.Sort(facadeMethods(methods))
+	return methods
+}
+
+type facadeMethods []FacadeMethod
+
+func (m facadeMethods) Len() int      { return len(m) }
+func (m facadeMethods) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m facadeMethods) Less(i, j int) bool {
+	if m[i].Facade != m[j].Facade {
+		return m[i].Facade < m[j].Facade
+	}
+	return m[i].Method < m[j].Method
+}
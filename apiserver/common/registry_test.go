@@ -52,6 +52,22 @@ func (s *facadeRegistrySuite) TestRegisterForFeature(c *gc.C) {
 	c.Check(val, gc.Equals, "myobject")
 }
 
+func (s *facadeRegistrySuite) TestRegisterUpgradeSafeMethods(c *gc.C) {
+	common.SanitizeUpgradeSafeMethods(s)
+	common.RegisterUpgradeSafeMethods("myfacade", "Status", "Info")
+	common.RegisterUpgradeSafeMethods("other", "Status")
+
+	c.Check(common.IsUpgradeSafeMethod("myfacade", "Status"), jc.IsTrue)
+	c.Check(common.IsUpgradeSafeMethod("myfacade", "Info"), jc.IsTrue)
+	c.Check(common.IsUpgradeSafeMethod("myfacade", "Set"), jc.IsFalse)
+	c.Check(common.IsUpgradeSafeMethod("other", "Info"), jc.IsFalse)
+	c.Check(common.UpgradeSafeMethods(), jc.DeepEquals, []common.FacadeMethod{
+		{"myfacade", "Info"},
+		{"myfacade", "Status"},
+		{"other", "Status"},
+	})
+}
+
 func (s *facadeRegistrySuite) TestRegisterFacadePanicsOnDoubleRegistry(c *gc.C) {
 	var v interface{}
 	doRegister := func() {
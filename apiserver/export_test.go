@@ -4,11 +4,14 @@
 package apiserver
 
 import (
+	"crypto/x509"
 	"fmt"
 	"net"
+	"reflect"
 	"time"
 
 	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils/clock"
 	gc "gopkg.in/check.v1"
 	"gopkg.in/juju/names.v2"
 	"gopkg.in/macaroon.v1"
@@ -18,17 +21,27 @@ import (
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/permission"
 	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/rpcreflect"
 	"github.com/juju/juju/state"
 )
 
 var (
-	NewPingTimeout        = newPingTimeout
-	MaxClientPingInterval = maxClientPingInterval
-	MongoPingInterval     = mongoPingInterval
-	NewBackups            = &newBackups
-	BZMimeType            = bzMimeType
-	JSMimeType            = jsMimeType
-	SpritePath            = spritePath
+	NewPingTimeout         = newPingTimeout
+	NewAdaptivePingTimeout = newAdaptivePingTimeout
+	MaxClientPingInterval  = maxClientPingInterval
+	MongoPingInterval      = mongoPingInterval
+	NewBackendBreaker      = newBackendBreaker
+	BreakerClosed          = breakerClosed
+	BreakerOpen            = breakerOpen
+	BreakerHalfOpen        = breakerHalfOpen
+	ReadReplicaHint        = readReplicaHint
+	ReadReplicaFacades     = readReplicaFacadeNames
+	NewBackups             = &newBackups
+	BZMimeType             = bzMimeType
+	JSMimeType             = jsMimeType
+	SpritePath             = spritePath
+	IsModelFacade          = isModelFacade
+	IsControllerFacade     = isControllerFacade
 )
 
 func ServerMacaroon(srv *Server) (*macaroon.Macaroon, error) {
@@ -50,7 +63,14 @@ func ServerBakeryService(srv *Server) (authentication.BakeryService, error) {
 // ServerAuthenticatorForTag calls the authenticatorForTag method
 // of the server's authContext.
 func ServerAuthenticatorForTag(srv *Server, tag names.Tag) (authentication.EntityAuthenticator, error) {
-	return srv.authCtxt.authenticator("testing.invalid:1234").authenticatorForTag(tag)
+	return srv.authCtxt.authenticator("testing.invalid:1234", "", nil).authenticatorForTag(tag)
+}
+
+// ServerAuthenticator returns the authenticator the server uses for a
+// connection to the given model on which the client presented the
+// given certificates.
+func ServerAuthenticator(srv *Server, modelUUID string, peerCertificates []*x509.Certificate) authentication.EntityAuthenticator {
+	return srv.authCtxt.authenticator("testing.invalid:1234", modelUUID, peerCertificates)
 }
 
 func APIHandlerWithEntity(entity state.Entity) *apiHandler {
@@ -59,6 +79,30 @@ func APIHandlerWithEntity(entity state.Entity) *apiHandler {
 
 const LoginRateLimit = loginRateLimit
 
+var (
+	LoginRateLimitRefreshInterval = &loginRateLimitRefreshInterval
+	NewLoginLimiter               = newLoginLimiter
+	NewEntityRequestLimiter       = newEntityRequestLimiter
+	IsRequestLimited              = isRequestLimited
+)
+
+// ServerLoginRateLimit returns the login rate limit the server is
+// currently enforcing.
+func ServerLoginRateLimit(srv *Server) int {
+	return srv.limiter.Limit()
+}
+
+// ServerEntityRequestLimiter returns the limiter the server uses to
+// limit each entity's concurrent API calls.
+func ServerEntityRequestLimiter(srv *Server) *entityRequestLimiter {
+	return srv.requestLimiter
+}
+
+// BackendBreakerState returns the state of the server's backend breaker.
+func BackendBreakerState(srv *Server) string {
+	return string(srv.breaker.State())
+}
+
 // DelayLogins changes how the Login code works so that logins won't proceed
 // until they get a message on the returned channel.
 // After calling this function, the caller is responsible for sending messages
@@ -77,6 +121,23 @@ func DelayLogins() (nextChan chan struct{}, cleanup func()) {
 	return
 }
 
+// LoginMetrics returns the server's login counters.
+func LoginMetrics(srv *Server) (attempts, successes uint64, failures map[string]uint64) {
+	m := srv.loginMetrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	failures = make(map[string]uint64)
+	for reason, count := range m.failures {
+		failures[reason] = count
+	}
+	return m.attempts, m.successes, failures
+}
+
+// ResetLoginMetrics sets the server's login counters back to zero.
+func ResetLoginMetrics(srv *Server) {
+	srv.loginMetrics.reset()
+}
+
 func NewErrRoot(err error) *errRoot {
 	return &errRoot{err}
 }
@@ -88,6 +149,35 @@ func TestingAPIRoot(st *state.State) rpc.Root {
 	return newAPIRoot(st, state.NewStatePool(st), common.NewResources(), nil)
 }
 
+// TestingAPIRootWithBreaker is like TestingAPIRoot, but guards facade
+// calls with the given backend breaker.
+func TestingAPIRootWithBreaker(breaker *backendBreaker) rpc.Root {
+	r := newAPIRoot(nil, nil, common.NewResources(), nil)
+	r.breaker = breaker
+	return r
+}
+
+// NewBreakerCaller returns a method caller for the named method of
+// facade, guarded by the given backend breaker.
+func NewBreakerCaller(c *gc.C, breaker *backendBreaker, facade interface{}, method string) rpcreflect.MethodCaller {
+	objMethod, err := rpcreflect.ObjTypeOf(reflect.TypeOf(facade)).Method(method)
+	c.Assert(err, jc.ErrorIsNil)
+	return &srvCaller{
+		objMethod: objMethod,
+		goType:    reflect.TypeOf(facade),
+		creator: func(string) (reflect.Value, error) {
+			return reflect.ValueOf(facade), nil
+		},
+		breaker: breaker,
+	}
+}
+
+// CallerBreaker returns the backend breaker guarding the given
+// method caller, which must have been returned by an apiRoot.
+func CallerBreaker(caller rpcreflect.MethodCaller) *backendBreaker {
+	return caller.(*srvCaller).breaker
+}
+
 // TestingAPIHandler gives you an APIHandler that isn't connected to
 // anything real. It's enough to let test some basic functionality though.
 func TestingAPIHandler(c *gc.C, srvSt, st *state.State) (*apiHandler, *common.Resources) {
@@ -119,6 +209,12 @@ func TestingUpgradingRoot(st *state.State) rpc.Root {
 	return restrictRoot(r, upgradeMethodsOnly)
 }
 
+// AllowedMethodsDuringUpgrades returns the facade methods that are not
+// blocked while the controller is being upgraded.
+func AllowedMethodsDuringUpgrades() []common.FacadeMethod {
+	return common.UpgradeSafeMethods()
+}
+
 // TestingMigratingRoot returns a resricted srvRoot in a migration
 // scenario.
 func TestingMigratingRoot(st *state.State) rpc.Root {
@@ -126,6 +222,13 @@ func TestingMigratingRoot(st *state.State) rpc.Root {
 	return restrictRoot(r, migrationClientMethodsOnly)
 }
 
+// TestingReadOnlyRoot returns a restricted srvRoot as if logged in
+// with a read-only login.
+func TestingReadOnlyRoot(st *state.State) rpc.Root {
+	r := TestingAPIRoot(st)
+	return restrictRoot(r, readOnlyMethodsOnly)
+}
+
 // TestingControllerOnlyRoot returns a restricted srvRoot as if
 // logged in to the root of the API path.
 func TestingControllerOnlyRoot() rpc.Root {
@@ -152,6 +255,8 @@ func SetAdminAPIVersions(srv *Server, versions ...int) {
 		switch n {
 		case 3:
 			factories[n] = newAdminAPIV3
+		case 4:
+			factories[n] = newAdminAPIV4
 		default:
 			panic(fmt.Errorf("unknown admin API version %d", n))
 		}
@@ -187,6 +292,18 @@ func PatchGetControllerCACert(p Patcher, caCert string) {
 	})
 }
 
+// PatchMaxClientPingInterval overrides maxClientPingInterval to
+// support testing.
+func PatchMaxClientPingInterval(p Patcher, interval time.Duration) {
+	p.PatchValue(&maxClientPingInterval, interval)
+}
+
+// NewConfigPinger returns the Pinger that an agent connection would
+// use with the given config.
+func NewConfigPinger(config PingTimeoutConfig, action func(), clock clock.Clock) Pinger {
+	return config.newPinger(action, clock)
+}
+
 // Patcher defines an interface that matches the PatchValue method on
 // CleanupSuite
 type Patcher interface {
@@ -297,10 +297,14 @@ type guiHandler struct {
 	uuid             string
 }
 
-// serveStatic serves the GUI static files.
+// serveStatic serves the GUI static files, compressed when the client
+// allows it.
 func (h *guiHandler) serveStatic(w http.ResponseWriter, req *http.Request) {
 	staticDir := filepath.Join(h.rootDir, "static")
-	fs := http.FileServer(http.Dir(staticDir))
+	fs := &guiCompressingHandler{
+		dir:     staticDir,
+		handler: http.FileServer(http.Dir(staticDir)),
+	}
 	http.StripPrefix(h.hashedPath("static/"), fs).ServeHTTP(w, req)
 }
 
@@ -329,6 +333,12 @@ func (h *guiHandler) serveCombo(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 	w.Header().Set("Content-Type", ctype)
+	w.Header().Add("Vary", "Accept-Encoding")
+	if acceptsEncoding(req, "gzip") && isCompressible(ctype) {
+		gw := newGzipResponseWriter(w)
+		defer gw.Close()
+		w = gw
+	}
 	for _, fpath := range paths {
 		sendGUIComboFile(w, fpath)
 	}
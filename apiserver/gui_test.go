@@ -6,6 +6,7 @@ package apiserver_test
 import (
 	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -594,6 +595,121 @@ func (s *guiSuite) TestGUIDirectory(c *gc.C) {
 	c.Assert(string(b), gc.Equals, indexContent)
 }
 
+func (s *guiSuite) TestGUIStaticCompression(c *gc.C) {
+	storage, err := s.State.GUIStorage()
+	c.Assert(err, jc.ErrorIsNil)
+	defer storage.Close()
+
+	vers := version.MustParse("2.0.0")
+	setupGUIArchive(c, storage, vers.String(), map[string]string{
+		"static/file.js":                 "plain js",
+		"static/file.js.gz":              gzipString(c, "gzipped js"),
+		"static/file.js.br":              "brotli js",
+		"static/style.css":               "plain css",
+		"static/image.png":               "png data",
+		apiserver.SpritePath:             "sprite",
+		apiserver.SpritePath + ".gz":     gzipString(c, "gzipped sprite"),
+		"static/gui/build/tng/picard.js": "enterprise",
+	})
+	err = s.State.GUISetVersion(vers)
+	c.Assert(err, jc.ErrorIsNil)
+
+	for i, test := range []struct {
+		about            string
+		pathAndquery     string
+		acceptEncoding   string
+		expectedType     string
+		expectedEncoding string
+		expectedBody     string
+	}{{
+		about:            "pre-compressed gzip variant",
+		pathAndquery:     "/static/file.js",
+		acceptEncoding:   "gzip",
+		expectedType:     apiserver.JSMimeType,
+		expectedEncoding: "gzip",
+		expectedBody:     "gzipped js",
+	}, {
+		about:            "pre-compressed brotli variant preferred",
+		pathAndquery:     "/static/file.js",
+		acceptEncoding:   "gzip, br",
+		expectedType:     apiserver.JSMimeType,
+		expectedEncoding: "br",
+		expectedBody:     "brotli js",
+	}, {
+		about:            "refused encoding not used",
+		pathAndquery:     "/static/file.js",
+		acceptEncoding:   "gzip, br;q=0",
+		expectedType:     apiserver.JSMimeType,
+		expectedEncoding: "gzip",
+		expectedBody:     "gzipped js",
+	}, {
+		about:          "no compression accepted",
+		pathAndquery:   "/static/file.js",
+		acceptEncoding: "identity",
+		expectedType:   apiserver.JSMimeType,
+		expectedBody:   "plain js",
+	}, {
+		about:            "compressed on the fly",
+		pathAndquery:     "/static/style.css",
+		acceptEncoding:   "gzip",
+		expectedType:     "text/css; charset=utf-8",
+		expectedEncoding: "gzip",
+		expectedBody:     "plain css",
+	}, {
+		about:          "binary asset not compressed",
+		pathAndquery:   "/static/image.png",
+		acceptEncoding: "gzip",
+		expectedType:   "image/png",
+		expectedBody:   "png data",
+	}, {
+		about:            "sprite compressed only once",
+		pathAndquery:     "/" + filepath.ToSlash(apiserver.SpritePath),
+		acceptEncoding:   "gzip",
+		expectedType:     "image/svg+xml",
+		expectedEncoding: "gzip",
+		expectedBody:     "gzipped sprite",
+	}, {
+		about:            "combo compressed on the fly",
+		pathAndquery:     "/combo?tng/picard.js",
+		acceptEncoding:   "gzip",
+		expectedType:     apiserver.JSMimeType,
+		expectedEncoding: "gzip",
+		expectedBody:     "enterprise\n/* picard.js */\n",
+	}} {
+		c.Logf("test %d: %s", i, test.about)
+		resp := s.sendRequest(c, httpRequestParams{
+			url: s.guiURL(c, "", test.pathAndquery),
+			extraHeaders: map[string]string{
+				"Accept-Encoding": test.acceptEncoding,
+			},
+		})
+		body := assertResponse(c, resp, http.StatusOK, test.expectedType)
+		c.Check(resp.Header.Get("Content-Encoding"), gc.Equals, test.expectedEncoding)
+		c.Check(resp.Header.Get("Vary"), gc.Equals, "Accept-Encoding")
+		if test.expectedEncoding == "gzip" {
+			body = gunzipBytes(c, body)
+		}
+		c.Check(string(body), gc.Equals, test.expectedBody)
+	}
+}
+
+func gzipString(c *gc.C, s string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := io.WriteString(w, s)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(w.Close(), jc.ErrorIsNil)
+	return buf.String()
+}
+
+func gunzipBytes(c *gc.C, b []byte) []byte {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	c.Assert(err, jc.ErrorIsNil)
+	out, err := ioutil.ReadAll(r)
+	c.Assert(err, jc.ErrorIsNil)
+	return out
+}
+
 type guiArchiveSuite struct {
 	authHTTPSuite
 }
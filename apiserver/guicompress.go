@@ -0,0 +1,182 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// guiPrecompressedEncodings holds the content encodings for which
+// pre-compressed variants of the GUI static files are looked for, in
+// order of preference, along with the file name suffix of each variant.
+var guiPrecompressedEncodings = []struct {
+	encoding string
+	suffix   string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// acceptsEncoding reports whether the client that sent the given
+// request accepts responses with the given content encoding.
+func acceptsEncoding(req *http.Request, encoding string) bool {
+	for _, header := range req.Header["Accept-Encoding"] {
+		for _, part := range strings.Split(header, ",") {
+			fields := strings.Split(part, ";")
+			name := strings.TrimSpace(fields[0])
+			if name != encoding && name != "*" {
+				continue
+			}
+			// An encoding with a zero quality value is explicitly
+			// refused.
+			for _, param := range fields[1:] {
+				param = strings.TrimSpace(param)
+				if !strings.HasPrefix(param, "q=") {
+					continue
+				}
+				if q, err := strconv.ParseFloat(param[len("q="):], 64); err == nil && q == 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// isCompressible reports whether content of the given type is worth
+// compressing. Images other than SVG, fonts and archives are usually
+// compressed already, so compressing them again only costs time.
+func isCompressible(ctype string) bool {
+	mediaType, _, err := mime.ParseMediaType(ctype)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/javascript",
+		"application/x-javascript",
+		"application/json",
+		"image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// guiCompressingHandler serves the GUI static files in dir using the
+// given handler, compressing the responses when the client allows it.
+// A pre-compressed variant of a file, such as "app.js.gz" for "app.js",
+// is served as is when the client accepts its encoding. Otherwise
+// compressible content is gzipped on the fly.
+type guiCompressingHandler struct {
+	dir     string
+	handler http.Handler
+}
+
+// ServeHTTP implements http.Handler.
+func (h *guiCompressingHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Add("Vary", "Accept-Encoding")
+	name := path.Clean("/" + req.URL.Path)
+	ctype := mime.TypeByExtension(path.Ext(name))
+	for _, variant := range guiPrecompressedEncodings {
+		if !acceptsEncoding(req, variant.encoding) {
+			continue
+		}
+		fpath := filepath.Join(h.dir, filepath.FromSlash(name)) + variant.suffix
+		if servePrecompressed(w, req, fpath, ctype, variant.encoding) {
+			return
+		}
+	}
+	if req.Header.Get("Range") == "" && acceptsEncoding(req, "gzip") && isCompressible(ctype) {
+		gw := newGzipResponseWriter(w)
+		defer gw.Close()
+		w = gw
+	}
+	h.handler.ServeHTTP(w, req)
+}
+
+// servePrecompressed serves the file at fpath, which holds content of
+// type ctype compressed with the given encoding. It reports whether the
+// file could be served.
+func servePrecompressed(w http.ResponseWriter, req *http.Request, fpath, ctype, encoding string) bool {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if ctype == "" {
+		// Do not let the type be sniffed from the compressed content.
+		ctype = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Content-Encoding", encoding)
+	http.ServeContent(w, req, fpath, info.ModTime(), f)
+	return true
+}
+
+// gzipResponseWriter is an http.ResponseWriter that gzips the body of
+// successful responses. Other responses, and responses that already
+// have a content encoding, are written unchanged.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func newGzipResponseWriter(w http.ResponseWriter) *gzipResponseWriter {
+	return &gzipResponseWriter{ResponseWriter: w}
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	header := w.Header()
+	if code == http.StatusOK && header.Get("Content-Encoding") == "" {
+		header.Set("Content-Encoding", "gzip")
+		// The length of the compressed body is not known yet.
+		header.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter.
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			// Sniff the type from the uncompressed content, as the
+			// standard library would have done.
+			w.Header().Set("Content-Type", http.DetectContentType(data))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz == nil {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.gz.Write(data)
+}
+
+// Close flushes the compressed body, if any.
+func (w *gzipResponseWriter) Close() error {
+	if w.gz == nil {
+		return nil
+	}
+	return w.gz.Close()
+}
@@ -65,7 +65,7 @@ func (h *localLoginHandlers) serveLoginPost(p httprequest.Params) (interface{},
 		return nil, errors.NotValidf("non-local username %q", username)
 	}
 
-	authenticator := h.authCtxt.authenticator(p.Request.Host)
+	authenticator := h.authCtxt.authenticator(p.Request.Host, h.state.ModelUUID(), nil)
 	if _, err := authenticator.Authenticate(h.state, userTag, params.LoginRequest{
 		Credentials: password,
 	}); err != nil {
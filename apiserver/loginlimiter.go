@@ -0,0 +1,92 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/tomb.v1"
+)
+
+// loginRateLimitRefreshInterval defines how often the server re-reads
+// the login rate limit and the per-entity request limit from the
+// controller config, and so bounds how long a change takes to apply.
+var loginRateLimitRefreshInterval = time.Minute
+
+// loginLimiter limits how many Login requests are handled concurrently.
+// Unlike utils.Limiter, its limit can be changed while it is in use.
+type loginLimiter struct {
+	// mu guards the fields below it.
+	mu     sync.Mutex
+	limit  int
+	active int
+}
+
+// newLoginLimiter returns a loginLimiter that allows up to limit
+// concurrent logins.
+func newLoginLimiter(limit int) *loginLimiter {
+	return &loginLimiter{limit: limit}
+}
+
+// Acquire reserves a login slot, and reports whether one was free.
+func (l *loginLimiter) Acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active >= l.limit {
+		return false
+	}
+	l.active++
+	return true
+}
+
+// Release frees a login slot reserved by Acquire.
+func (l *loginLimiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+}
+
+// Limit returns the current limit.
+func (l *loginLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// SetLimit changes the limit, and reports whether it was different.
+// Logins already in progress are unaffected; if the limit is lowered
+// below their number, no more are allowed until enough have finished.
+func (l *loginLimiter) SetLimit(limit int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit == limit {
+		return false
+	}
+	l.limit = limit
+	return true
+}
+
+// refreshLimits keeps the server's login limit and per-entity request
+// limit in line with the controller config.
+func (srv *Server) refreshLimits() error {
+	for {
+		cfg, err := srv.state.ControllerConfig()
+		if err != nil {
+			logger.Warningf("cannot read login rate limit: %v", err)
+		} else {
+			if limit := cfg.APILoginRateLimit(); srv.limiter.SetLimit(limit) {
+				logger.Infof("login rate limit set to %d", limit)
+			}
+			if limit := cfg.APIEntityRequestLimit(); srv.requestLimiter.SetLimit(limit) {
+				logger.Infof("per-entity request limit set to %d", limit)
+			}
+		}
+		select {
+		case <-srv.clock.After(loginRateLimitRefreshInterval):
+		case <-srv.tomb.Dying():
+			return tomb.ErrDying
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type loginLimiterSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&loginLimiterSuite{})
+
+func (s *loginLimiterSuite) TestAcquireUpToLimit(c *gc.C) {
+	l := apiserver.NewLoginLimiter(2)
+	c.Assert(l.Acquire(), jc.IsTrue)
+	c.Assert(l.Acquire(), jc.IsTrue)
+	c.Assert(l.Acquire(), jc.IsFalse)
+	l.Release()
+	c.Assert(l.Acquire(), jc.IsTrue)
+}
+
+func (s *loginLimiterSuite) TestSetLimit(c *gc.C) {
+	l := apiserver.NewLoginLimiter(1)
+	c.Assert(l.SetLimit(1), jc.IsFalse)
+	c.Assert(l.Acquire(), jc.IsTrue)
+	c.Assert(l.Acquire(), jc.IsFalse)
+
+	c.Assert(l.SetLimit(2), jc.IsTrue)
+	c.Assert(l.Limit(), gc.Equals, 2)
+	c.Assert(l.Acquire(), jc.IsTrue)
+	c.Assert(l.Acquire(), jc.IsFalse)
+}
+
+func (s *loginLimiterSuite) TestLoweredLimitWaitsForReleases(c *gc.C) {
+	l := apiserver.NewLoginLimiter(3)
+	for i := 0; i < 3; i++ {
+		c.Assert(l.Acquire(), jc.IsTrue)
+	}
+	c.Assert(l.SetLimit(1), jc.IsTrue)
+
+	// Two logins are in flight above the new limit, so a slot only
+	// becomes available once all but one have finished.
+	l.Release()
+	c.Assert(l.Acquire(), jc.IsFalse)
+	l.Release()
+	c.Assert(l.Acquire(), jc.IsFalse)
+	l.Release()
+	c.Assert(l.Acquire(), jc.IsTrue)
+}
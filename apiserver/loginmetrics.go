@@ -0,0 +1,134 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons for which a login attempt is counted as a failure.
+const (
+	// loginFailureBadCredentials is recorded when the credentials
+	// supplied do not identify a known entity.
+	loginFailureBadCredentials = "bad-credentials"
+
+	// loginFailureRateLimited is recorded when an agent login is
+	// rejected because too many logins are already in progress.
+	loginFailureRateLimited = "rate-limited"
+
+	// loginFailureMaintenance is recorded when a login is rejected
+	// because an upgrade, restore or similar operation is in progress.
+	loginFailureMaintenance = "maintenance"
+
+	// loginFailureOther is recorded for every other failed login.
+	loginFailureOther = "other"
+)
+
+var loginFailureReasons = []string{
+	loginFailureBadCredentials,
+	loginFailureRateLimited,
+	loginFailureMaintenance,
+	loginFailureOther,
+}
+
+var (
+	jujuAPILoginAttemptsTotalDesc = prometheus.NewDesc(
+		"juju_api_login_attempts_total",
+		"Total number of API login attempts.",
+		[]string{},
+		prometheus.Labels{},
+	)
+	jujuAPILoginSuccessesTotalDesc = prometheus.NewDesc(
+		"juju_api_login_successes_total",
+		"Total number of successful API logins.",
+		[]string{},
+		prometheus.Labels{},
+	)
+	jujuAPILoginFailuresTotalDesc = prometheus.NewDesc(
+		"juju_api_login_failures_total",
+		"Total number of failed API logins, by reason.",
+		[]string{"reason"},
+		prometheus.Labels{},
+	)
+)
+
+// loginMetrics counts the outcomes of API login attempts. It is a
+// prometheus.Collector.
+//
+// Attempts that end by asking the client to discharge a macaroon are
+// counted as attempts, but neither as successes nor as failures; the
+// client is expected to log in again with the discharged macaroon.
+type loginMetrics struct {
+	// mu guards the fields below it.
+	mu        sync.Mutex
+	attempts  uint64
+	successes uint64
+	failures  map[string]uint64
+}
+
+func newLoginMetrics() *loginMetrics {
+	return &loginMetrics{
+		failures: make(map[string]uint64),
+	}
+}
+
+func (m *loginMetrics) attempted() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts++
+}
+
+func (m *loginMetrics) succeeded() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.successes++
+}
+
+func (m *loginMetrics) failed(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures[reason]++
+}
+
+// reset sets all the counters back to zero.
+func (m *loginMetrics) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts = 0
+	m.successes = 0
+	m.failures = make(map[string]uint64)
+}
+
+// Describe is part of the prometheus.Collector interface.
+func (m *loginMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- jujuAPILoginAttemptsTotalDesc
+	ch <- jujuAPILoginSuccessesTotalDesc
+	ch <- jujuAPILoginFailuresTotalDesc
+}
+
+// Collect is part of the prometheus.Collector interface.
+func (m *loginMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch <- prometheus.MustNewConstMetric(
+		jujuAPILoginAttemptsTotalDesc,
+		prometheus.CounterValue,
+		float64(m.attempts),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		jujuAPILoginSuccessesTotalDesc,
+		prometheus.CounterValue,
+		float64(m.successes),
+	)
+	for _, reason := range loginFailureReasons {
+		ch <- prometheus.MustNewConstMetric(
+			jujuAPILoginFailuresTotalDesc,
+			prometheus.CounterValue,
+			float64(m.failures[reason]),
+			reason,
+		)
+	}
+}
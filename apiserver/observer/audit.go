@@ -3,8 +3,10 @@
 package observer
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/juju/errors"
@@ -25,15 +27,27 @@ type AuditContext struct {
 	// ModelUUID is the UUID of the model the audit observer is
 	// currently running on.
 	ModelUUID string
+
+	// Methods, if not empty, restricts auditing to calls to these
+	// methods, each written as "<facade>.<method>".
+	Methods []string
 }
 
 type ErrorHandler func(error)
 
 // NewAudit creates a new Audit with the information provided via the Context.
 func NewAudit(ctx *AuditContext, handleAuditEntry audit.AuditEntrySinkFn, errorHandler ErrorHandler) *Audit {
+	var methods map[string]bool
+	if len(ctx.Methods) > 0 {
+		methods = make(map[string]bool)
+		for _, method := range ctx.Methods {
+			methods[method] = true
+		}
+	}
 	return &Audit{
 		jujuServerVersion: ctx.JujuServerVersion,
 		modelUUID:         ctx.ModelUUID,
+		methods:           methods,
 		errorHandler:      errorHandler,
 		handleAuditEntry:  handleAuditEntry,
 	}
@@ -44,6 +58,7 @@ func NewAudit(ctx *AuditContext, handleAuditEntry audit.AuditEntrySinkFn, errorH
 type Audit struct {
 	jujuServerVersion version.Number
 	modelUUID         string
+	methods           map[string]bool
 	errorHandler      ErrorHandler
 	handleAuditEntry  audit.AuditEntrySinkFn
 
@@ -79,6 +94,7 @@ func (a *Audit) RPCObserver() rpc.Observer {
 	return &AuditRPCObserver{
 		jujuServerVersion: a.jujuServerVersion,
 		modelUUID:         a.modelUUID,
+		methods:           a.methods,
 		errorHandler:      a.errorHandler,
 		handleAuditEntry:  a.handleAuditEntry,
 		authenticatedTag:  a.state.authenticatedTag,
@@ -86,34 +102,64 @@ func (a *Audit) RPCObserver() rpc.Observer {
 	}
 }
 
-// AuditRPCObserver is an observer which will log RPC requests using
-// the function provided.
+// AuditRPCObserver is an observer which will log RPC requests, along
+// with their outcome, using the function provided.
 type AuditRPCObserver struct {
 	jujuServerVersion version.Number
 	modelUUID         string
+	methods           map[string]bool
 	errorHandler      ErrorHandler
 	handleAuditEntry  audit.AuditEntrySinkFn
 	authenticatedTag  string
 	remoteAddress     string
+
+	// entry holds the audit entry for the request, if it is audited,
+	// until the reply completes it.
+	entry *audit.AuditEntry
 }
 
 // ServerRequest implements Observer.
 func (a *AuditRPCObserver) ServerRequest(hdr *rpc.Header, body interface{}) {
+	req := hdr.Request
+	if a.methods != nil && !a.methods[req.Type+"."+req.Action] {
+		return
+	}
 	auditEntry := a.boilerplateAuditEntry()
 	auditEntry.OriginName = a.authenticatedTag
 
 	auditEntry.OriginType = "API request"
-	auditEntry.Operation = rpcRequestToOperation(hdr.Request)
-	auditEntry.Data = map[string]interface{}{"request-body": body}
-	err := a.handleAuditEntry(auditEntry)
+	auditEntry.Operation = rpcRequestToOperation(req)
+	auditEntry.Data = map[string]interface{}{"request-body": redactRequestBody(body)}
+	a.entry = &auditEntry
+}
+
+// ServerReply implements Observer. It records the audit entry begun
+// by ServerRequest, with the error the call returned, if any. For bulk
+// calls, the errors of the individual results are combined.
+func (a *AuditRPCObserver) ServerReply(_ rpc.Request, hdr *rpc.Header, body interface{}) {
+	if a.entry == nil {
+		return
+	}
+	replyErr := hdr.Error
+	if combiner, ok := body.(interface {
+		Combine() error
+	}); ok && replyErr == "" {
+		if err := combiner.Combine(); err != nil {
+			replyErr = err.Error()
+		}
+	}
+	if replyErr != "" {
+		a.entry.Data["error"] = replyErr
+	}
+	if hdr.ErrorCode != "" {
+		a.entry.Data["error-code"] = hdr.ErrorCode
+	}
+	err := a.handleAuditEntry(*a.entry)
 	if err != nil {
 		a.errorHandler(errors.Trace(err))
 	}
 }
 
-// ServerReply implements Observer.
-func (a *AuditRPCObserver) ServerReply(rpc.Request, *rpc.Header, interface{}) {}
-
 func (a *AuditRPCObserver) boilerplateAuditEntry() audit.AuditEntry {
 	return audit.AuditEntry{
 		JujuServerVersion: a.jujuServerVersion,
@@ -127,3 +173,60 @@ func (a *AuditRPCObserver) boilerplateAuditEntry() audit.AuditEntry {
 func rpcRequestToOperation(req rpc.Request) string {
 	return fmt.Sprintf("%s:v%d - %s", req.Type, req.Version, req.Action)
 }
+
+// redactedValue replaces the values of sensitive request fields.
+const redactedValue = "<redacted>"
+
+// sensitiveFields holds substrings of the names of request fields
+// whose values are never recorded.
+var sensitiveFields = []string{
+	"credential",
+	"key",
+	"macaroon",
+	"password",
+	"secret",
+	"token",
+}
+
+// redactRequestBody returns the given request body decoded from its
+// JSON encoding, with the values of any fields whose names look
+// sensitive replaced.
+func redactRequestBody(body interface{}) interface{} {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return redactedValue
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return redactedValue
+	}
+	return redactValue(value)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for name, v := range value {
+			if isSensitiveField(name) {
+				value[name] = redactedValue
+			} else {
+				value[name] = redactValue(v)
+			}
+		}
+	case []interface{}:
+		for i, v := range value {
+			value[i] = redactValue(v)
+		}
+	}
+	return value
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, sensitive := range sensitiveFields {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package observer_test
+
+import (
+	"net/http"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/version"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/names.v2"
+
+	"github.com/juju/juju/apiserver/observer"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/audit"
+	"github.com/juju/juju/rpc"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type auditSuite struct {
+	testing.IsolationSuite
+	entries []audit.AuditEntry
+}
+
+var _ = gc.Suite(&auditSuite{})
+
+func (s *auditSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.entries = nil
+}
+
+func (s *auditSuite) newAudit(c *gc.C, methods ...string) *observer.Audit {
+	ctx := &observer.AuditContext{
+		JujuServerVersion: version.MustParse("2.2.0"),
+		ModelUUID:         coretesting.ModelTag.Id(),
+		Methods:           methods,
+	}
+	a := observer.NewAudit(ctx, func(entry audit.AuditEntry) error {
+		s.entries = append(s.entries, entry)
+		return nil
+	}, func(err error) {
+		c.Errorf("unexpected audit error: %v", err)
+	})
+	a.Join(&http.Request{RemoteAddr: "10.0.0.1:1234"}, 1)
+	a.Login(names.NewUserTag("bob"), coretesting.ModelTag, false, "")
+	return a
+}
+
+func call(a *observer.Audit, facade, method string, args, result interface{}, replyHdr rpc.Header) {
+	rpcObserver := a.RPCObserver()
+	req := rpc.Request{Type: facade, Version: 4, Action: method}
+	rpcObserver.ServerRequest(&rpc.Header{Request: req}, args)
+	rpcObserver.ServerReply(req, &replyHdr, result)
+}
+
+func (s *auditSuite) TestRecordsAllMethodsByDefault(c *gc.C) {
+	a := s.newAudit(c)
+	call(a, "Client", "FullStatus", params.StatusParams{}, params.FullStatus{}, rpc.Header{})
+	c.Assert(s.entries, gc.HasLen, 1)
+	entry := s.entries[0]
+	c.Assert(entry.OriginName, gc.Equals, "user-bob")
+	c.Assert(entry.RemoteAddress, gc.Equals, "10.0.0.1:1234")
+	c.Assert(entry.Operation, gc.Equals, "Client:v4 - FullStatus")
+	c.Assert(entry.Data, jc.DeepEquals, map[string]interface{}{
+		"request-body": map[string]interface{}{"patterns": nil},
+	})
+}
+
+func (s *auditSuite) TestRecordsOnlyGivenMethods(c *gc.C) {
+	a := s.newAudit(c, "Application.DestroyRelation")
+	call(a, "Client", "FullStatus", params.StatusParams{}, params.FullStatus{}, rpc.Header{})
+	c.Assert(s.entries, gc.HasLen, 0)
+
+	args := params.DestroyRelation{Endpoints: []string{"wordpress", "mysql"}}
+	call(a, "Application", "DestroyRelation", args, struct{}{}, rpc.Header{})
+	c.Assert(s.entries, gc.HasLen, 1)
+	c.Assert(s.entries[0].Operation, gc.Equals, "Application:v4 - DestroyRelation")
+	c.Assert(s.entries[0].Data, jc.DeepEquals, map[string]interface{}{
+		"request-body": map[string]interface{}{
+			"endpoints": []interface{}{"wordpress", "mysql"},
+		},
+	})
+}
+
+func (s *auditSuite) TestRedactsSensitiveFields(c *gc.C) {
+	a := s.newAudit(c)
+	args := map[string]interface{}{
+		"changes": []interface{}{
+			map[string]interface{}{"tag": "user-bob", "Password": "sekrit"},
+		},
+		"cloud-credential": map[string]interface{}{"user": "bob"},
+		"private-key":      "xyz",
+	}
+	call(a, "UserManager", "SetPassword", args, params.ErrorResults{}, rpc.Header{})
+	c.Assert(s.entries, gc.HasLen, 1)
+	c.Assert(s.entries[0].Data["request-body"], jc.DeepEquals, map[string]interface{}{
+		"changes": []interface{}{
+			map[string]interface{}{"tag": "user-bob", "Password": "<redacted>"},
+		},
+		"cloud-credential": "<redacted>",
+		"private-key":      "<redacted>",
+	})
+}
+
+func (s *auditSuite) TestRecordsError(c *gc.C) {
+	a := s.newAudit(c)
+	call(a, "Application", "DestroyRelation", params.DestroyRelation{}, struct{}{}, rpc.Header{
+		Error:     "relation not found",
+		ErrorCode: params.CodeNotFound,
+	})
+	c.Assert(s.entries, gc.HasLen, 1)
+	c.Assert(s.entries[0].Data["error"], gc.Equals, "relation not found")
+	c.Assert(s.entries[0].Data["error-code"], gc.Equals, params.CodeNotFound)
+}
+
+func (s *auditSuite) TestRecordsBulkErrors(c *gc.C) {
+	a := s.newAudit(c)
+	result := params.ErrorResults{Results: []params.ErrorResult{
+		{},
+		{Error: &params.Error{Message: "machine 1 has units"}},
+	}}
+	call(a, "Client", "DestroyMachines", params.DestroyMachines{MachineNames: []string{"0", "1"}}, result, rpc.Header{})
+	c.Assert(s.entries, gc.HasLen, 1)
+	c.Assert(s.entries[0].Data["error"], gc.Equals, "machine 1 has units")
+	_, ok := s.entries[0].Data["error-code"]
+	c.Assert(ok, jc.IsFalse)
+}
@@ -94,6 +94,7 @@ const (
 	CodeDischargeRequired         = "macaroon discharge required"
 	CodeRedirect                  = "redirection required"
 	CodeRetry                     = "retry"
+	CodeBackendUnavailable        = "backend unavailable"
 )
 
 // ErrCode returns the error code associated with
@@ -200,6 +201,10 @@ func IsCodeTryAgain(err error) bool {
 	return ErrCode(err) == CodeTryAgain
 }
 
+func IsCodeBackendUnavailable(err error) bool {
+	return ErrCode(err) == CodeBackendUnavailable
+}
+
 func IsCodeNotImplemented(err error) bool {
 	return ErrCode(err) == CodeNotImplemented
 }
@@ -127,6 +127,25 @@ type AddRelationResults struct {
 // The endpoints specified are unordered.
 type DestroyRelation struct {
 	Endpoints []string `json:"endpoints"`
+
+	// Force removes any units in the relation's scope from it straight
+	// away, without running their hooks.
+	Force bool `json:"force,omitempty"`
+}
+
+// RelationEndpoints holds the endpoints identifying a relation, for
+// making the RelationDetails call. The endpoints specified are unordered.
+type RelationEndpoints struct {
+	Endpoints []string `json:"endpoints"`
+}
+
+// RelationDetails describes the relation identified by a
+// RelationDetails call.
+type RelationDetails struct {
+	Id        int                     `json:"id"`
+	Key       string                  `json:"key"`
+	Endpoints []multiwatcher.Endpoint `json:"endpoints"`
+	UnitCount int                     `json:"unit-count"`
 }
 
 // AddCharm holds the arguments for making an AddCharm API call.
@@ -409,12 +428,21 @@ type Creds struct {
 // valid macaroons and macaroon authentication is configured,
 // the LoginResponse will contain a macaroon that when
 // discharged, may allow access.
+//
+// If ReadOnly is true, the connection will only be allowed to make
+// read-only calls, whatever the permissions of the authenticated entity.
 type LoginRequest struct {
 	AuthTag     string           `json:"auth-tag"`
 	Credentials string           `json:"credentials"`
 	Nonce       string           `json:"nonce"`
 	Macaroons   []macaroon.Slice `json:"macaroons"`
 	UserData    string           `json:"user-data"`
+	ReadOnly    bool             `json:"read-only,omitempty"`
+
+	// CertAuth, if true, asks the server to authenticate the entity
+	// with AuthTag by the TLS client certificate presented on the
+	// connection rather than by Credentials or Macaroons.
+	CertAuth bool `json:"cert-auth,omitempty"`
 }
 
 // LoginRequestCompat holds credentials for identifying an entity to the Login v1
@@ -622,6 +650,18 @@ type RedirectInfoResult struct {
 	CACert string `json:"ca-cert"`
 }
 
+// MacaroonDischargeInfoResult holds the result of a MacaroonDischargeInfo
+// call.
+type MacaroonDischargeInfoResult struct {
+	// PublicKey holds the base64-encoded public key of the bakery
+	// service that verifies macaroons for external users.
+	PublicKey string `json:"public-key"`
+
+	// DischargeURL holds the URL of the identity service that
+	// discharges the third-party caveats in those macaroons.
+	DischargeURL string `json:"discharge-url"`
+}
+
 // ReauthRequest holds a challenge/response token meaningful to the identity
 // provider.
 type ReauthRequest struct {
@@ -682,6 +722,40 @@ type LoginResult struct {
 	// ServerVersion is the string representation of the server version
 	// if the server supports it.
 	ServerVersion string `json:"server-version,omitempty"`
+
+	// ReadReplica, if set, tells the client which facades it may call
+	// on any controller rather than only the one it is connected to.
+	ReadReplica *ReadReplicaHint `json:"read-replica,omitempty"`
+
+	// ServerTime holds the controller's time when it handled the
+	// login, so that clients can detect clock skew. It is not set by
+	// older controllers.
+	ServerTime *time.Time `json:"server-time,omitempty"`
+}
+
+// ReadConsistencyEventual indicates that reads issued to another
+// controller may not yet reflect writes made through this one.
+const ReadConsistencyEventual = "eventual"
+
+// ReadReplicaHint describes which read-only facades are safe to call
+// on any controller, so that clients may spread read load across a
+// highly available controller.
+type ReadReplicaHint struct {
+	// Preferred is true when the controller has been marked as a
+	// preferred target for reads.
+	Preferred bool `json:"preferred"`
+
+	// Facades holds the names of facades whose methods only read state
+	// and keep no state on the server between calls.
+	Facades []string `json:"facades"`
+
+	// Consistency describes the consistency of reads made through
+	// Facades on a controller other than the one the client wrote
+	// through. It is currently always ReadConsistencyEventual: watchers
+	// and caches on other controllers catch up with committed changes
+	// asynchronously, so a client must not expect to read its own
+	// writes from another controller immediately.
+	Consistency string `json:"consistency"`
 }
 
 // ControllersServersSpec contains arguments for
@@ -897,3 +971,37 @@ type MacaroonResult struct {
 	Result *macaroon.Macaroon `json:"result,omitempty"`
 	Error  *Error             `json:"error,omitempty"`
 }
+
+// ResourceURLArg identifies an application resource to sign a
+// download URL for.
+type ResourceURLArg struct {
+	Application string `json:"application"`
+	Name        string `json:"name"`
+}
+
+// ResourceURLArgs holds the resources to sign download URLs for.
+type ResourceURLArgs struct {
+	Resources []ResourceURLArg `json:"resources"`
+}
+
+// ResourceURLResult holds a signed resource download URL, or an error.
+type ResourceURLResult struct {
+	// URL is the path, relative to the API server address, from which
+	// the resource can be downloaded without logging in.
+	URL string `json:"url,omitempty"`
+
+	// Expires is when the URL stops being valid.
+	Expires time.Time `json:"expires,omitempty"`
+
+	Error *Error `json:"error,omitempty"`
+}
+
+// ResourceURLResults holds the results of signing resource download URLs.
+type ResourceURLResults struct {
+	Results []ResourceURLResult `json:"results"`
+}
+
+// ResourceURLs holds signed resource download URLs.
+type ResourceURLs struct {
+	URLs []string `json:"urls"`
+}
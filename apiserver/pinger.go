@@ -10,6 +10,7 @@ import (
 	"github.com/juju/utils/clock"
 	"gopkg.in/tomb.v1"
 
+	"github.com/juju/juju/api"
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/facade"
 	"github.com/juju/juju/state"
@@ -17,6 +18,7 @@ import (
 
 func init() {
 	common.RegisterStandardFacade("Pinger", 1, NewPinger)
+	common.RegisterUpgradeSafeMethods("Pinger", "Ping")
 }
 
 // NewPinger returns an object that can be pinged by calling its Ping method.
@@ -36,6 +38,79 @@ type Pinger interface {
 	Stop() error
 }
 
+// pingLatencyFactor is how many times the smoothed round-trip time of
+// a connection an adaptive ping timeout adds to the base timeout.
+const pingLatencyFactor = 4
+
+// PingTimeoutConfig holds how the API server decides that an agent
+// connection which has stopped pinging is dead.
+type PingTimeoutConfig struct {
+	// Timeout is how long an agent connection may go without pinging
+	// before it is closed. If it is zero, maxClientPingInterval is
+	// used. It must not be more than maxClientPingInterval.
+	Timeout time.Duration
+
+	// Adaptive, if true, lengthens the timeout of each connection by
+	// pingLatencyFactor times its smoothed round-trip time, so that
+	// agents on slow links are not cut off. Clients wait
+	// api.PingPeriod after each ping reply before pinging again, so
+	// the round trip is however much longer than that the gap
+	// between two pings is.
+	Adaptive bool
+
+	// MaxTimeout is the longest timeout an adaptive connection may
+	// use. If it is zero, or more than maxClientPingInterval,
+	// maxClientPingInterval is used.
+	MaxTimeout time.Duration
+}
+
+// Validate returns an error if the config is not valid.
+func (c PingTimeoutConfig) Validate() error {
+	if c.Timeout < 0 {
+		return errors.NotValidf("negative Timeout")
+	}
+	if c.MaxTimeout < 0 {
+		return errors.NotValidf("negative MaxTimeout")
+	}
+	if c.Timeout > maxClientPingInterval {
+		return errors.NotValidf("Timeout greater than %v", maxClientPingInterval)
+	}
+	if c.MaxTimeout > maxClientPingInterval {
+		return errors.NotValidf("MaxTimeout greater than %v", maxClientPingInterval)
+	}
+	if min, _ := c.bounds(); c.MaxTimeout != 0 && c.MaxTimeout < min {
+		return errors.NotValidf("MaxTimeout less than Timeout")
+	}
+	return nil
+}
+
+// bounds returns the base and maximum timeouts, with defaults filled
+// in. Neither is ever more than maxClientPingInterval.
+func (c PingTimeoutConfig) bounds() (base, max time.Duration) {
+	base = c.Timeout
+	if base == 0 || base > maxClientPingInterval {
+		base = maxClientPingInterval
+	}
+	max = c.MaxTimeout
+	if max == 0 || max > maxClientPingInterval {
+		max = maxClientPingInterval
+	}
+	if max < base {
+		max = base
+	}
+	return base, max
+}
+
+// newPinger returns a Pinger that invokes the given action when the
+// connection is considered dead according to the config.
+func (c PingTimeoutConfig) newPinger(action func(), clock clock.Clock) Pinger {
+	base, max := c.bounds()
+	if !c.Adaptive {
+		return newPingTimeout(action, clock, base)
+	}
+	return newAdaptivePingTimeout(action, clock, api.PingPeriod, base, max)
+}
+
 // pingTimeout listens for pings and will call the
 // passed action in case of a timeout. This way broken
 // or inactive connections can be closed.
@@ -45,6 +120,13 @@ type pingTimeout struct {
 	clock   clock.Clock
 	timeout time.Duration
 	reset   chan struct{}
+
+	// adaptive, if not nil, adjusts the timeout with the round-trip
+	// time measured from each pair of pings. The client waits
+	// pingPeriod between receiving a ping reply and sending its next
+	// ping.
+	adaptive   *adaptiveTimeout
+	pingPeriod time.Duration
 }
 
 // newPingTimeout returns a new pingTimeout instance
@@ -52,12 +134,31 @@ type pingTimeout struct {
 // is more than the given timeout interval between calls
 // to its Ping method.
 func newPingTimeout(action func(), clock clock.Clock, timeout time.Duration) Pinger {
-	pt := &pingTimeout{
+	return startPingTimeout(&pingTimeout{
 		action:  action,
 		clock:   clock,
 		timeout: timeout,
 		reset:   make(chan struct{}),
-	}
+	})
+}
+
+// newAdaptivePingTimeout is like newPingTimeout, except that it takes
+// however much longer than pingPeriod the gap between two pings is as
+// the round-trip time of the connection. From then on the timeout is
+// base plus pingLatencyFactor times the smoothed round-trip time, but
+// no more than max.
+func newAdaptivePingTimeout(action func(), clock clock.Clock, pingPeriod, base, max time.Duration) Pinger {
+	return startPingTimeout(&pingTimeout{
+		action:     action,
+		clock:      clock,
+		timeout:    base,
+		reset:      make(chan struct{}),
+		adaptive:   &adaptiveTimeout{base: base, max: max},
+		pingPeriod: pingPeriod,
+	})
+}
+
+func startPingTimeout(pt *pingTimeout) *pingTimeout {
 	go func() {
 		defer pt.tomb.Done()
 		pt.tomb.Kill(pt.loop())
@@ -83,18 +184,60 @@ func (pt *pingTimeout) Stop() error {
 // loop waits for a reset signal, otherwise it performs
 // the initially passed action.
 func (pt *pingTimeout) loop() error {
+	timeout := pt.timeout
+	deadline := pt.clock.After(timeout)
+	var lastPing time.Time
 	for {
 		select {
 		case <-pt.tomb.Dying():
 			return tomb.ErrDying
 		case <-pt.reset:
-		case <-pt.clock.After(pt.timeout):
+			now := pt.clock.Now()
+			if pt.adaptive != nil && !lastPing.IsZero() {
+				roundTrip := now.Sub(lastPing) - pt.pingPeriod
+				if roundTrip < 0 {
+					roundTrip = 0
+				}
+				timeout = pt.adaptive.observe(roundTrip)
+			}
+			lastPing = now
+			deadline = pt.clock.After(timeout)
+		case <-deadline:
 			go pt.action()
 			return errors.New("ping timeout")
 		}
 	}
 }
 
+// adaptiveTimeout calculates a ping timeout from the round-trip times
+// measured on a connection.
+type adaptiveTimeout struct {
+	base, max time.Duration
+
+	// roundTrip holds the smoothed round-trip time, once measured
+	// is true.
+	roundTrip time.Duration
+	measured  bool
+}
+
+// observe records a measured round-trip time, and returns the timeout
+// to wait for the next ping.
+func (a *adaptiveTimeout) observe(roundTrip time.Duration) time.Duration {
+	if !a.measured {
+		a.roundTrip = roundTrip
+		a.measured = true
+	} else {
+		// Weight the history heavily, so that a single slow round
+		// trip does not swing the timeout.
+		a.roundTrip = (3*a.roundTrip + roundTrip) / 4
+	}
+	timeout := a.base + pingLatencyFactor*a.roundTrip
+	if timeout > a.max {
+		return a.max
+	}
+	return timeout
+}
+
 // nullPinger implements the pinger interface but just does nothing
 type nullPinger struct{}
 
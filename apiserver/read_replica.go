@@ -0,0 +1,39 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"sort"
+
+	"github.com/juju/utils/set"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+// readReplicaFacadeNames holds the root names of facades that may be
+// called on any controller. Every method of these facades must only
+// read state, and must not rely on resources (such as watchers) held
+// by the controller the client connected to.
+var readReplicaFacadeNames = set.NewStrings(
+	"Bundle",
+	"Charms",
+	"SSHClient",
+)
+
+// readReplicaHint returns the hint telling a client which of the given
+// facades it may call on any controller.
+func readReplicaHint(preferred bool, facades []params.FacadeVersions) *params.ReadReplicaHint {
+	names := []string{}
+	for _, facade := range facades {
+		if readReplicaFacadeNames.Contains(facade.Name) {
+			names = append(names, facade.Name)
+		}
+	}
+	sort.Strings(names)
+	return &params.ReadReplicaHint{
+		Preferred:   preferred,
+		Facades:     names,
+		Consistency: params.ReadConsistencyEventual,
+	}
+}
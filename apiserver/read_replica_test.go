@@ -0,0 +1,81 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"strings"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver"
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/rpc/rpcreflect"
+	"github.com/juju/juju/testing"
+)
+
+type readReplicaSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&readReplicaSuite{})
+
+func (s *readReplicaSuite) TestHintMarksReadFacadesOnly(c *gc.C) {
+	facades := []params.FacadeVersions{
+		{Name: "Application", Versions: []int{4}},
+		{Name: "Charms", Versions: []int{2}},
+		{Name: "Client", Versions: []int{1}},
+		{Name: "SSHClient", Versions: []int{1, 2}},
+		{Name: "Bundle", Versions: []int{1}},
+		{Name: "ModelConfig", Versions: []int{1}},
+	}
+	hint := apiserver.ReadReplicaHint(false, facades)
+	c.Assert(hint, jc.DeepEquals, &params.ReadReplicaHint{
+		Facades:     []string{"Bundle", "Charms", "SSHClient"},
+		Consistency: params.ReadConsistencyEventual,
+	})
+}
+
+func (s *readReplicaSuite) TestHintPreferred(c *gc.C) {
+	hint := apiserver.ReadReplicaHint(true, nil)
+	c.Assert(hint, jc.DeepEquals, &params.ReadReplicaHint{
+		Preferred:   true,
+		Facades:     []string{},
+		Consistency: params.ReadConsistencyEventual,
+	})
+}
+
+var writeMethodPrefixes = []string{
+	"Add", "Create", "Delete", "Destroy", "Remove", "Revoke",
+	"Save", "Set", "Update", "Watch",
+}
+
+func (s *readReplicaSuite) TestReadFacadesHaveNoWriteMethods(c *gc.C) {
+	for _, description := range common.Facades.List() {
+		if !apiserver.ReadReplicaFacades.Contains(description.Name) {
+			continue
+		}
+		for _, version := range description.Versions {
+			facadeType, err := common.Facades.GetType(description.Name, version)
+			c.Assert(err, jc.ErrorIsNil)
+			for _, method := range rpcreflect.ObjTypeOf(facadeType).MethodNames() {
+				for _, prefix := range writeMethodPrefixes {
+					c.Check(strings.HasPrefix(method, prefix), jc.IsFalse,
+						gc.Commentf("%s(%d).%s", description.Name, version, method))
+				}
+			}
+		}
+	}
+}
+
+func (s *readReplicaSuite) TestReadFacadesAreRegistered(c *gc.C) {
+	registered := make(map[string]bool)
+	for _, description := range common.Facades.List() {
+		registered[description.Name] = true
+	}
+	for _, name := range apiserver.ReadReplicaFacades.Values() {
+		c.Check(registered[name], jc.IsTrue, gc.Commentf("facade %q", name))
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+// errTooManyRequests is returned to clients that already have as many
+// API calls in progress as the per-entity request limit allows.
+var errTooManyRequests = &params.Error{
+	Message: "too many concurrent requests, try again later",
+	Code:    params.CodeTryAgain,
+}
+
+// entityRequestLimiter limits how many API calls each authenticated
+// entity may have in progress at once, across all of its connections.
+// Each entity has its own allowance, so one that is making too many
+// calls does not hold up the others. A limit that is not positive
+// means there is no limit.
+type entityRequestLimiter struct {
+	// mu guards the fields below it.
+	mu     sync.Mutex
+	limit  int
+	active map[string]int
+}
+
+// newEntityRequestLimiter returns an entityRequestLimiter that allows
+// each entity up to limit concurrent calls.
+func newEntityRequestLimiter(limit int) *entityRequestLimiter {
+	return &entityRequestLimiter{
+		limit:  limit,
+		active: make(map[string]int),
+	}
+}
+
+// Acquire reserves a call slot for the entity with the given tag, and
+// reports whether one was free.
+func (l *entityRequestLimiter) Acquire(entity string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit > 0 && l.active[entity] >= l.limit {
+		return false
+	}
+	l.active[entity]++
+	return true
+}
+
+// Release frees a call slot reserved by Acquire.
+func (l *entityRequestLimiter) Release(entity string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active[entity] <= 1 {
+		delete(l.active, entity)
+		return
+	}
+	l.active[entity]--
+}
+
+// Limit returns the current limit.
+func (l *entityRequestLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// SetLimit changes the limit, and reports whether it was different.
+// Calls already in progress are unaffected; if the limit is lowered
+// below an entity's number of them, it may make no more until enough
+// have finished.
+func (l *entityRequestLimiter) SetLimit(limit int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit == limit {
+		return false
+	}
+	l.limit = limit
+	return true
+}
+
+// isRequestLimited reports whether calls to the given method count
+// towards the per-entity request limit. Calls to a watcher's Next
+// method block until the watcher changes, and agents keep one in
+// progress for each of their watchers, so they are not limited.
+func isRequestLimited(rootName, methodName string) bool {
+	return !(strings.HasSuffix(rootName, "Watcher") && methodName == "Next")
+}
@@ -0,0 +1,103 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"sync"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type entityRequestLimiterSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&entityRequestLimiterSuite{})
+
+func (s *entityRequestLimiterSuite) TestAcquireUpToLimitPerEntity(c *gc.C) {
+	l := apiserver.NewEntityRequestLimiter(2)
+	c.Assert(l.Acquire("user-bob"), jc.IsTrue)
+	c.Assert(l.Acquire("user-bob"), jc.IsTrue)
+	c.Assert(l.Acquire("user-bob"), jc.IsFalse)
+
+	// Other entities have their own allowance.
+	c.Assert(l.Acquire("machine-0"), jc.IsTrue)
+	c.Assert(l.Acquire("machine-0"), jc.IsTrue)
+
+	l.Release("user-bob")
+	c.Assert(l.Acquire("user-bob"), jc.IsTrue)
+	c.Assert(l.Acquire("machine-0"), jc.IsFalse)
+}
+
+func (s *entityRequestLimiterSuite) TestNoLimit(c *gc.C) {
+	l := apiserver.NewEntityRequestLimiter(0)
+	for i := 0; i < 100; i++ {
+		c.Assert(l.Acquire("user-bob"), jc.IsTrue)
+	}
+}
+
+func (s *entityRequestLimiterSuite) TestSetLimit(c *gc.C) {
+	l := apiserver.NewEntityRequestLimiter(1)
+	c.Assert(l.SetLimit(1), jc.IsFalse)
+	c.Assert(l.Acquire("user-bob"), jc.IsTrue)
+	c.Assert(l.Acquire("user-bob"), jc.IsFalse)
+
+	c.Assert(l.SetLimit(2), jc.IsTrue)
+	c.Assert(l.Limit(), gc.Equals, 2)
+	c.Assert(l.Acquire("user-bob"), jc.IsTrue)
+	c.Assert(l.Acquire("user-bob"), jc.IsFalse)
+
+	c.Assert(l.SetLimit(0), jc.IsTrue)
+	c.Assert(l.Acquire("user-bob"), jc.IsTrue)
+}
+
+func (s *entityRequestLimiterSuite) TestConcurrentCallsFromOneEntity(c *gc.C) {
+	const limit = 3
+	const callers = 10
+	l := apiserver.NewEntityRequestLimiter(limit)
+
+	// All the callers try to start a call at once, and hold on to
+	// any slot they get until they have all tried.
+	var wg sync.WaitGroup
+	results := make(chan bool, callers)
+	start := make(chan struct{})
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			results <- l.Acquire("user-bob")
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(results)
+
+	var acquired int
+	for ok := range results {
+		if ok {
+			acquired++
+		}
+	}
+	c.Assert(acquired, gc.Equals, limit)
+
+	// Another entity is not affected.
+	c.Assert(l.Acquire("user-mary"), jc.IsTrue)
+
+	for i := 0; i < acquired; i++ {
+		l.Release("user-bob")
+	}
+	c.Assert(l.Acquire("user-bob"), jc.IsTrue)
+}
+
+func (s *entityRequestLimiterSuite) TestWatcherNextNotLimited(c *gc.C) {
+	c.Assert(apiserver.IsRequestLimited("NotifyWatcher", "Next"), jc.IsFalse)
+	c.Assert(apiserver.IsRequestLimited("AllWatcher", "Next"), jc.IsFalse)
+	c.Assert(apiserver.IsRequestLimited("NotifyWatcher", "Stop"), jc.IsTrue)
+	c.Assert(apiserver.IsRequestLimited("Client", "FullStatus"), jc.IsTrue)
+}
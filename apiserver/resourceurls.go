@@ -0,0 +1,65 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils/clock"
+
+	"github.com/juju/juju/apiserver/resourceurls"
+	resourceapi "github.com/juju/juju/resource/api"
+)
+
+// signedResourceDownloadHandler serves resource downloads authorized
+// by a signed URL rather than by logging in.
+type signedResourceDownloadHandler struct {
+	ctxt  httpContext
+	clock clock.Clock
+}
+
+func (h *signedResourceDownloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.serveGet(w, r); err != nil {
+		if err := sendError(w, err); err != nil {
+			logger.Errorf("%v", err)
+		}
+	}
+}
+
+func (h *signedResourceDownloadHandler) serveGet(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != "GET" {
+		return errors.MethodNotAllowedf("unsupported method: %q", r.Method)
+	}
+	st, releaser, err := h.ctxt.stateForRequestUnauthenticated(r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer releaser()
+
+	query := r.URL.Query()
+	application := query.Get(":application")
+	name := query.Get(":resource")
+	signer := resourceurls.NewSigner(st, h.clock, resourceurls.SignedURLTTL)
+	if err := signer.Verify(application, name, query); err != nil {
+		return errors.Trace(err)
+	}
+	resources, err := st.Resources()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	res, reader, err := resources.OpenResource(application, name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer reader.Close()
+
+	resourceapi.UpdateDownloadResponse(w, res)
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, reader); err != nil {
+		logger.Errorf("resource download failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package resourceurls
+
+import (
+	"time"
+
+	"gopkg.in/juju/names.v2"
+
+	"github.com/juju/juju/state"
+)
+
+// SignerBackend defines the state functionality required to sign and
+// verify resource URLs.
+type SignerBackend interface {
+	ModelUUID() string
+	ResourceURLKey() ([]byte, error)
+	RevokeResourceURL(signature string, expires time.Time) error
+	IsResourceURLRevoked(signature string) (bool, error)
+}
+
+// Backend defines the state functionality required by the
+// ResourceURLs facade.
+type Backend interface {
+	SignerBackend
+	ModelTag() names.ModelTag
+	Resources() (state.Resources, error)
+}
@@ -0,0 +1,11 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package resourceurls defines the ResourceURLs API facade, which
+// mints and revokes short-lived signed URLs from which application
+// resources can be downloaded without logging in to the API.
+//
+// The signing key and the revoked URLs are held in state, so a URL
+// signed by any of the controller's API servers is accepted, or
+// rejected once revoked, by all of them.
+package resourceurls
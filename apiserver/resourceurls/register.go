@@ -0,0 +1,24 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package resourceurls
+
+import (
+	"github.com/juju/utils/clock"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/facade"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("ResourceURLs", 1, newAPIShim)
+}
+
+func newAPIShim(
+	st *state.State,
+	resources facade.Resources,
+	authorizer facade.Authorizer,
+) (*API, error) {
+	return NewAPI(st, authorizer, NewSigner(st, clock.WallClock, SignedURLTTL))
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package resourceurls
+
+import (
+	"net/url"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/facade"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/permission"
+)
+
+// API implements the ResourceURLs facade, which mints and revokes
+// signed resource download URLs.
+type API struct {
+	backend    Backend
+	authorizer facade.Authorizer
+	signer     *Signer
+}
+
+// NewAPI returns a new ResourceURLs facade for the backend's model,
+// signing URLs with the given signer.
+func NewAPI(backend Backend, authorizer facade.Authorizer, signer *Signer) (*API, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &API{
+		backend:    backend,
+		authorizer: authorizer,
+		signer:     signer,
+	}, nil
+}
+
+func (api *API) checkAccess(access permission.Access) error {
+	ok, err := api.authorizer.HasPermission(access, api.backend.ModelTag())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !ok {
+		return common.ErrPerm
+	}
+	return nil
+}
+
+// SignResourceURLs returns a short-lived URL for each of the given
+// application resources, from which it can be downloaded without
+// logging in.
+func (api *API) SignResourceURLs(args params.ResourceURLArgs) (params.ResourceURLResults, error) {
+	if err := api.checkAccess(permission.ReadAccess); err != nil {
+		return params.ResourceURLResults{}, errors.Trace(err)
+	}
+	resources, err := api.backend.Resources()
+	if err != nil {
+		return params.ResourceURLResults{}, errors.Trace(err)
+	}
+	results := make([]params.ResourceURLResult, len(args.Resources))
+	for i, arg := range args.Resources {
+		if _, err := resources.GetResource(arg.Application, arg.Name); err != nil {
+			results[i].Error = common.ServerError(err)
+			continue
+		}
+		results[i].URL, results[i].Expires, err = api.signer.Sign(arg.Application, arg.Name)
+		results[i].Error = common.ServerError(err)
+	}
+	return params.ResourceURLResults{Results: results}, nil
+}
+
+// RevokeResourceURLs stops the given signed URLs from being accepted
+// before they expire. Only users with write access to the model can
+// revoke its URLs.
+func (api *API) RevokeResourceURLs(args params.ResourceURLs) (params.ErrorResults, error) {
+	if err := api.checkAccess(permission.WriteAccess); err != nil {
+		return params.ErrorResults{}, errors.Trace(err)
+	}
+	results := make([]params.ErrorResult, len(args.URLs))
+	for i, rawURL := range args.URLs {
+		u, err := url.Parse(rawURL)
+		if err == nil {
+			err = api.signer.Revoke(u)
+		}
+		results[i].Error = common.ServerError(err)
+	}
+	return params.ErrorResults{Results: results}, nil
+}
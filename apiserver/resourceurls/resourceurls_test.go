@@ -0,0 +1,191 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package resourceurls_test
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/names.v2"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/apiserver/resourceurls"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/resource"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+)
+
+// Ensure that Backend remains compatible with *state.State
+var _ resourceurls.Backend = (*state.State)(nil)
+
+type Suite struct {
+	coretesting.BaseSuite
+
+	clock      *testing.Clock
+	backend    *stubBackend
+	authorizer apiservertesting.FakeAuthorizer
+}
+
+var _ = gc.Suite(&Suite{})
+
+func (s *Suite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.clock = testing.NewClock(time.Now())
+	s.backend = newStubBackend()
+	s.authorizer = apiservertesting.FakeAuthorizer{
+		Tag: names.NewUserTag("admin"),
+	}
+}
+
+func (s *Suite) TestAuthNotClient(c *gc.C) {
+	s.authorizer.Tag = names.NewMachineTag("0")
+	_, err := s.makeAPI()
+	c.Assert(err, gc.Equals, common.ErrPerm)
+}
+
+func (s *Suite) TestSignResourceURLs(c *gc.C) {
+	api := s.mustMakeAPI(c)
+	results, err := api.SignResourceURLs(params.ResourceURLArgs{
+		Resources: []params.ResourceURLArg{
+			{Application: "mysql", Name: "bin"},
+			{Application: "mysql", Name: "missing"},
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results, gc.HasLen, 2)
+
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	u := parseURL(c, results.Results[0].URL)
+	c.Assert(u.Path, gc.Equals, "/model/"+coretesting.ModelTag.Id()+"/applications/mysql/resources/bin/signed")
+	c.Assert(results.Results[0].Expires, gc.Equals, s.clock.Now().Add(time.Minute).Truncate(time.Second))
+
+	c.Assert(results.Results[1].Error, gc.ErrorMatches, "resource missing not found")
+	c.Assert(results.Results[1].URL, gc.Equals, "")
+}
+
+func (s *Suite) TestSignResourceURLsReadOnly(c *gc.C) {
+	s.authorizer.Tag = names.NewUserTag("read")
+	api := s.mustMakeAPI(c)
+	results, err := api.SignResourceURLs(params.ResourceURLArgs{
+		Resources: []params.ResourceURLArg{{Application: "mysql", Name: "bin"}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+}
+
+func (s *Suite) TestSignResourceURLsNoAccess(c *gc.C) {
+	s.authorizer.Tag = names.NewUserTag("dorothy")
+	api := s.mustMakeAPI(c)
+	_, err := api.SignResourceURLs(params.ResourceURLArgs{
+		Resources: []params.ResourceURLArg{{Application: "mysql", Name: "bin"}},
+	})
+	c.Assert(err, gc.Equals, common.ErrPerm)
+}
+
+func (s *Suite) TestRevokeResourceURLs(c *gc.C) {
+	signer := resourceurls.NewSigner(s.backend, s.clock, time.Minute)
+	rawURL, _, err := signer.Sign("mysql", "bin")
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.authorizer.Tag = names.NewUserTag("write")
+	api := s.mustMakeAPI(c)
+	results, err := api.RevokeResourceURLs(params.ResourceURLs{
+		URLs: []string{rawURL, "%zz"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results, gc.HasLen, 2)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	c.Assert(results.Results[1].Error, gc.NotNil)
+
+	query := parseURL(c, rawURL).Query()
+	err = signer.Verify("mysql", "bin", query)
+	c.Assert(err, gc.ErrorMatches, "resource URL revoked")
+}
+
+func (s *Suite) TestRevokeResourceURLsReadOnly(c *gc.C) {
+	signer := resourceurls.NewSigner(s.backend, s.clock, time.Minute)
+	rawURL, _, err := signer.Sign("mysql", "bin")
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.authorizer.Tag = names.NewUserTag("read")
+	api := s.mustMakeAPI(c)
+	_, err = api.RevokeResourceURLs(params.ResourceURLs{URLs: []string{rawURL}})
+	c.Assert(err, gc.Equals, common.ErrPerm)
+	c.Assert(s.backend.revoked, gc.HasLen, 0)
+}
+
+func (s *Suite) makeAPI() (*resourceurls.API, error) {
+	signer := resourceurls.NewSigner(s.backend, s.clock, time.Minute)
+	return resourceurls.NewAPI(s.backend, s.authorizer, signer)
+}
+
+func (s *Suite) mustMakeAPI(c *gc.C) *resourceurls.API {
+	api, err := s.makeAPI()
+	c.Assert(err, jc.ErrorIsNil)
+	return api
+}
+
+func parseURL(c *gc.C, rawURL string) *url.URL {
+	u, err := url.Parse(rawURL)
+	c.Assert(err, jc.ErrorIsNil)
+	return u
+}
+
+type stubBackend struct {
+	resourceurls.Backend
+	modelUUID string
+	key       []byte
+	revoked   map[string]time.Time
+}
+
+func newStubBackend() *stubBackend {
+	return &stubBackend{
+		modelUUID: coretesting.ModelTag.Id(),
+		key:       []byte("sekrit"),
+		revoked:   make(map[string]time.Time),
+	}
+}
+
+func (b *stubBackend) ModelUUID() string {
+	return b.modelUUID
+}
+
+func (b *stubBackend) ModelTag() names.ModelTag {
+	return names.NewModelTag(b.modelUUID)
+}
+
+func (b *stubBackend) ResourceURLKey() ([]byte, error) {
+	return b.key, nil
+}
+
+func (b *stubBackend) RevokeResourceURL(signature string, expires time.Time) error {
+	b.revoked[signature] = expires
+	return nil
+}
+
+func (b *stubBackend) IsResourceURLRevoked(signature string) (bool, error) {
+	_, ok := b.revoked[signature]
+	return ok, nil
+}
+
+func (b *stubBackend) Resources() (state.Resources, error) {
+	return stubResources{}, nil
+}
+
+type stubResources struct {
+	state.Resources
+}
+
+func (stubResources) GetResource(application, name string) (resource.Resource, error) {
+	if name != "bin" {
+		return resource.Resource{}, errors.NotFoundf("resource %s", name)
+	}
+	return resource.Resource{}, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package resourceurls
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils/clock"
+)
+
+// SignedURLTTL is how long a signed resource download URL remains
+// valid.
+const SignedURLTTL = 5 * time.Minute
+
+// Signer signs and verifies the download URLs of the resources in a
+// model, so that a resource can be downloaded without logging in to
+// the API.
+type Signer struct {
+	backend SignerBackend
+	clock   clock.Clock
+	ttl     time.Duration
+}
+
+// NewSigner returns a Signer for the backend's model, whose URLs
+// remain valid for the given time.
+func NewSigner(backend SignerBackend, clock clock.Clock, ttl time.Duration) *Signer {
+	return &Signer{
+		backend: backend,
+		clock:   clock,
+		ttl:     ttl,
+	}
+}
+
+// Sign returns a URL from which the named application resource can be
+// downloaded until the returned expiry time.
+func (s *Signer) Sign(application, name string) (string, time.Time, error) {
+	expires := s.clock.Now().Add(s.ttl).Truncate(time.Second)
+	signature, err := s.signature(application, name, expires.Unix())
+	if err != nil {
+		return "", time.Time{}, errors.Trace(err)
+	}
+	query := url.Values{
+		"expires":   []string{strconv.FormatInt(expires.Unix(), 10)},
+		"signature": []string{signature},
+	}
+	// Model UUIDs, application names and resource names are all
+	// valid in URL paths without escaping.
+	path := fmt.Sprintf("/model/%s/applications/%s/resources/%s/signed", s.backend.ModelUUID(), application, name)
+	return path + "?" + query.Encode(), expires, nil
+}
+
+// Verify returns an error unless query holds an unexpired, unrevoked
+// signature for the named application resource.
+func (s *Signer) Verify(application, name string, query url.Values) error {
+	signature, expires, err := s.check(application, name, query)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !s.clock.Now().Before(expires) {
+		return errors.Unauthorizedf("resource URL expired")
+	}
+	revoked, err := s.backend.IsResourceURLRevoked(signature)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if revoked {
+		return errors.Unauthorizedf("resource URL revoked")
+	}
+	return nil
+}
+
+// Revoke stops the given URL, which must have been signed for a
+// resource in this model, from being accepted even though it has not
+// expired yet.
+func (s *Signer) Revoke(u *url.URL) error {
+	// The path is /model/<uuid>/applications/<application>/resources/<name>/signed.
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 7 || parts[0] != "model" || parts[2] != "applications" || parts[4] != "resources" || parts[6] != "signed" {
+		return errors.NotValidf("resource URL path %q", u.Path)
+	}
+	if parts[1] != s.backend.ModelUUID() {
+		return errors.NotValidf("resource URL for model %q", parts[1])
+	}
+	signature, expires, err := s.check(parts[3], parts[5], u.Query())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !s.clock.Now().Before(expires) {
+		// Expired URLs are rejected anyway.
+		return nil
+	}
+	return errors.Trace(s.backend.RevokeResourceURL(signature, expires))
+}
+
+// check returns the signature and expiry time in query, or an error if
+// they are not valid for the named application resource.
+func (s *Signer) check(application, name string, query url.Values) (string, time.Time, error) {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return "", time.Time{}, errors.Unauthorizedf("invalid resource URL expiry")
+	}
+	expected, err := s.signature(application, name, expires)
+	if err != nil {
+		return "", time.Time{}, errors.Trace(err)
+	}
+	signature := query.Get("signature")
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", time.Time{}, errors.Unauthorizedf("invalid resource URL signature")
+	}
+	return signature, time.Unix(expires, 0), nil
+}
+
+func (s *Signer) signature(application, name string, expires int64) (string, error) {
+	key, err := s.backend.ResourceURLKey()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d", s.backend.ModelUUID(), application, name, expires)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
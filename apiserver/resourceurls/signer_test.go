@@ -0,0 +1,167 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package resourceurls_test
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/resourceurls"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type SignerSuite struct {
+	coretesting.BaseSuite
+
+	clock   *testing.Clock
+	backend *stubBackend
+	signer  *resourceurls.Signer
+}
+
+var _ = gc.Suite(&SignerSuite{})
+
+func (s *SignerSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.clock = testing.NewClock(time.Now())
+	s.backend = newStubBackend()
+	s.signer = resourceurls.NewSigner(s.backend, s.clock, time.Minute)
+}
+
+func (s *SignerSuite) sign(c *gc.C, application, name string) (*url.URL, time.Time) {
+	rawURL, expires, err := s.signer.Sign(application, name)
+	c.Assert(err, jc.ErrorIsNil)
+	return parseURL(c, rawURL), expires
+}
+
+func (s *SignerSuite) TestSignAndVerify(c *gc.C) {
+	u, expires := s.sign(c, "mysql", "bin")
+	c.Assert(expires.After(s.clock.Now()), jc.IsTrue)
+	c.Assert(u.Path, gc.Equals, "/model/"+coretesting.ModelTag.Id()+"/applications/mysql/resources/bin/signed")
+
+	err := s.signer.Verify("mysql", "bin", u.Query())
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *SignerSuite) TestVerifySharedKey(c *gc.C) {
+	u, _ := s.sign(c, "mysql", "bin")
+
+	// Another API server using the same state accepts the URL.
+	other := resourceurls.NewSigner(s.backend, s.clock, time.Minute)
+	err := other.Verify("mysql", "bin", u.Query())
+	c.Assert(err, jc.ErrorIsNil)
+
+	// A signer with a different key does not.
+	backend := newStubBackend()
+	backend.key = []byte("other")
+	other = resourceurls.NewSigner(backend, s.clock, time.Minute)
+	err = other.Verify("mysql", "bin", u.Query())
+	c.Assert(err, gc.ErrorMatches, "invalid resource URL signature")
+}
+
+func (s *SignerSuite) TestVerifyScopedToResource(c *gc.C) {
+	u, _ := s.sign(c, "mysql", "bin")
+	query := u.Query()
+
+	err := s.signer.Verify("wordpress", "bin", query)
+	c.Check(err, gc.ErrorMatches, "invalid resource URL signature")
+	c.Check(err, jc.Satisfies, errors.IsUnauthorized)
+	err = s.signer.Verify("mysql", "data", query)
+	c.Check(err, gc.ErrorMatches, "invalid resource URL signature")
+
+	backend := newStubBackend()
+	backend.modelUUID = "deadbeef-0bad-400d-8000-4b1d0d06f00e"
+	other := resourceurls.NewSigner(backend, s.clock, time.Minute)
+	err = other.Verify("mysql", "bin", query)
+	c.Check(err, gc.ErrorMatches, "invalid resource URL signature")
+}
+
+func (s *SignerSuite) TestVerifyTampered(c *gc.C) {
+	u, expires := s.sign(c, "mysql", "bin")
+	query := u.Query()
+
+	tampered := url.Values{
+		"expires":   []string{query.Get("expires")},
+		"signature": []string{strings.Repeat("0", len(query.Get("signature")))},
+	}
+	err := s.signer.Verify("mysql", "bin", tampered)
+	c.Check(err, gc.ErrorMatches, "invalid resource URL signature")
+
+	// Extending the expiry invalidates the signature.
+	tampered = url.Values{
+		"expires":   []string{strconv.FormatInt(expires.Add(time.Hour).Unix(), 10)},
+		"signature": []string{query.Get("signature")},
+	}
+	err = s.signer.Verify("mysql", "bin", tampered)
+	c.Check(err, gc.ErrorMatches, "invalid resource URL signature")
+
+	tampered.Set("expires", "soon")
+	err = s.signer.Verify("mysql", "bin", tampered)
+	c.Check(err, gc.ErrorMatches, "invalid resource URL expiry")
+}
+
+func (s *SignerSuite) TestVerifyExpired(c *gc.C) {
+	u, expires := s.sign(c, "mysql", "bin")
+
+	s.clock.Advance(expires.Sub(s.clock.Now()) - time.Nanosecond)
+	err := s.signer.Verify("mysql", "bin", u.Query())
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.clock.Advance(time.Nanosecond)
+	err = s.signer.Verify("mysql", "bin", u.Query())
+	c.Assert(err, gc.ErrorMatches, "resource URL expired")
+	c.Assert(err, jc.Satisfies, errors.IsUnauthorized)
+}
+
+func (s *SignerSuite) TestRevoke(c *gc.C) {
+	revoked, expires := s.sign(c, "mysql", "bin")
+	s.clock.Advance(time.Second)
+	other, _ := s.sign(c, "mysql", "bin")
+
+	err := s.signer.Revoke(revoked)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.backend.revoked, gc.HasLen, 1)
+	c.Assert(s.backend.revoked[revoked.Query().Get("signature")].Equal(expires), jc.IsTrue)
+	err = s.signer.Verify("mysql", "bin", revoked.Query())
+	c.Assert(err, gc.ErrorMatches, "resource URL revoked")
+	c.Assert(err, jc.Satisfies, errors.IsUnauthorized)
+
+	// Other URLs for the same resource are unaffected.
+	err = s.signer.Verify("mysql", "bin", other.Query())
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *SignerSuite) TestRevokeExpired(c *gc.C) {
+	u, expires := s.sign(c, "mysql", "bin")
+	s.clock.Advance(expires.Sub(s.clock.Now()))
+
+	err := s.signer.Revoke(u)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.backend.revoked, gc.HasLen, 0)
+}
+
+func (s *SignerSuite) TestRevokeInvalid(c *gc.C) {
+	u, _ := s.sign(c, "mysql", "bin")
+
+	tampered := *u
+	tampered.Path = "/model/" + coretesting.ModelTag.Id() + "/tools/2.2.0"
+	err := s.signer.Revoke(&tampered)
+	c.Check(err, gc.ErrorMatches, `resource URL path ".*" not valid`)
+
+	tampered.Path = strings.Replace(u.Path, coretesting.ModelTag.Id(), "deadbeef-0bad-400d-8000-4b1d0d06f00e", 1)
+	err = s.signer.Revoke(&tampered)
+	c.Check(err, gc.ErrorMatches, `resource URL for model "deadbeef-0bad-400d-8000-4b1d0d06f00e" not valid`)
+
+	tampered.Path = strings.Replace(u.Path, "/resources/bin/", "/resources/data/", 1)
+	err = s.signer.Revoke(&tampered)
+	c.Check(err, gc.ErrorMatches, "invalid resource URL signature")
+
+	c.Assert(s.backend.revoked, gc.HasLen, 0)
+}
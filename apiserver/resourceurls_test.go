@@ -0,0 +1,134 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	charmresource "gopkg.in/juju/charm.v6-unstable/resource"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/component/all"
+)
+
+type signedResourceDownloadSuite struct {
+	authHTTPSuite
+	appName string
+}
+
+var _ = gc.Suite(&signedResourceDownloadSuite{})
+
+func parseResourceURL(c *gc.C, rawURL string) *url.URL {
+	u, err := url.Parse(rawURL)
+	c.Assert(err, jc.ErrorIsNil)
+	return u
+}
+
+func (s *signedResourceDownloadSuite) SetUpSuite(c *gc.C) {
+	s.authHTTPSuite.SetUpSuite(c)
+	all.RegisterForServer()
+}
+
+func (s *signedResourceDownloadSuite) SetUpTest(c *gc.C) {
+	s.authHTTPSuite.SetUpTest(c)
+	s.appName = s.Factory.MakeApplication(c, nil).Name()
+
+	fp, err := charmresource.GenerateFingerprint(strings.NewReader(content))
+	c.Assert(err, jc.ErrorIsNil)
+	res := charmresource.Resource{
+		Meta: charmresource.Meta{
+			Name: "bin",
+			Type: charmresource.TypeFile,
+			Path: "blob.zip",
+		},
+		Origin:      charmresource.OriginUpload,
+		Size:        int64(len(content)),
+		Fingerprint: fp,
+	}
+	resources, err := s.State.Resources()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = resources.SetResource(s.appName, "admin", res, strings.NewReader(content))
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *signedResourceDownloadSuite) signURLs(c *gc.C, args ...params.ResourceURLArg) []params.ResourceURLResult {
+	var results params.ResourceURLResults
+	err := s.APIState.APICall("ResourceURLs", 1, "", "SignResourceURLs", params.ResourceURLArgs{
+		Resources: args,
+	}, &results)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results, gc.HasLen, len(args))
+	return results.Results
+}
+
+func (s *signedResourceDownloadSuite) signURL(c *gc.C) *url.URL {
+	results := s.signURLs(c, params.ResourceURLArg{Application: s.appName, Name: "bin"})
+	c.Assert(results[0].Error, gc.IsNil)
+	signed := parseResourceURL(c, results[0].URL)
+	return s.makeURL(c, "https", signed.Path, signed.Query())
+}
+
+func (s *signedResourceDownloadSuite) TestDownload(c *gc.C) {
+	// No credentials are sent; the signature is enough.
+	resp := s.sendRequest(c, httpRequestParams{method: "GET", url: s.signURL(c).String()})
+	body := assertResponse(c, resp, http.StatusOK, "application/octet-stream")
+	c.Assert(string(body), gc.Equals, content)
+}
+
+func (s *signedResourceDownloadSuite) TestDownloadTampered(c *gc.C) {
+	u := s.signURL(c)
+	query := u.Query()
+	query.Set("signature", strings.Repeat("0", len(query.Get("signature"))))
+	u.RawQuery = query.Encode()
+	resp := s.sendRequest(c, httpRequestParams{method: "GET", url: u.String()})
+	body := assertResponse(c, resp, http.StatusUnauthorized, params.ContentTypeJSON)
+	c.Assert(string(body), jc.Contains, "invalid resource URL signature")
+}
+
+func (s *signedResourceDownloadSuite) TestDownloadOtherResource(c *gc.C) {
+	u := s.signURL(c)
+	u.Path = strings.Replace(u.Path, "/resources/bin/", "/resources/other/", 1)
+	resp := s.sendRequest(c, httpRequestParams{method: "GET", url: u.String()})
+	body := assertResponse(c, resp, http.StatusUnauthorized, params.ContentTypeJSON)
+	c.Assert(string(body), jc.Contains, "invalid resource URL signature")
+}
+
+func (s *signedResourceDownloadSuite) TestDownloadExpired(c *gc.C) {
+	u := s.signURL(c)
+	query := u.Query()
+	query.Set("expires", "1")
+	u.RawQuery = query.Encode()
+	resp := s.sendRequest(c, httpRequestParams{method: "GET", url: u.String()})
+	assertResponse(c, resp, http.StatusUnauthorized, params.ContentTypeJSON)
+}
+
+func (s *signedResourceDownloadSuite) TestDownloadRevoked(c *gc.C) {
+	u := s.signURL(c)
+	var results params.ErrorResults
+	err := s.APIState.APICall("ResourceURLs", 1, "", "RevokeResourceURLs", params.ResourceURLs{
+		URLs: []string{u.String()},
+	}, &results)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.OneError(), jc.ErrorIsNil)
+
+	resp := s.sendRequest(c, httpRequestParams{method: "GET", url: u.String()})
+	body := assertResponse(c, resp, http.StatusUnauthorized, params.ContentTypeJSON)
+	c.Assert(string(body), jc.Contains, "resource URL revoked")
+}
+
+func (s *signedResourceDownloadSuite) TestPOSTUnsupported(c *gc.C) {
+	resp := s.sendRequest(c, httpRequestParams{method: "POST", url: s.signURL(c).String()})
+	body := assertResponse(c, resp, http.StatusMethodNotAllowed, params.ContentTypeJSON)
+	c.Assert(string(body), jc.Contains, `unsupported method: \"POST\"`)
+}
+
+func (s *signedResourceDownloadSuite) TestSignUnknownResource(c *gc.C) {
+	results := s.signURLs(c, params.ResourceURLArg{Application: s.appName, Name: "missing"})
+	c.Assert(results[0].Error, gc.NotNil)
+	c.Assert(results[0].URL, gc.Equals, "")
+}
@@ -0,0 +1,69 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/utils/set"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+)
+
+func readOnlyMethodsOnly(facadeName, methodName string) error {
+	if !IsMethodAllowedForReadOnlyLogin(facadeName, methodName) {
+		return errors.Annotatef(common.ErrPerm, "read-only login cannot call %s.%s", facadeName, methodName)
+	}
+	return nil
+}
+
+// IsMethodAllowedForReadOnlyLogin reports whether the given method of
+// the given facade may be called by a connection that logged in with
+// ReadOnly set.
+func IsMethodAllowedForReadOnlyLogin(facadeName, methodName string) bool {
+	methods, ok := allowedMethodsForReadOnlyLogin[facadeName]
+	if !ok {
+		return false
+	}
+	return methods.Contains(methodName)
+}
+
+// readOnlyFacades returns those of the given facades that a read-only
+// login may use.
+func readOnlyFacades(facades []params.FacadeVersions) []params.FacadeVersions {
+	out := make([]params.FacadeVersions, 0, len(facades))
+	for _, facade := range facades {
+		if _, ok := allowedMethodsForReadOnlyLogin[facade.Name]; ok {
+			out = append(out, facade)
+		}
+	}
+	return out
+}
+
+// allowedMethodsForReadOnlyLogin stores the api calls, by facade name,
+// that may be made by read-only logins, as used by monitoring tools.
+// None of them may change anything.
+var allowedMethodsForReadOnlyLogin = map[string]set.Strings{
+	"Client": set.NewStrings(
+		"FullStatus",
+		"StatusHistory",
+		"ModelInfo",
+		"ModelUserInfo",
+		"GetModelConstraints",
+		"AgentVersion",
+		"APIHostPorts",
+		"WatchAll",
+	),
+	"AllWatcher": set.NewStrings(
+		"Next",
+		"Stop",
+	),
+	"ModelManager": set.NewStrings(
+		"ListModels",
+		"ModelInfo",
+	),
+	"Pinger": set.NewStrings(
+		"Ping",
+	),
+}
@@ -0,0 +1,41 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver"
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/testing"
+)
+
+type restrictReadOnlySuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&restrictReadOnlySuite{})
+
+func (r *restrictReadOnlySuite) TestAllowedMethods(c *gc.C) {
+	root := apiserver.TestingReadOnlyRoot(nil)
+	checkAllowed := func(facade, method string) {
+		caller, err := root.FindMethod(facade, 1, method)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(caller, gc.NotNil)
+	}
+	checkAllowed("Client", "FullStatus")
+	checkAllowed("Client", "StatusHistory")
+	checkAllowed("AllWatcher", "Next")
+	checkAllowed("Pinger", "Ping")
+}
+
+func (r *restrictReadOnlySuite) TestFindDisallowedMethod(c *gc.C) {
+	root := apiserver.TestingReadOnlyRoot(nil)
+	caller, err := root.FindMethod("Client", 1, "ModelSet")
+	c.Assert(err, gc.ErrorMatches, "read-only login cannot call Client.ModelSet: permission denied")
+	c.Assert(errors.Cause(err), gc.Equals, common.ErrPerm)
+	c.Assert(caller, gc.IsNil)
+}
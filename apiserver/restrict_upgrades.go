@@ -4,8 +4,7 @@
 package apiserver
 
 import (
-	"github.com/juju/utils/set"
-
+	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
 )
 
@@ -16,39 +15,12 @@ func upgradeMethodsOnly(facadeName, methodName string) error {
 	return nil
 }
 
+// IsMethodAllowedDuringUpgrade reports whether the given method of the
+// given facade may be called while the controller is being upgraded.
+// Facades declare such methods with common.RegisterUpgradeSafeMethods
+// when they are registered. When needed, at some future point, this
+// will need to be adjusted to cater for different facade versions as
+// well.
 func IsMethodAllowedDuringUpgrade(facadeName, methodName string) bool {
-	methods, ok := allowedMethodsDuringUpgrades[facadeName]
-	if !ok {
-		return false
-	}
-	return methods.Contains(methodName)
-}
-
-// allowedMethodsDuringUpgrades stores api calls
-// that are not blocked during the upgrade process
-// as well as  their respective facade names.
-// When needed, at some future point, this solution
-// will need to be adjusted to cater for different
-// facade versions as well.
-var allowedMethodsDuringUpgrades = map[string]set.Strings{
-	"Client": set.NewStrings(
-		"FullStatus",          // for "juju status"
-		"FindTools",           // for "juju upgrade-juju", before we can reset upgrade to re-run
-		"AbortCurrentUpgrade", // for "juju upgrade-juju", so that we can reset upgrade to re-run
-
-	),
-	"SSHClient": set.NewStrings( // allow all SSH client related calls
-		"PublicAddress",
-		"PrivateAddress",
-		"BestAPIVersion",
-		"AllAddresses",
-		"PublicKeys",
-		"Proxy",
-	),
-	"Pinger": set.NewStrings(
-		"Ping",
-	),
-	"Backups": set.NewStrings(
-		"FinishRestore",
-	),
+	return common.IsUpgradeSafeMethod(facadeName, methodName)
 }
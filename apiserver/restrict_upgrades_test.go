@@ -9,6 +9,7 @@ import (
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/juju/apiserver"
+	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/testing"
 )
@@ -33,6 +34,29 @@ func (r *restrictUpgradesSuite) TestAllowedMethods(c *gc.C) {
 	checkAllowed("Pinger", "Ping")
 }
 
+func (r *restrictUpgradesSuite) TestAllowedMethodsDuringUpgrades(c *gc.C) {
+	c.Assert(apiserver.AllowedMethodsDuringUpgrades(), jc.DeepEquals, []common.FacadeMethod{
+		{"Backups", "FinishRestore"},
+		{"Client", "AbortCurrentUpgrade"},
+		{"Client", "FindTools"},
+		{"Client", "FullStatus"},
+		{"Pinger", "Ping"},
+		{"SSHClient", "AllAddresses"},
+		{"SSHClient", "BestAPIVersion"},
+		{"SSHClient", "PrivateAddress"},
+		{"SSHClient", "Proxy"},
+		{"SSHClient", "PublicAddress"},
+		{"SSHClient", "PublicKeys"},
+	})
+}
+
+func (r *restrictUpgradesSuite) TestAllowedMethodsAreKeyedByFacade(c *gc.C) {
+	// FullStatus is only allowed on the facade that declared it.
+	c.Check(apiserver.IsMethodAllowedDuringUpgrade("Client", "FullStatus"), jc.IsTrue)
+	c.Check(apiserver.IsMethodAllowedDuringUpgrade("SSHClient", "FullStatus"), jc.IsFalse)
+	c.Check(apiserver.IsMethodAllowedDuringUpgrade("Client", "ModelSet"), jc.IsFalse)
+}
+
 func (r *restrictUpgradesSuite) TestFindDisallowedMethod(c *gc.C) {
 	root := apiserver.TestingUpgradingRoot(nil)
 	caller, err := root.FindMethod("Client", 1, "ModelSet")
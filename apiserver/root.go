@@ -4,6 +4,7 @@
 package apiserver
 
 import (
+	"crypto/x509"
 	"reflect"
 	"sync"
 	"time"
@@ -59,6 +60,10 @@ type apiHandler struct {
 	// serverHost is the host:port of the API server that the client
 	// connected to.
 	serverHost string
+
+	// peerCertificates holds the TLS certificates the client
+	// presented when connecting, if any.
+	peerCertificates []*x509.Certificate
 }
 
 var _ = (*apiHandler)(nil)
@@ -110,6 +115,12 @@ type srvCaller struct {
 	objMethod rpcreflect.ObjMethod
 	goType    reflect.Type
 	creator   func(id string) (reflect.Value, error)
+	breaker   *backendBreaker
+
+	// limiter, if not nil, limits how many calls entity may have in
+	// progress.
+	limiter *entityRequestLimiter
+	entity  string
 }
 
 // ParamsType defines the parameters that should be supplied to this function.
@@ -127,6 +138,18 @@ func (s *srvCaller) ResultType() reflect.Type {
 // Call takes the object Id and an instance of ParamsType to create an object and place
 // a call on its method. It then returns an instance of ResultType.
 func (s *srvCaller) Call(objId string, arg reflect.Value) (reflect.Value, error) {
+	if s.limiter != nil {
+		if !s.limiter.Acquire(s.entity) {
+			logger.Debugf("request limit reached for %s", s.entity)
+			return reflect.Value{}, errTooManyRequests
+		}
+		defer s.limiter.Release(s.entity)
+	}
+	if s.breaker != nil {
+		if err := s.breaker.Allow(); err != nil {
+			return reflect.Value{}, err
+		}
+	}
 	objVal, err := s.creator(objId)
 	if err != nil {
 		return reflect.Value{}, err
@@ -140,7 +163,14 @@ type apiRoot struct {
 	pool        *state.StatePool
 	resources   *common.Resources
 	authorizer  facade.Authorizer
+	breaker     *backendBreaker
 	objectMutex sync.RWMutex
+
+	// limiter, if not nil, limits how many calls the entity with the
+	// tag limitEntity may have in progress.
+	limiter     *entityRequestLimiter
+	limitEntity string
+
 	objectCache map[objectKey]reflect.Value
 }
 
@@ -218,10 +248,21 @@ func (r *apiRoot) FindMethod(rootName string, version int, methodName string) (r
 		r.objectCache[objKey] = objValue
 		return objValue, nil
 	}
-	return &srvCaller{
+	caller := &srvCaller{
 		creator:   creator,
 		objMethod: objMethod,
-	}, nil
+	}
+	// A watcher's Next call waits for the watcher to change rather
+	// than querying mongo itself, so like the request limiter, the
+	// breaker leaves such calls alone.
+	if isRequestLimited(rootName, methodName) {
+		caller.breaker = r.breaker
+	}
+	if r.limiter != nil && isRequestLimited(rootName, methodName) {
+		caller.limiter = r.limiter
+		caller.entity = r.limitEntity
+	}
+	return caller, nil
 }
 
 func (r *apiRoot) dispose(key objectKey) {
@@ -14,6 +14,7 @@ import (
 	gc "gopkg.in/check.v1"
 	"gopkg.in/juju/names.v2"
 
+	"github.com/juju/juju/api"
 	"github.com/juju/juju/apiserver"
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/facade"
@@ -77,6 +78,157 @@ func (r *pingSuite) TestPingTimeoutStopped(c *gc.C) {
 	}
 }
 
+func (r *pingSuite) TestAdaptivePingTimeoutFollowsRoundTrip(c *gc.C) {
+	for i, test := range []struct {
+		about  string
+		gap    time.Duration
+		expect time.Duration
+	}{{
+		about:  "round trip lengthens the timeout",
+		gap:    60 * time.Millisecond,
+		expect: 140 * time.Millisecond,
+	}, {
+		about:  "early ping leaves the base timeout",
+		gap:    40 * time.Millisecond,
+		expect: 100 * time.Millisecond,
+	}, {
+		about:  "timeout is capped",
+		gap:    100 * time.Millisecond,
+		expect: 200 * time.Millisecond,
+	}} {
+		c.Logf("test %d: %s", i, test.about)
+		triggered := make(chan struct{})
+		action := func() {
+			close(triggered)
+		}
+		clock := jujutesting.NewClock(time.Now())
+		timeout := apiserver.NewAdaptivePingTimeout(action, clock, 50*time.Millisecond, 100*time.Millisecond, 200*time.Millisecond)
+
+		// The first ping only starts the measurement.
+		waitAlarm(c, clock)
+		timeout.Ping()
+		waitAlarm(c, clock)
+		clock.Advance(test.gap)
+		timeout.Ping()
+
+		waitAlarm(c, clock)
+		assertPingTimeout(c, clock, triggered, test.expect)
+	}
+}
+
+func (r *pingSuite) TestAdaptivePingTimeoutSmoothsRoundTrip(c *gc.C) {
+	triggered := make(chan struct{})
+	action := func() {
+		close(triggered)
+	}
+	clock := jujutesting.NewClock(time.Now())
+	timeout := apiserver.NewAdaptivePingTimeout(action, clock, 50*time.Millisecond, 100*time.Millisecond, 200*time.Millisecond)
+
+	waitAlarm(c, clock)
+	timeout.Ping()
+	for _, gap := range []time.Duration{66 * time.Millisecond, 50 * time.Millisecond} {
+		waitAlarm(c, clock)
+		clock.Advance(gap)
+		timeout.Ping()
+	}
+
+	// The round trips were 16ms and 0ms, which smooth to 12ms.
+	waitAlarm(c, clock)
+	assertPingTimeout(c, clock, triggered, 148*time.Millisecond)
+}
+
+func (r *pingSuite) TestAdaptivePingTimeoutNeverExceedsMaxClientPingInterval(c *gc.C) {
+	apiserver.PatchMaxClientPingInterval(r, 2*time.Minute)
+	for i, config := range []apiserver.PingTimeoutConfig{{
+		Timeout:  90 * time.Second,
+		Adaptive: true,
+	}, {
+		// Validate rejects this, but the timeout is still capped.
+		Timeout:    90 * time.Second,
+		Adaptive:   true,
+		MaxTimeout: time.Hour,
+	}} {
+		c.Logf("test %d", i)
+		triggered := make(chan struct{})
+		action := func() {
+			close(triggered)
+		}
+		clock := jujutesting.NewClock(time.Now())
+		timeout := apiserver.NewConfigPinger(config, action, clock)
+
+		// A 20s round trip would make the timeout 170s.
+		waitAlarm(c, clock)
+		timeout.Ping()
+		waitAlarm(c, clock)
+		clock.Advance(api.PingPeriod + 20*time.Second)
+		timeout.Ping()
+
+		waitAlarm(c, clock)
+		assertPingTimeout(c, clock, triggered, 2*time.Minute)
+	}
+}
+
+func (r *pingSuite) TestPingTimeoutConfigValidate(c *gc.C) {
+	for i, test := range []struct {
+		config apiserver.PingTimeoutConfig
+		err    string
+	}{{
+		config: apiserver.PingTimeoutConfig{},
+	}, {
+		config: apiserver.PingTimeoutConfig{Adaptive: true, Timeout: 2 * time.Minute, MaxTimeout: 3 * time.Minute},
+	}, {
+		config: apiserver.PingTimeoutConfig{Timeout: -time.Second},
+		err:    "negative Timeout not valid",
+	}, {
+		config: apiserver.PingTimeoutConfig{MaxTimeout: -time.Second},
+		err:    "negative MaxTimeout not valid",
+	}, {
+		config: apiserver.PingTimeoutConfig{Timeout: 5 * time.Minute},
+		err:    "Timeout greater than 3m0s not valid",
+	}, {
+		config: apiserver.PingTimeoutConfig{Adaptive: true, MaxTimeout: 10 * time.Minute},
+		err:    "MaxTimeout greater than 3m0s not valid",
+	}, {
+		config: apiserver.PingTimeoutConfig{Timeout: time.Minute, MaxTimeout: time.Second},
+		err:    "MaxTimeout less than Timeout not valid",
+	}, {
+		config: apiserver.PingTimeoutConfig{MaxTimeout: time.Minute},
+		err:    "MaxTimeout less than Timeout not valid",
+	}} {
+		c.Logf("test %d", i)
+		err := test.config.Validate()
+		if test.err == "" {
+			c.Check(err, jc.ErrorIsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (r *pingSuite) TestPingTimeoutConfigValidatePatchedMaxClientPingInterval(c *gc.C) {
+	apiserver.PatchMaxClientPingInterval(r, 2*time.Minute)
+	config := apiserver.PingTimeoutConfig{Adaptive: true, MaxTimeout: 3 * time.Minute}
+	err := config.Validate()
+	c.Assert(err, gc.ErrorMatches, "MaxTimeout greater than 2m0s not valid")
+}
+
+// assertPingTimeout checks that the action is triggered exactly
+// expect after the clock's current time.
+func assertPingTimeout(c *gc.C, clock *jujutesting.Clock, triggered <-chan struct{}, expect time.Duration) {
+	clock.Advance(expect - time.Millisecond)
+	select {
+	case <-triggered:
+		c.Fatalf("action triggered early")
+	case <-time.After(testing.ShortWait):
+	}
+	clock.Advance(time.Millisecond)
+	select {
+	case <-triggered:
+	case <-time.After(testing.LongWait):
+		c.Fatalf("action never triggered")
+	}
+}
+
 func waitAlarm(c *gc.C, clock *jujutesting.Clock) {
 	select {
 	case <-time.After(testing.LongWait):
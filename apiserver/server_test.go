@@ -345,6 +345,32 @@ func (s *serverSuite) TestNoBakeryWhenNoIdentityURL(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "macaroon authentication is not configured")
 }
 
+// openWithoutLogin opens an API connection without logging in, so
+// that the Admin facade can be called.
+func openWithoutLogin(c *gc.C, info *api.Info) api.Connection {
+	info.Tag = nil
+	info.Password = ""
+	info.SkipLogin = true
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, jc.ErrorIsNil)
+	return st
+}
+
+func (s *serverSuite) TestMacaroonDischargeInfoNotConfigured(c *gc.C) {
+	st := openWithoutLogin(c, s.APIInfo(c))
+	defer st.Close()
+	_, _, err := st.MacaroonDischargeInfo()
+	c.Assert(err, gc.ErrorMatches, "macaroon authentication is not configured")
+}
+
+func (s *serverSuite) TestMacaroonDischargeInfoNotInAdminV3(c *gc.C) {
+	st := openWithoutLogin(c, s.APIInfo(c))
+	defer st.Close()
+	var result params.MacaroonDischargeInfoResult
+	err := st.APICall("Admin", 3, "", "MacaroonDischargeInfo", nil, &result)
+	c.Assert(err, jc.Satisfies, params.IsCodeNotImplemented)
+}
+
 type macaroonServerSuite struct {
 	jujutesting.JujuConnSuite
 	discharger *bakerytest.Discharger
@@ -393,6 +419,16 @@ func (s *macaroonServerSuite) TestServerBakery(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 }
 
+func (s *macaroonServerSuite) TestMacaroonDischargeInfo(c *gc.C) {
+	st := openWithoutLogin(c, s.APIInfo(c))
+	defer st.Close()
+	publicKey, dischargeURL, err := st.MacaroonDischargeInfo()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(dischargeURL, gc.Equals, s.discharger.Location())
+	c.Assert(publicKey, gc.NotNil)
+	c.Assert(*publicKey, gc.Not(gc.Equals), bakery.PublicKey{})
+}
+
 type macaroonServerWrongPublicKeySuite struct {
 	jujutesting.JujuConnSuite
 	discharger *bakerytest.Discharger
@@ -568,6 +604,84 @@ func (s *serverSuite) TestClosesStateFromPool(c *gc.C) {
 	assertStateBecomesClosed(c, st)
 }
 
+func (s *serverSuite) TestLoginRateLimitFollowsControllerConfig(c *gc.C) {
+	s.PatchValue(apiserver.LoginRateLimitRefreshInterval, coretesting.ShortWait)
+	_, srv := newServer(c, s.State)
+	defer assertStop(c, srv)
+	c.Assert(apiserver.ServerLoginRateLimit(srv), gc.Equals, apiserver.LoginRateLimit)
+
+	err := s.State.UpdateControllerConfig(map[string]interface{}{
+		controller.APILoginRateLimit: 3,
+	}, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		if apiserver.ServerLoginRateLimit(srv) == 3 {
+			return
+		}
+	}
+	c.Fatalf("login rate limit not updated")
+}
+
+func (s *serverSuite) TestEntityRequestLimit(c *gc.C) {
+	info, srv := newServer(c, s.State)
+	defer assertStop(c, srv)
+
+	openAsMachine := func() (api.Connection, names.MachineTag) {
+		machine, password := s.Factory.MakeMachineReturningPassword(
+			c, &factory.MachineParams{Nonce: "fake_nonce"})
+		apiInfo := *info
+		apiInfo.Tag = machine.Tag()
+		apiInfo.Password = password
+		apiInfo.Nonce = "fake_nonce"
+		apiInfo.ModelTag = s.State.ModelTag()
+		st, err := api.Open(&apiInfo, fastDialOpts)
+		c.Assert(err, jc.ErrorIsNil)
+		s.AddCleanup(func(*gc.C) { st.Close() })
+		return st, machine.MachineTag()
+	}
+	busySt, busyTag := openAsMachine()
+	otherSt, otherTag := openAsMachine()
+
+	// Hold the only call slot the busy machine is allowed, as if it
+	// already had a call in progress.
+	limiter := apiserver.ServerEntityRequestLimiter(srv)
+	limiter.SetLimit(1)
+	c.Assert(limiter.Acquire(busyTag.String()), jc.IsTrue)
+
+	_, err := apimachiner.NewState(busySt).Machine(busyTag)
+	c.Assert(err, gc.ErrorMatches, "too many concurrent requests, try again later")
+	c.Assert(params.IsCodeTryAgain(err), jc.IsTrue)
+
+	// The other machine is not held up.
+	_, err = apimachiner.NewState(otherSt).Machine(otherTag)
+	c.Assert(err, jc.ErrorIsNil)
+
+	limiter.Release(busyTag.String())
+	_, err = apimachiner.NewState(busySt).Machine(busyTag)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *serverSuite) TestEntityRequestLimitFollowsControllerConfig(c *gc.C) {
+	s.PatchValue(apiserver.LoginRateLimitRefreshInterval, coretesting.ShortWait)
+	_, srv := newServer(c, s.State)
+	defer assertStop(c, srv)
+	limiter := apiserver.ServerEntityRequestLimiter(srv)
+	c.Assert(limiter.Limit(), gc.Equals, controller.DefaultAPIEntityRequestLimit)
+
+	err := s.State.UpdateControllerConfig(map[string]interface{}{
+		controller.APIEntityRequestLimit: 4,
+	}, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		if limiter.Limit() == 4 {
+			return
+		}
+	}
+	c.Fatalf("entity request limit not updated")
+}
+
 func assertChange(c *gc.C, w state.StringsWatcher) {
 	select {
 	case <-w.Changes():
@@ -0,0 +1,99 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"context"
+
+	"github.com/juju/errors"
+	"golang.org/x/net/websocket"
+)
+
+// errShutdownStarted is returned by Shutdown when it has already been
+// called.
+var errShutdownStarted = errors.New("apiserver shutdown already started")
+
+// Shutdown stops the server gracefully. It stops accepting new
+// connections and API requests, and asks each open API connection to
+// finish its in-flight RPC calls and close; calls made meanwhile fail
+// with a "connection is shut down" error. Once all the connections are
+// closed, the server is stopped, closing its listener.
+//
+// If ctx is done before then, the remaining connections are closed
+// forcibly, so their clients see the connection shut down, the server
+// is killed, closing its listener, and an error is returned. Wait can
+// then be used to wait for any calls still running to finish.
+//
+// Shutdown may only be called once; later calls return an error
+// without doing anything.
+func (srv *Server) Shutdown(ctx context.Context) error {
+	srv.connMu.Lock()
+	select {
+	case <-srv.draining:
+		srv.connMu.Unlock()
+		return errShutdownStarted
+	default:
+	}
+	close(srv.draining)
+	drained := make(chan struct{})
+	if len(srv.conns) == 0 {
+		close(drained)
+	} else {
+		srv.drained = drained
+	}
+	logger.Infof("draining %d API connections", len(srv.conns))
+	srv.connMu.Unlock()
+
+	select {
+	case <-drained:
+		return errors.Trace(srv.Stop())
+	case <-ctx.Done():
+	}
+
+	srv.connMu.Lock()
+	remaining := len(srv.conns)
+	for wsConn := range srv.conns {
+		wsConn.Close()
+	}
+	srv.connMu.Unlock()
+	logger.Warningf("closed %d API connections that did not finish before the shutdown deadline", remaining)
+	// Calls still running on those connections may not finish for a
+	// while, so don't wait for them here.
+	srv.Kill()
+	return errors.Annotatef(ctx.Err(), "forcibly closed %d API connections", remaining)
+}
+
+// isDraining reports whether Shutdown has been called.
+func (srv *Server) isDraining() bool {
+	select {
+	case <-srv.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// addConn records an API connection, so that Shutdown can wait for
+// it. It returns false, without recording anything, if the server is
+// shutting down.
+func (srv *Server) addConn(wsConn *websocket.Conn) bool {
+	srv.connMu.Lock()
+	defer srv.connMu.Unlock()
+	if srv.isDraining() {
+		return false
+	}
+	srv.conns[wsConn] = struct{}{}
+	return true
+}
+
+// removeConn forgets an API connection recorded by addConn.
+func (srv *Server) removeConn(wsConn *websocket.Conn) {
+	srv.connMu.Lock()
+	defer srv.connMu.Unlock()
+	delete(srv.conns, wsConn)
+	if len(srv.conns) == 0 && srv.drained != nil {
+		close(srv.drained)
+		srv.drained = nil
+	}
+}
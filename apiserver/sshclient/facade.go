@@ -20,6 +20,16 @@ func init() {
 
 	// Facade version 2 adds AllAddresses() method.
 	common.RegisterStandardFacade("SSHClient", 2, newFacade)
+
+	// All SSH client related calls are allowed during upgrades.
+	common.RegisterUpgradeSafeMethods("SSHClient",
+		"PublicAddress",
+		"PrivateAddress",
+		"BestAPIVersion",
+		"AllAddresses",
+		"PublicKeys",
+		"Proxy",
+	)
 }
 
 // Facade implements the API required by the sshclient worker.
@@ -4,8 +4,15 @@
 package cert
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
+	"strings"
 	"time"
 
 	"github.com/juju/errors"
@@ -66,6 +73,83 @@ func NewServer(caCertPEM, caKeyPEM string, expiry time.Time, hostnames []string)
 	})
 }
 
+// Prefixes of the subject organizational units that record which
+// controller and model a client certificate was issued for.
+const (
+	controllerUnitPrefix = "controller:"
+	modelUnitPrefix      = "model:"
+)
+
+// NewClient generates a certificate/key pair with which the entity with
+// the given tag, in the given model of the given controller, may
+// authenticate to the API server as a TLS client.
+func NewClient(caCertPEM, caKeyPEM string, expiry time.Time, tag, controllerUUID, modelUUID string) (certPEM, keyPEM string, err error) {
+	tlsCert, err := tls.X509KeyPair([]byte(caCertPEM), []byte(caKeyPEM))
+	if err != nil {
+		return "", "", errors.Annotate(err, "cannot load CA certificate")
+	}
+	caCert, err := x509.ParseCertificate(tlsCert.Certificate[0])
+	if err != nil {
+		return "", "", errors.Annotate(err, "cannot parse CA certificate")
+	}
+	caKey, ok := tlsCert.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return "", "", errors.Errorf("CA private key has unexpected type %T", tlsCert.PrivateKey)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, NewLeafKeyBits)
+	if err != nil {
+		return "", "", errors.Annotate(err, "cannot generate key")
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", errors.Annotate(err, "cannot generate serial number")
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   tag,
+			Organization: []string{"juju"},
+			OrganizationalUnit: []string{
+				controllerUnitPrefix + controllerUUID,
+				modelUnitPrefix + modelUUID,
+			},
+		},
+		NotBefore:   now.UTC().AddDate(0, 0, -7),
+		NotAfter:    expiry.UTC(),
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return "", "", errors.Annotate(err, "cannot create certificate")
+	}
+	certPEM = string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: certDER,
+	}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}))
+	return certPEM, keyPEM, nil
+}
+
+// ClientScope returns the controller and model UUIDs recorded in a
+// client certificate issued by NewClient. Either is empty if the
+// certificate does not record it.
+func ClientScope(clientCert *x509.Certificate) (controllerUUID, modelUUID string) {
+	for _, unit := range clientCert.Subject.OrganizationalUnit {
+		switch {
+		case strings.HasPrefix(unit, controllerUnitPrefix):
+			controllerUUID = strings.TrimPrefix(unit, controllerUnitPrefix)
+		case strings.HasPrefix(unit, modelUnitPrefix):
+			modelUUID = strings.TrimPrefix(unit, modelUnitPrefix)
+		}
+	}
+	return controllerUUID, modelUUID
+}
+
 // NewCA generates a CA certificate/key pair suitable for signing server
 // keys for an environment with the given name.
 // wrapper arount utils/cert#NewCA
@@ -192,6 +192,35 @@ func (certSuite) TestNewDefaultServer(c *gc.C) {
 	checkCertificate(c, caCert, srvCertPEM, srvKeyPEM, now, srvCertExpiry)
 }
 
+func (certSuite) TestNewClient(c *gc.C) {
+	now := time.Now()
+	expiry := roundTime(now.AddDate(1, 0, 0))
+	caCertPEM, caKeyPEM, err := cert.NewCA("foo", "1", expiry)
+	c.Assert(err, jc.ErrorIsNil)
+
+	clientCertPEM, clientKeyPEM, err := cert.NewClient(caCertPEM, caKeyPEM, expiry, "machine-0", "controller-uuid", "model-uuid")
+	c.Assert(err, jc.ErrorIsNil)
+	clientCert, _, err := utilscert.ParseCertAndKey(clientCertPEM, clientKeyPEM)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(clientCert.Subject.CommonName, gc.Equals, "machine-0")
+	c.Assert(clientCert.ExtKeyUsage, jc.DeepEquals, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth})
+	checkNotAfter(c, clientCert, expiry)
+
+	controllerUUID, modelUUID := cert.ClientScope(clientCert)
+	c.Assert(controllerUUID, gc.Equals, "controller-uuid")
+	c.Assert(modelUUID, gc.Equals, "model-uuid")
+
+	roots := x509.NewCertPool()
+	caCert, err := utilscert.ParseCert(caCertPEM)
+	c.Assert(err, jc.ErrorIsNil)
+	roots.AddCert(caCert)
+	_, err = clientCert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+}
+
 func (certSuite) TestWithNonUTCExpiry(c *gc.C) {
 	expiry, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", "2012-11-28 15:53:57 +0100 CET")
 	c.Assert(err, jc.ErrorIsNil)
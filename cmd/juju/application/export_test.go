@@ -5,6 +5,7 @@ package application
 
 import (
 	"github.com/juju/cmd"
+	"github.com/juju/utils/clock"
 	"gopkg.in/juju/charmrepo.v2-unstable/csclient"
 	"gopkg.in/macaroon-bakery.v1/httpbakery"
 
@@ -63,9 +64,18 @@ func NewAddRelationCommandForTest(api ApplicationAddRelationAPI) cmd.Command {
 
 // NewRemoveRelationCommandForTest returns an RemoveRelationCommand with the api provided as specified.
 func NewRemoveRelationCommandForTest(api ApplicationDestroyRelationAPI) cmd.Command {
-	cmd := &removeRelationCommand{newAPIFunc: func() (ApplicationDestroyRelationAPI, error) {
-		return api, nil
-	}}
+	return NewRemoveRelationCommandWithClockForTest(api, clock.WallClock)
+}
+
+// NewRemoveRelationCommandWithClockForTest returns an
+// RemoveRelationCommand with the api and clock provided as specified.
+func NewRemoveRelationCommandWithClockForTest(api ApplicationDestroyRelationAPI, clock clock.Clock) cmd.Command {
+	cmd := &removeRelationCommand{
+		clock: clock,
+		newAPIFunc: func() (ApplicationDestroyRelationAPI, error) {
+			return api, nil
+		},
+	}
 	return modelcmd.Wrap(cmd)
 }
 
@@ -4,10 +4,20 @@
 package application
 
 import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+	"github.com/juju/utils/clock"
+	"gopkg.in/juju/names.v2"
 
+	"github.com/juju/juju/api"
 	"github.com/juju/juju/api/application"
+	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/cmd/juju/block"
 	"github.com/juju/juju/cmd/modelcmd"
 )
@@ -32,6 +42,53 @@ at least once - the following examples will all have the same effect:
     juju remove-relation mediawiki:db mariadb:db
     juju remove-relation mediawiki mariadb:db
     juju remove-relation mediawiki:db mariadb
+
+If units are stuck in an error state in a relation hook, the relation will
+not be removed until the error is resolved. The --force option removes such
+units from the relation without running their hooks, once they have had
+a chance to leave it by themselves. How long the command waits for that,
+1 minute by default, can be set with --timeout; --no-wait removes the units
+straight away:
+
+    juju remove-relation --force mysql wordpress
+    juju remove-relation --force --timeout 30s mysql wordpress
+    juju remove-relation --force --no-wait mysql wordpress
+
+By default it is an error to remove a relation that does not exist. With
+--if-exists, the command reports that there is no such relation and
+succeeds, which is useful when retrying or scripting. It still fails if
+either application does not exist:
+
+    juju remove-relation --if-exists mysql wordpress
+
+To check which relation would be removed, without removing it, use
+--dry-run. It shows the relation's id, its endpoints and how many units
+are in it:
+
+    juju remove-relation --dry-run mediawiki mariadb:db
+
+To remove every relation involving a single application, name only that
+application and pass --all-relations. Each relation is removed in turn and
+reported; the command fails if any of them could not be removed. Peer
+relations are removed only with the application itself:
+
+    juju remove-relation --all-relations mysql
+    juju remove-relation --all-relations --dry-run mysql
+
+With --format json or yaml, the command writes a list describing each
+relation it removed, would remove with --dry-run, or did not find with
+--if-exists: its id, key and endpoints, its status, and any error:
+
+    juju remove-relation --format json mysql wordpress
+
+Removing a relation runs the relation-departed and relation-broken hooks
+of its units, and the command returns before they have finished. With
+--wait, the command waits until the relation is gone, once all its units
+have left it, reporting how many are still in it. It gives up after
+--wait-timeout, 5 minutes by default:
+
+    juju remove-relation --wait mysql wordpress
+    juju remove-relation --wait --wait-timeout 10m --all-relations mysql
  
 See also: 
     add-relation
@@ -39,14 +96,16 @@ See also:
 
 // NewRemoveRelationCommand returns a command to remove a relation between 2 services.
 func NewRemoveRelationCommand() cmd.Command {
-	cmd := &removeRelationCommand{}
+	cmd := &removeRelationCommand{clock: clock.WallClock}
 	cmd.newAPIFunc = func() (ApplicationDestroyRelationAPI, error) {
 		root, err := cmd.NewAPIRoot()
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		return application.NewClient(root), nil
-
+		return destroyRelationClient{
+			Client: application.NewClient(root),
+			status: root.Client(),
+		}, nil
 	}
 	return modelcmd.Wrap(cmd)
 }
@@ -54,38 +113,445 @@ func NewRemoveRelationCommand() cmd.Command {
 // removeRelationCommand causes an existing application relation to be shut down.
 type removeRelationCommand struct {
 	modelcmd.ModelCommandBase
-	Endpoints  []string
-	newAPIFunc func() (ApplicationDestroyRelationAPI, error)
+	Endpoints   []string
+	Force       bool
+	NoWait      bool
+	IfExists    bool
+	DryRun      bool
+	All         bool
+	Timeout     time.Duration
+	Wait        bool
+	WaitTimeout time.Duration
+	out         cmd.Output
+	clock       clock.Clock
+	newAPIFunc  func() (ApplicationDestroyRelationAPI, error)
 }
 
+// defaultRelationWaitTimeout is how long --wait waits for removed
+// relations to go away, unless --wait-timeout is given.
+const defaultRelationWaitTimeout = 5 * time.Minute
+
+// relationWaitPollInterval is how often --wait and --force check
+// whether the removed relations have gone away.
+const relationWaitPollInterval = 2 * time.Second
+
+// defaultForceRelationTimeout is how long --force waits for units to
+// leave a relation by themselves, unless --timeout or --no-wait is
+// given.
+const defaultForceRelationTimeout = time.Minute
+
 func (c *removeRelationCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "remove-relation",
-		Args:    "<application1>[:<relation name1>] <application2>[:<relation name2>]",
+		Args:    "<application1>[:<relation name1>] <application2>[:<relation name2>] | --all-relations <application>",
 		Purpose: helpSummary,
 		Doc:     helpDetails,
 	}
 }
 
+func (c *removeRelationCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.ModelCommandBase.SetFlags(f)
+	f.BoolVar(&c.Force, "force", false, "Remove units stuck in the relation without running their hooks")
+	f.BoolVar(&c.NoWait, "no-wait", false, "With --force, don't wait for units to leave the relation by themselves")
+	f.DurationVar(&c.Timeout, "timeout", 0, "With --force, how long to wait for units to leave the relation by themselves")
+	f.BoolVar(&c.IfExists, "if-exists", false, "Succeed if there is no relation between the endpoints")
+	f.BoolVar(&c.DryRun, "dry-run", false, "Show the relation that would be removed, without removing it")
+	f.BoolVar(&c.All, "all-relations", false, "Remove all relations involving the single application given")
+	f.BoolVar(&c.Wait, "wait", false, "Wait until the removed relations are gone")
+	f.DurationVar(&c.WaitTimeout, "wait-timeout", defaultRelationWaitTimeout, "With --wait, how long to wait for the removed relations to go")
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"tabular": formatRelationRemovalsTabular,
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+	})
+}
+
 func (c *removeRelationCommand) Init(args []string) error {
-	if len(args) != 2 {
+	if c.All {
+		if len(args) != 1 {
+			return errors.Errorf("--all-relations requires a single application")
+		}
+		if !names.IsValidApplication(args[0]) {
+			return errors.NotValidf("application name %q", args[0])
+		}
+	} else if len(args) != 2 {
 		return errors.Errorf("a relation must involve two applications")
 	}
+	if !c.Force && (c.NoWait || c.Timeout != 0) {
+		return errors.Errorf("--no-wait and --timeout can only be used with --force")
+	}
+	if c.NoWait && c.Timeout != 0 {
+		return errors.Errorf("cannot specify both --no-wait and --timeout")
+	}
+	if c.Timeout < 0 {
+		return errors.Errorf("--timeout must not be negative")
+	}
+	if c.Wait && c.DryRun {
+		return errors.Errorf("cannot specify both --wait and --dry-run")
+	}
+	if c.WaitTimeout <= 0 {
+		return errors.Errorf("--wait-timeout must be positive")
+	}
 	c.Endpoints = args
 	return nil
 }
 
 // ApplicationDestroyRelationAPI defines the API methods that application remove relation command uses.
 type ApplicationDestroyRelationAPI interface {
+	BestAPIVersion() int
 	Close() error
 	DestroyRelation(endpoints ...string) error
+	ForceDestroyRelation(endpoints ...string) error
+	RelationDetails(endpoints ...string) (params.RelationDetails, error)
+	Status(patterns []string) (*params.FullStatus, error)
+}
+
+// destroyRelationClient adds the Status method of the client facade to
+// the application facade client.
+type destroyRelationClient struct {
+	*application.Client
+	status *api.Client
 }
 
-func (c *removeRelationCommand) Run(_ *cmd.Context) error {
+// Status is part of the ApplicationDestroyRelationAPI interface.
+func (c destroyRelationClient) Status(patterns []string) (*params.FullStatus, error) {
+	return c.status.Status(patterns)
+}
+
+func (c *removeRelationCommand) Run(ctx *cmd.Context) error {
 	client, err := c.newAPIFunc()
 	if err != nil {
 		return err
 	}
 	defer client.Close()
-	return block.ProcessBlockedError(client.DestroyRelation(c.Endpoints...), block.BlockRemove)
+	if c.Force && client.BestAPIVersion() < 4 {
+		return errors.NotSupportedf("--force on this controller")
+	}
+	if c.All {
+		return c.removeAllRelations(ctx, client)
+	}
+	removal, err := c.removeRelation(ctx, client)
+	if c.IfExists && params.IsCodeNotFound(err) {
+		// A missing application is reported as not found too, but
+		// --if-exists only excuses a missing relation.
+		if err := c.checkApplicationsExist(client); err != nil {
+			return errors.Trace(err)
+		}
+		ctx.Infof("%v; nothing to remove", err)
+		removal = relationRemoval{Endpoints: c.Endpoints, Status: relationNotFound}
+		err = nil
+	}
+	if err != nil {
+		return block.ProcessBlockedError(err, block.BlockRemove)
+	}
+	removals := []relationRemoval{removal}
+	if err := c.writeRemovals(ctx, removals); err != nil {
+		return errors.Trace(err)
+	}
+	if c.Wait {
+		return c.waitForRemovals(ctx, client, removals)
+	}
+	return nil
+}
+
+// removeRelation removes the relation between the endpoints given on
+// the command line, or shows it with --dry-run. The relation's details
+// are only fetched when they are needed for the output.
+func (c *removeRelationCommand) removeRelation(ctx *cmd.Context, client ApplicationDestroyRelationAPI) (relationRemoval, error) {
+	var removal relationRemoval
+	if c.DryRun || c.Wait || c.structuredOutput() {
+		details, err := client.RelationDetails(c.Endpoints...)
+		if err != nil {
+			return relationRemoval{}, err
+		}
+		if c.DryRun && !c.structuredOutput() {
+			showRelation(ctx, details)
+		}
+		removal = newRelationRemoval(details)
+	}
+	if c.DryRun {
+		removal.Status = relationWouldRemove
+		return removal, nil
+	}
+	if err := c.destroyRelation(client, c.Endpoints); err != nil {
+		return relationRemoval{}, err
+	}
+	removal.Status = relationRemoved
+	return removal, nil
+}
+
+// checkApplicationsExist returns a not found error if any of the
+// applications named by the endpoints given on the command line does
+// not exist.
+func (c *removeRelationCommand) checkApplicationsExist(client ApplicationDestroyRelationAPI) error {
+	appNames := make([]string, len(c.Endpoints))
+	for i, endpoint := range c.Endpoints {
+		appNames[i] = strings.SplitN(endpoint, ":", 2)[0]
+	}
+	status, err := client.Status(appNames)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, appName := range appNames {
+		_, local := status.Applications[appName]
+		_, remote := status.RemoteApplications[appName]
+		if !local && !remote {
+			return errors.NotFoundf("application %q", appName)
+		}
+	}
+	return nil
+}
+
+// destroyRelation removes the relation between the given endpoints.
+// With --force, any units that have not left the relation by the end
+// of --timeout are then removed from it without running their hooks.
+func (c *removeRelationCommand) destroyRelation(client ApplicationDestroyRelationAPI, endpoints []string) error {
+	if !c.Force {
+		return client.DestroyRelation(endpoints...)
+	}
+	if !c.NoWait {
+		if err := client.DestroyRelation(endpoints...); err != nil {
+			return err
+		}
+		timeout := c.Timeout
+		if timeout == 0 {
+			timeout = defaultForceRelationTimeout
+		}
+		removed, err := c.waitForUnitsToLeave(client, endpoints, timeout)
+		if err != nil || removed {
+			return err
+		}
+	}
+	err := client.ForceDestroyRelation(endpoints...)
+	if params.IsCodeNotFound(err) && !c.NoWait {
+		// The last units left while the relation was being forced.
+		return nil
+	}
+	return err
+}
+
+// waitForUnitsToLeave waits up to timeout for the dying relation
+// between the given endpoints to be removed, once all its units have
+// left it. It reports whether the relation was removed.
+func (c *removeRelationCommand) waitForUnitsToLeave(client ApplicationDestroyRelationAPI, endpoints []string, timeout time.Duration) (bool, error) {
+	deadline := c.clock.Now().Add(timeout)
+	for {
+		_, err := client.RelationDetails(endpoints...)
+		if params.IsCodeNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if !c.clock.Now().Before(deadline) {
+			return false, nil
+		}
+		<-c.clock.After(relationWaitPollInterval)
+	}
+}
+
+// removeAllRelations removes each relation involving the application
+// given on the command line, reporting the outcome for each to ctx.
+// It returns an error combining all the relations that could not be
+// removed.
+func (c *removeRelationCommand) removeAllRelations(ctx *cmd.Context, client ApplicationDestroyRelationAPI) error {
+	appName := c.Endpoints[0]
+	status, err := client.Status([]string{appName})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, ok := status.Applications[appName]; !ok {
+		return errors.NotFoundf("application %q", appName)
+	}
+	var relations []params.RelationStatus
+	for _, rel := range status.Relations {
+		if len(rel.Endpoints) == 2 && relationInvolves(rel, appName) {
+			relations = append(relations, rel)
+		}
+	}
+	if len(relations) == 0 {
+		ctx.Infof("application %q has no relations to remove", appName)
+		return c.writeRemovals(ctx, []relationRemoval{})
+	}
+	removals := make([]relationRemoval, len(relations))
+	var failed []string
+	for i, rel := range relations {
+		id := rel.Id
+		removal := &removals[i]
+		removal.Id = &id
+		removal.Key = rel.Key
+		for _, ep := range rel.Endpoints {
+			removal.Endpoints = append(removal.Endpoints, ep.ApplicationName+":"+ep.Name)
+		}
+		if c.DryRun {
+			if !c.structuredOutput() {
+				fmt.Fprintf(ctx.Stdout, "Would remove relation %d %q\n", rel.Id, rel.Key)
+			}
+			removal.Status = relationWouldRemove
+			continue
+		}
+		err := c.destroyRelation(client, removal.Endpoints)
+		if params.IsCodeOperationBlocked(err) {
+			return block.ProcessBlockedError(err, block.BlockRemove)
+		}
+		if params.IsCodeNotFound(err) {
+			ctx.Infof("relation %q already removed", rel.Key)
+			removal.Status = relationNotFound
+			continue
+		}
+		if err != nil {
+			ctx.Infof("cannot remove relation %q: %v", rel.Key, err)
+			removal.Status = relationRemoveFailed
+			removal.Error = err.Error()
+			failed = append(failed, fmt.Sprintf("%q: %v", rel.Key, err))
+			continue
+		}
+		ctx.Infof("removed relation %q", rel.Key)
+		removal.Status = relationRemoved
+	}
+	if err := c.writeRemovals(ctx, removals); err != nil {
+		return errors.Trace(err)
+	}
+	if c.Wait {
+		if err := c.waitForRemovals(ctx, client, removals); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf(
+			"cannot remove %d of %d relations of %q: %s",
+			len(failed), len(relations), appName, strings.Join(failed, "; "),
+		)
+	}
+	return nil
+}
+
+// waitForRemovals waits until each of the removed relations has gone
+// away, once all its units have left it, reporting progress to ctx. It
+// gives up after --wait-timeout.
+func (c *removeRelationCommand) waitForRemovals(ctx *cmd.Context, client ApplicationDestroyRelationAPI, removals []relationRemoval) error {
+	var pending []relationRemoval
+	for _, removal := range removals {
+		if removal.Status == relationRemoved {
+			pending = append(pending, removal)
+		}
+	}
+	unitCounts := make(map[string]int)
+	deadline := c.clock.Now().Add(c.WaitTimeout)
+	for len(pending) > 0 {
+		var remaining []relationRemoval
+		for _, removal := range pending {
+			details, err := client.RelationDetails(removal.Endpoints...)
+			if params.IsCodeNotFound(err) {
+				ctx.Infof("relation %q has been removed", removal.Key)
+				continue
+			}
+			if err != nil {
+				return errors.Annotatef(err, "cannot check relation %q", removal.Key)
+			}
+			if count, ok := unitCounts[removal.Key]; !ok || count != details.UnitCount {
+				ctx.Infof("waiting for relation %q: %d units in it", removal.Key, details.UnitCount)
+				unitCounts[removal.Key] = details.UnitCount
+			}
+			remaining = append(remaining, removal)
+		}
+		pending = remaining
+		if len(pending) == 0 {
+			break
+		}
+		if !c.clock.Now().Before(deadline) {
+			keys := make([]string, len(pending))
+			for i, removal := range pending {
+				keys[i] = fmt.Sprintf("%q", removal.Key)
+			}
+			return errors.Errorf(
+				"timed out after %v waiting for relations to be removed: %s",
+				c.WaitTimeout, strings.Join(keys, ", "),
+			)
+		}
+		<-c.clock.After(relationWaitPollInterval)
+	}
+	return nil
+}
+
+// The possible values of relationRemoval.Status.
+const (
+	relationRemoved      = "removed"
+	relationWouldRemove  = "would-remove"
+	relationNotFound     = "not-found"
+	relationRemoveFailed = "failed"
+)
+
+// relationRemoval holds the outcome of removing a single relation, as
+// written with --format json or yaml.
+type relationRemoval struct {
+	// Id and Key identify the relation. They are omitted when the
+	// relation was not found.
+	Id  *int   `json:"id,omitempty" yaml:"id,omitempty"`
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+
+	// Endpoints holds the relation's endpoints, as
+	// <application>:<relation name>.
+	Endpoints []string `json:"endpoints" yaml:"endpoints"`
+
+	// Status holds one of "removed", "would-remove", "not-found"
+	// or "failed".
+	Status string `json:"status" yaml:"status"`
+
+	// Error holds why the relation could not be removed.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// newRelationRemoval returns the relationRemoval describing the
+// relation with the given details.
+func newRelationRemoval(details params.RelationDetails) relationRemoval {
+	id := details.Id
+	removal := relationRemoval{Id: &id, Key: details.Key}
+	for _, ep := range details.Endpoints {
+		removal.Endpoints = append(removal.Endpoints, ep.ApplicationName+":"+ep.Relation.Name)
+	}
+	return removal
+}
+
+// relationInvolves reports whether any of the relation's endpoints
+// belongs to the named application.
+func relationInvolves(rel params.RelationStatus, appName string) bool {
+	for _, ep := range rel.Endpoints {
+		if ep.ApplicationName == appName {
+			return true
+		}
+	}
+	return false
+}
+
+// showRelation writes the relation that would be removed to ctx.Stdout.
+func showRelation(ctx *cmd.Context, details params.RelationDetails) {
+	fmt.Fprintf(ctx.Stdout, "Would remove relation %d %q\n", details.Id, details.Key)
+	fmt.Fprintf(ctx.Stdout, "Endpoints:\n")
+	for _, ep := range details.Endpoints {
+		fmt.Fprintf(ctx.Stdout, "  %s:%s (%s)\n", ep.ApplicationName, ep.Relation.Name, ep.Relation.Role)
+	}
+	fmt.Fprintf(ctx.Stdout, "Units in scope: %d\n", details.UnitCount)
+}
+
+// structuredOutput reports whether the results are to be written in
+// one of the machine-readable formats.
+func (c *removeRelationCommand) structuredOutput() bool {
+	return c.out.Name() != "tabular"
+}
+
+// writeRemovals writes the given results in the requested
+// machine-readable format. The default output is written as the
+// command runs instead.
+func (c *removeRelationCommand) writeRemovals(ctx *cmd.Context, removals []relationRemoval) error {
+	if !c.structuredOutput() {
+		return nil
+	}
+	return c.out.Write(ctx, removals)
+}
+
+// formatRelationRemovalsTabular writes nothing: in the default format,
+// progress is reported as the command runs.
+func formatRelationRemovalsTabular(io.Writer, interface{}) error {
+	return nil
 }
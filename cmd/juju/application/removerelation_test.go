@@ -4,12 +4,17 @@
 package application
 
 import (
+	"time"
+
+	"github.com/juju/cmd"
 	"github.com/juju/errors"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state/multiwatcher"
 	coretesting "github.com/juju/juju/testing"
 )
 
@@ -20,7 +25,7 @@ type RemoveRelationSuite struct {
 
 func (s *RemoveRelationSuite) SetUpTest(c *gc.C) {
 	s.IsolationSuite.SetUpTest(c)
-	s.mockAPI = &mockRemoveAPI{Stub: &testing.Stub{}}
+	s.mockAPI = &mockRemoveAPI{Stub: &testing.Stub{}, version: 4}
 	s.mockAPI.removeRelationFunc = func(endpoints ...string) error {
 		return s.mockAPI.NextErr()
 	}
@@ -28,6 +33,15 @@ func (s *RemoveRelationSuite) SetUpTest(c *gc.C) {
 
 var _ = gc.Suite(&RemoveRelationSuite{})
 
+// setApplications makes the mock API's status report the named
+// applications.
+func (s *RemoveRelationSuite) setApplications(names ...string) {
+	s.mockAPI.status.Applications = make(map[string]params.ApplicationStatus)
+	for _, name := range names {
+		s.mockAPI.status.Applications[name] = params.ApplicationStatus{}
+	}
+}
+
 func (s *RemoveRelationSuite) runRemoveRelation(c *gc.C, args ...string) error {
 	_, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), args...)
 	return err
@@ -71,9 +85,524 @@ func (s *RemoveRelationSuite) TestRemoveRelationBlocked(c *gc.C) {
 	s.mockAPI.CheckCall(c, 1, "Close")
 }
 
+func (s *RemoveRelationSuite) TestRemoveRelationNotFound(c *gc.C) {
+	s.mockAPI.SetErrors(&params.Error{
+		Code:    params.CodeNotFound,
+		Message: `relation "application1:db application2:server" not found`,
+	})
+	err := s.runRemoveRelation(c, "application1", "application2")
+	c.Assert(err, gc.ErrorMatches, `relation "application1:db application2:server" not found`)
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationIfExistsNotFound(c *gc.C) {
+	s.setApplications("application1", "application2")
+	s.mockAPI.SetErrors(&params.Error{
+		Code:    params.CodeNotFound,
+		Message: `relation "application1:db application2:server" not found`,
+	})
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--if-exists", "application1", "application2")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(coretesting.Stderr(ctx), gc.Equals, `relation "application1:db application2:server" not found; nothing to remove`+"\n")
+	s.mockAPI.CheckCalls(c, []testing.StubCall{
+		{"DestroyRelation", []interface{}{[]string{"application1", "application2"}}},
+		{"Status", []interface{}{[]string{"application1", "application2"}}},
+		{"Close", nil},
+	})
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationIfExistsApplicationNotFound(c *gc.C) {
+	s.setApplications("application1")
+	s.mockAPI.SetErrors(&params.Error{
+		Code:    params.CodeNotFound,
+		Message: `application "application2" not found`,
+	})
+	err := s.runRemoveRelation(c, "--if-exists", "application1:db", "application2:server")
+	c.Assert(err, gc.ErrorMatches, `application "application2" not found`)
+	s.mockAPI.CheckCalls(c, []testing.StubCall{
+		{"DestroyRelation", []interface{}{[]string{"application1:db", "application2:server"}}},
+		{"Status", []interface{}{[]string{"application1", "application2"}}},
+		{"Close", nil},
+	})
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationIfExistsRemoteApplication(c *gc.C) {
+	s.setApplications("application1")
+	s.mockAPI.status.RemoteApplications = map[string]params.RemoteApplicationStatus{
+		"application2": {},
+	}
+	s.mockAPI.SetErrors(&params.Error{Code: params.CodeNotFound, Message: "relation not found"})
+	err := s.runRemoveRelation(c, "--if-exists", "application1", "application2")
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationIfExistsOtherError(c *gc.C) {
+	s.mockAPI.SetErrors(errors.New("boom"))
+	err := s.runRemoveRelation(c, "--if-exists", "application1", "application2")
+	c.Assert(err, gc.ErrorMatches, "boom")
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationForceIfExistsNotFound(c *gc.C) {
+	s.setApplications("application1", "application2")
+	s.mockAPI.SetErrors(&params.Error{Code: params.CodeNotFound, Message: "not found"})
+	err := s.runRemoveRelation(c, "--force", "--if-exists", "application1", "application2")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCall(c, 0, "DestroyRelation", []string{"application1", "application2"})
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationDryRun(c *gc.C) {
+	s.mockAPI.details = params.RelationDetails{
+		Id:  3,
+		Key: "application1:db application2:server",
+		Endpoints: []multiwatcher.Endpoint{{
+			ApplicationName: "application1",
+			Relation:        multiwatcher.CharmRelation{Name: "db", Role: "requirer"},
+		}, {
+			ApplicationName: "application2",
+			Relation:        multiwatcher.CharmRelation{Name: "server", Role: "provider"},
+		}},
+		UnitCount: 2,
+	}
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--dry-run", "application1", "application2")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, `
+Would remove relation 3 "application1:db application2:server"
+Endpoints:
+  application1:db (requirer)
+  application2:server (provider)
+Units in scope: 2
+`[1:])
+	s.mockAPI.CheckCalls(c, []testing.StubCall{
+		{"RelationDetails", []interface{}{[]string{"application1", "application2"}}},
+		{"Close", nil},
+	})
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationDryRunNotFound(c *gc.C) {
+	s.mockAPI.SetErrors(&params.Error{Code: params.CodeNotFound, Message: "relation not found"})
+	err := s.runRemoveRelation(c, "--dry-run", "application1", "application2")
+	c.Assert(err, gc.ErrorMatches, "relation not found")
+
+	s.setApplications("application1", "application2")
+	s.mockAPI.SetErrors(&params.Error{Code: params.CodeNotFound, Message: "relation not found"})
+	err = s.runRemoveRelation(c, "--dry-run", "--if-exists", "application1", "application2")
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationForceUnitsLeave(c *gc.C) {
+	notFound := &params.Error{Code: params.CodeNotFound, Message: "relation not found"}
+	s.mockAPI.SetErrors(nil, notFound)
+	err := s.runRemoveRelation(c, "--force", "application1", "application2")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCalls(c, []testing.StubCall{
+		{"DestroyRelation", []interface{}{[]string{"application1", "application2"}}},
+		{"RelationDetails", []interface{}{[]string{"application1", "application2"}}},
+		{"Close", nil},
+	})
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationForceTimeout(c *gc.C) {
+	s.setUpDetails()
+	clock := testing.NewClock(time.Time{})
+	result := s.runRemoveRelationWithClock(c, clock, "--force", "--timeout", "3s", "application1", "application2")
+
+	for i := 0; i < 2; i++ {
+		err := clock.WaitAdvance(relationWaitPollInterval, coretesting.LongWait, 1)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	r := waitRemoveRelationResult(c, result)
+	c.Assert(r.err, jc.ErrorIsNil)
+	s.mockAPI.CheckCalls(c, []testing.StubCall{
+		{"DestroyRelation", []interface{}{[]string{"application1", "application2"}}},
+		{"RelationDetails", []interface{}{[]string{"application1", "application2"}}},
+		{"RelationDetails", []interface{}{[]string{"application1", "application2"}}},
+		{"RelationDetails", []interface{}{[]string{"application1", "application2"}}},
+		{"ForceDestroyRelation", []interface{}{[]string{"application1", "application2"}}},
+		{"Close", nil},
+	})
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationForceDefaultTimeout(c *gc.C) {
+	s.setUpDetails()
+	clock := testing.NewClock(time.Time{})
+	result := s.runRemoveRelationWithClock(c, clock, "--force", "application1", "application2")
+
+	polls := int(defaultForceRelationTimeout / relationWaitPollInterval)
+	for i := 0; i < polls; i++ {
+		err := clock.WaitAdvance(relationWaitPollInterval, coretesting.LongWait, 1)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	r := waitRemoveRelationResult(c, result)
+	c.Assert(r.err, jc.ErrorIsNil)
+	s.mockAPI.CheckCall(c, polls+2, "ForceDestroyRelation", []string{"application1", "application2"})
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationForceUnitsLeaveWhileForcing(c *gc.C) {
+	s.setUpDetails()
+	notFound := &params.Error{Code: params.CodeNotFound, Message: "relation not found"}
+	s.mockAPI.SetErrors(nil, nil, nil, notFound)
+	clock := testing.NewClock(time.Time{})
+	result := s.runRemoveRelationWithClock(c, clock, "--force", "--timeout", "2s", "application1", "application2")
+
+	err := clock.WaitAdvance(relationWaitPollInterval, coretesting.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	r := waitRemoveRelationResult(c, result)
+	c.Assert(r.err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "DestroyRelation", "RelationDetails", "RelationDetails", "ForceDestroyRelation", "Close")
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationForceNoWait(c *gc.C) {
+	err := s.runRemoveRelation(c, "--force", "--no-wait", "application1", "application2")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCalls(c, []testing.StubCall{
+		{"ForceDestroyRelation", []interface{}{[]string{"application1", "application2"}}},
+		{"Close", nil},
+	})
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationForceNotSupported(c *gc.C) {
+	s.mockAPI.version = 3
+	err := s.runRemoveRelation(c, "--force", "application1", "application2")
+	c.Assert(err, gc.ErrorMatches, "--force on this controller not supported")
+	s.mockAPI.CheckCallNames(c, "Close")
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationForceBlocked(c *gc.C) {
+	s.mockAPI.SetErrors(common.OperationBlockedError("TestRemoveRelationForceBlocked"))
+	err := s.runRemoveRelation(c, "--force", "application1", "application2")
+	coretesting.AssertOperationWasBlocked(c, err, ".*TestRemoveRelationForceBlocked.*")
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationInvalidForceFlags(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		args: []string{"--no-wait"},
+		err:  "--no-wait and --timeout can only be used with --force",
+	}, {
+		args: []string{"--timeout", "1m"},
+		err:  "--no-wait and --timeout can only be used with --force",
+	}, {
+		args: []string{"--force", "--no-wait", "--timeout", "1m"},
+		err:  "cannot specify both --no-wait and --timeout",
+	}, {
+		args: []string{"--force", "--timeout=-1m"},
+		err:  "--timeout must not be negative",
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		args := append(test.args, "application1", "application2")
+		err := s.runRemoveRelation(c, args...)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *RemoveRelationSuite) setUpAllRelations() {
+	s.mockAPI.status = params.FullStatus{
+		Applications: map[string]params.ApplicationStatus{
+			"mysql": {},
+		},
+		Relations: []params.RelationStatus{{
+			Id:  0,
+			Key: "mysql:cluster",
+			Endpoints: []params.EndpointStatus{
+				{ApplicationName: "mysql", Name: "cluster", Role: "peer"},
+			},
+		}, {
+			Id:  1,
+			Key: "wordpress:db mysql:server",
+			Endpoints: []params.EndpointStatus{
+				{ApplicationName: "wordpress", Name: "db", Role: "requirer"},
+				{ApplicationName: "mysql", Name: "server", Role: "provider"},
+			},
+		}, {
+			Id:  2,
+			Key: "mediawiki:db mysql:server",
+			Endpoints: []params.EndpointStatus{
+				{ApplicationName: "mediawiki", Name: "db", Role: "requirer"},
+				{ApplicationName: "mysql", Name: "server", Role: "provider"},
+			},
+		}, {
+			Id:  3,
+			Key: "wordpress:cache memcached:cache",
+			Endpoints: []params.EndpointStatus{
+				{ApplicationName: "wordpress", Name: "cache", Role: "requirer"},
+				{ApplicationName: "memcached", Name: "cache", Role: "provider"},
+			},
+		}},
+	}
+}
+
+func (s *RemoveRelationSuite) TestRemoveAllRelationsInvalidArgs(c *gc.C) {
+	err := s.runRemoveRelation(c, "--all-relations")
+	c.Assert(err, gc.ErrorMatches, "--all-relations requires a single application")
+	err = s.runRemoveRelation(c, "--all-relations", "mysql", "wordpress")
+	c.Assert(err, gc.ErrorMatches, "--all-relations requires a single application")
+	err = s.runRemoveRelation(c, "--all-relations", "mysql:server")
+	c.Assert(err, gc.ErrorMatches, `application name "mysql:server" not valid`)
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *RemoveRelationSuite) TestRemoveAllRelations(c *gc.C) {
+	s.setUpAllRelations()
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--all-relations", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(coretesting.Stderr(ctx), gc.Equals, `
+removed relation "wordpress:db mysql:server"
+removed relation "mediawiki:db mysql:server"
+`[1:])
+	s.mockAPI.CheckCalls(c, []testing.StubCall{
+		{"Status", []interface{}{[]string{"mysql"}}},
+		{"DestroyRelation", []interface{}{[]string{"wordpress:db", "mysql:server"}}},
+		{"DestroyRelation", []interface{}{[]string{"mediawiki:db", "mysql:server"}}},
+		{"Close", nil},
+	})
+}
+
+func (s *RemoveRelationSuite) TestRemoveAllRelationsForce(c *gc.C) {
+	s.setUpAllRelations()
+	err := s.runRemoveRelation(c, "--all-relations", "--force", "--no-wait", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCall(c, 1, "ForceDestroyRelation", []string{"wordpress:db", "mysql:server"})
+	s.mockAPI.CheckCall(c, 2, "ForceDestroyRelation", []string{"mediawiki:db", "mysql:server"})
+}
+
+func (s *RemoveRelationSuite) TestRemoveAllRelationsPartialFailure(c *gc.C) {
+	s.setUpAllRelations()
+	s.mockAPI.SetErrors(nil, errors.New("boom"), nil)
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--all-relations", "mysql")
+	c.Assert(err, gc.ErrorMatches, `cannot remove 1 of 2 relations of "mysql": "wordpress:db mysql:server": boom`)
+	c.Assert(coretesting.Stderr(ctx), gc.Equals, `
+cannot remove relation "wordpress:db mysql:server": boom
+removed relation "mediawiki:db mysql:server"
+`[1:])
+	s.mockAPI.CheckCallNames(c, "Status", "DestroyRelation", "DestroyRelation", "Close")
+}
+
+func (s *RemoveRelationSuite) TestRemoveAllRelationsBlocked(c *gc.C) {
+	s.setUpAllRelations()
+	s.mockAPI.SetErrors(nil, common.OperationBlockedError("TestRemoveAllRelationsBlocked"))
+	err := s.runRemoveRelation(c, "--all-relations", "mysql")
+	coretesting.AssertOperationWasBlocked(c, err, ".*TestRemoveAllRelationsBlocked.*")
+	s.mockAPI.CheckCallNames(c, "Status", "DestroyRelation", "Close")
+}
+
+func (s *RemoveRelationSuite) TestRemoveAllRelationsDryRun(c *gc.C) {
+	s.setUpAllRelations()
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--all-relations", "--dry-run", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, `
+Would remove relation 1 "wordpress:db mysql:server"
+Would remove relation 2 "mediawiki:db mysql:server"
+`[1:])
+	s.mockAPI.CheckCallNames(c, "Status", "Close")
+}
+
+func (s *RemoveRelationSuite) TestRemoveAllRelationsNone(c *gc.C) {
+	s.mockAPI.status = params.FullStatus{
+		Applications: map[string]params.ApplicationStatus{"mysql": {}},
+	}
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--all-relations", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(coretesting.Stderr(ctx), gc.Equals, `application "mysql" has no relations to remove`+"\n")
+	s.mockAPI.CheckCallNames(c, "Status", "Close")
+}
+
+func (s *RemoveRelationSuite) TestRemoveAllRelationsApplicationNotFound(c *gc.C) {
+	err := s.runRemoveRelation(c, "--all-relations", "mysql")
+	c.Assert(err, gc.ErrorMatches, `application "mysql" not found`)
+	s.mockAPI.CheckCallNames(c, "Status", "Close")
+}
+
+func (s *RemoveRelationSuite) setUpDetails() {
+	s.mockAPI.details = params.RelationDetails{
+		Id:  3,
+		Key: "application1:db application2:server",
+		Endpoints: []multiwatcher.Endpoint{{
+			ApplicationName: "application1",
+			Relation:        multiwatcher.CharmRelation{Name: "db", Role: "requirer"},
+		}, {
+			ApplicationName: "application2",
+			Relation:        multiwatcher.CharmRelation{Name: "server", Role: "provider"},
+		}},
+		UnitCount: 2,
+	}
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationFormatJSON(c *gc.C) {
+	s.setUpDetails()
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--format", "json", "application1", "application2")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals,
+		`[{"id":3,"key":"application1:db application2:server","endpoints":["application1:db","application2:server"],"status":"removed"}]`+"\n")
+	s.mockAPI.CheckCalls(c, []testing.StubCall{
+		{"RelationDetails", []interface{}{[]string{"application1", "application2"}}},
+		{"DestroyRelation", []interface{}{[]string{"application1", "application2"}}},
+		{"Close", nil},
+	})
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationFormatYAMLDryRun(c *gc.C) {
+	s.setUpDetails()
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--format", "yaml", "--dry-run", "application1", "application2")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, `
+- id: 3
+  key: application1:db application2:server
+  endpoints:
+  - application1:db
+  - application2:server
+  status: would-remove
+`[1:])
+	s.mockAPI.CheckCallNames(c, "RelationDetails", "Close")
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationFormatJSONIfExistsNotFound(c *gc.C) {
+	s.setApplications("application1", "application2")
+	s.mockAPI.SetErrors(&params.Error{Code: params.CodeNotFound, Message: "relation not found"})
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--format", "json", "--if-exists", "application1", "application2")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals,
+		`[{"endpoints":["application1","application2"],"status":"not-found"}]`+"\n")
+	s.mockAPI.CheckCallNames(c, "RelationDetails", "Status", "Close")
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationFormatJSONNotFound(c *gc.C) {
+	s.mockAPI.SetErrors(&params.Error{Code: params.CodeNotFound, Message: "relation not found"})
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--format", "json", "application1", "application2")
+	c.Assert(err, gc.ErrorMatches, "relation not found")
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, "")
+}
+
+func (s *RemoveRelationSuite) TestRemoveAllRelationsFormatJSON(c *gc.C) {
+	s.setUpAllRelations()
+	s.mockAPI.SetErrors(nil, errors.New("boom"), nil)
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--format", "json", "--all-relations", "mysql")
+	c.Assert(err, gc.ErrorMatches, `cannot remove 1 of 2 relations of "mysql": .*`)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, `[`+
+		`{"id":1,"key":"wordpress:db mysql:server","endpoints":["wordpress:db","mysql:server"],"status":"failed","error":"boom"},`+
+		`{"id":2,"key":"mediawiki:db mysql:server","endpoints":["mediawiki:db","mysql:server"],"status":"removed"}`+
+		`]`+"\n")
+}
+
+func (s *RemoveRelationSuite) TestRemoveAllRelationsFormatJSONNone(c *gc.C) {
+	s.mockAPI.status = params.FullStatus{
+		Applications: map[string]params.ApplicationStatus{"mysql": {}},
+	}
+	ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandForTest(s.mockAPI), "--format", "json", "--all-relations", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, "[]\n")
+}
+
+// runRemoveRelationWithClock runs the command in the background with
+// the given clock, so that the test can advance it.
+func (s *RemoveRelationSuite) runRemoveRelationWithClock(c *gc.C, clock *testing.Clock, args ...string) <-chan removeRelationResult {
+	result := make(chan removeRelationResult, 1)
+	go func() {
+		ctx, err := coretesting.RunCommand(c, NewRemoveRelationCommandWithClockForTest(s.mockAPI, clock), args...)
+		result <- removeRelationResult{ctx, err}
+	}()
+	return result
+}
+
+type removeRelationResult struct {
+	ctx *cmd.Context
+	err error
+}
+
+func waitRemoveRelationResult(c *gc.C, result <-chan removeRelationResult) removeRelationResult {
+	select {
+	case r := <-result:
+		return r
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for remove-relation")
+	}
+	panic("unreachable")
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationWait(c *gc.C) {
+	s.setUpDetails()
+	notFound := &params.Error{Code: params.CodeNotFound, Message: "relation not found"}
+	s.mockAPI.SetErrors(nil, nil, nil, notFound)
+	clock := testing.NewClock(time.Time{})
+	result := s.runRemoveRelationWithClock(c, clock, "--wait", "application1", "application2")
+
+	err := clock.WaitAdvance(relationWaitPollInterval, coretesting.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	r := waitRemoveRelationResult(c, result)
+	c.Assert(r.err, jc.ErrorIsNil)
+	c.Assert(coretesting.Stderr(r.ctx), gc.Equals, `
+waiting for relation "application1:db application2:server": 2 units in it
+relation "application1:db application2:server" has been removed
+`[1:])
+	s.mockAPI.CheckCallNames(c, "RelationDetails", "DestroyRelation", "RelationDetails", "RelationDetails", "Close")
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationWaitTimeout(c *gc.C) {
+	s.setUpDetails()
+	clock := testing.NewClock(time.Time{})
+	result := s.runRemoveRelationWithClock(c, clock, "--wait", "--wait-timeout", "3s", "application1", "application2")
+
+	for i := 0; i < 2; i++ {
+		err := clock.WaitAdvance(relationWaitPollInterval, coretesting.LongWait, 1)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	r := waitRemoveRelationResult(c, result)
+	c.Assert(r.err, gc.ErrorMatches, `timed out after 3s waiting for relations to be removed: "application1:db application2:server"`)
+	c.Assert(coretesting.Stderr(r.ctx), gc.Equals, `
+waiting for relation "application1:db application2:server": 2 units in it
+`[1:])
+	s.mockAPI.CheckCallNames(c, "RelationDetails", "DestroyRelation", "RelationDetails", "RelationDetails", "RelationDetails", "Close")
+}
+
+func (s *RemoveRelationSuite) TestRemoveRelationWaitInvalidFlags(c *gc.C) {
+	err := s.runRemoveRelation(c, "--wait", "--dry-run", "application1", "application2")
+	c.Assert(err, gc.ErrorMatches, "cannot specify both --wait and --dry-run")
+	err = s.runRemoveRelation(c, "--wait", "--wait-timeout", "0s", "application1", "application2")
+	c.Assert(err, gc.ErrorMatches, "--wait-timeout must be positive")
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *RemoveRelationSuite) TestRemoveAllRelationsWait(c *gc.C) {
+	s.setUpAllRelations()
+	s.setUpDetails()
+	notFound := &params.Error{Code: params.CodeNotFound, Message: "relation not found"}
+	s.mockAPI.SetErrors(nil, nil, nil, notFound, nil, notFound)
+	clock := testing.NewClock(time.Time{})
+	result := s.runRemoveRelationWithClock(c, clock, "--wait", "--all-relations", "mysql")
+
+	err := clock.WaitAdvance(relationWaitPollInterval, coretesting.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	r := waitRemoveRelationResult(c, result)
+	c.Assert(r.err, jc.ErrorIsNil)
+	c.Assert(coretesting.Stderr(r.ctx), gc.Equals, `
+removed relation "wordpress:db mysql:server"
+removed relation "mediawiki:db mysql:server"
+relation "wordpress:db mysql:server" has been removed
+waiting for relation "mediawiki:db mysql:server": 2 units in it
+relation "mediawiki:db mysql:server" has been removed
+`[1:])
+	s.mockAPI.CheckCalls(c, []testing.StubCall{
+		{"Status", []interface{}{[]string{"mysql"}}},
+		{"DestroyRelation", []interface{}{[]string{"wordpress:db", "mysql:server"}}},
+		{"DestroyRelation", []interface{}{[]string{"mediawiki:db", "mysql:server"}}},
+		{"RelationDetails", []interface{}{[]string{"wordpress:db", "mysql:server"}}},
+		{"RelationDetails", []interface{}{[]string{"mediawiki:db", "mysql:server"}}},
+		{"RelationDetails", []interface{}{[]string{"mediawiki:db", "mysql:server"}}},
+		{"Close", nil},
+	})
+}
+
 type mockRemoveAPI struct {
 	*testing.Stub
 	removeRelationFunc func(endpoints ...string) error
+	details            params.RelationDetails
+	status             params.FullStatus
+	version            int
+}
+
+func (s mockRemoveAPI) BestAPIVersion() int {
+	return s.version
 }
 
 func (s mockRemoveAPI) Close() error {
@@ -85,3 +614,24 @@ func (s mockRemoveAPI) DestroyRelation(endpoints ...string) error {
 	s.MethodCall(s, "DestroyRelation", endpoints)
 	return s.removeRelationFunc(endpoints...)
 }
+
+func (s mockRemoveAPI) ForceDestroyRelation(endpoints ...string) error {
+	s.MethodCall(s, "ForceDestroyRelation", endpoints)
+	return s.removeRelationFunc(endpoints...)
+}
+
+func (s mockRemoveAPI) RelationDetails(endpoints ...string) (params.RelationDetails, error) {
+	s.MethodCall(s, "RelationDetails", endpoints)
+	if err := s.NextErr(); err != nil {
+		return params.RelationDetails{}, err
+	}
+	return s.details, nil
+}
+
+func (s mockRemoveAPI) Status(patterns []string) (*params.FullStatus, error) {
+	s.MethodCall(s, "Status", patterns)
+	if err := s.NextErr(); err != nil {
+		return nil, err
+	}
+	return &s.status, nil
+}
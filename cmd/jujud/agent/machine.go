@@ -1193,6 +1193,11 @@ func (a *MachineAgent) newAPIserverWorker(
 		return nil, errors.Annotate(err, "cannot fetch the controller config")
 	}
 
+	pingTimeout, err := newPingTimeoutConfig(agentConfig)
+	if err != nil {
+		return nil, &cmdutil.FatalError{err.Error()}
+	}
+
 	newObserver, err := newObserverFn(
 		controllerConfig,
 		clock.WallClock,
@@ -1219,6 +1224,7 @@ func (a *MachineAgent) newAPIserverWorker(
 
 	server, err := apiserver.NewServer(st, listener, apiserver.ServerConfig{
 		Clock:                         clock.WallClock,
+		PingTimeout:                   pingTimeout,
 		Cert:                          cert,
 		Key:                           key,
 		Tag:                           tag,
@@ -1233,6 +1239,7 @@ func (a *MachineAgent) newAPIserverWorker(
 		NewObserver:                   newObserver,
 		StatePool:                     statePool,
 		RegisterIntrospectionHandlers: registerIntrospectionHandlers,
+		PrometheusRegisterer:          a.prometheusRegistry,
 	})
 	if err != nil {
 		return nil, errors.Annotate(err, "cannot start api server worker")
@@ -1241,6 +1248,33 @@ func (a *MachineAgent) newAPIserverWorker(
 	return server, nil
 }
 
+// newPingTimeoutConfig returns the API server ping timeout settings
+// held in the agent config. Settings that are not present keep the
+// API server defaults.
+func newPingTimeoutConfig(agentConfig agent.Config) (apiserver.PingTimeoutConfig, error) {
+	var config apiserver.PingTimeoutConfig
+	var err error
+	if s := agentConfig.Value(agent.APIPingTimeout); s != "" {
+		if config.Timeout, err = time.ParseDuration(s); err != nil {
+			return config, errors.Errorf("invalid API ping timeout: %q", s)
+		}
+	}
+	if s := agentConfig.Value(agent.APIPingTimeoutAdaptive); s != "" {
+		if config.Adaptive, err = strconv.ParseBool(s); err != nil {
+			return config, errors.Errorf("invalid API ping timeout adaptive setting: %q", s)
+		}
+	}
+	if s := agentConfig.Value(agent.APIPingTimeoutMax); s != "" {
+		if config.MaxTimeout, err = time.ParseDuration(s); err != nil {
+			return config, errors.Errorf("invalid API ping timeout maximum: %q", s)
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return config, errors.Annotate(err, "invalid API ping timeout settings")
+	}
+	return config, nil
+}
+
 func newAuditEntrySink(st *state.State, logDir string) audit.AuditEntrySinkFn {
 	persistFn := st.PutAuditEntryFn()
 	fileSinkFn := audit.NewLogFileSink(logDir)
@@ -1294,6 +1328,7 @@ func newObserverFn(
 			ctx := &observer.AuditContext{
 				JujuServerVersion: jujuServerVersion,
 				ModelUUID:         modelUUID,
+				Methods:           controllerConfig.AuditLogMethods(),
 			}
 			return observer.NewAudit(ctx, persistAuditEntry, auditErrorHandler)
 		})
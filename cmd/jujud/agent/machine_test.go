@@ -36,6 +36,7 @@ import (
 	"github.com/juju/juju/api"
 	"github.com/juju/juju/api/imagemetadata"
 	apimachiner "github.com/juju/juju/api/machiner"
+	"github.com/juju/juju/apiserver"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/cmd/jujud/agent/model"
 	"github.com/juju/juju/core/migration"
@@ -1448,3 +1449,58 @@ func (w *nullWorker) Kill() {
 func (w *nullWorker) Wait() error {
 	return w.tomb.Wait()
 }
+
+type pingTimeoutConfigSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&pingTimeoutConfigSuite{})
+
+func (s *pingTimeoutConfigSuite) TestDefaults(c *gc.C) {
+	config, err := newPingTimeoutConfig(&mockAgentConfig{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(config, jc.DeepEquals, apiserver.PingTimeoutConfig{})
+}
+
+func (s *pingTimeoutConfigSuite) TestFromAgentConfig(c *gc.C) {
+	config, err := newPingTimeoutConfig(&mockAgentConfig{values: map[string]string{
+		agent.APIPingTimeout:         "2m",
+		agent.APIPingTimeoutAdaptive: "true",
+		agent.APIPingTimeoutMax:      "3m",
+	}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(config, jc.DeepEquals, apiserver.PingTimeoutConfig{
+		Timeout:    2 * time.Minute,
+		Adaptive:   true,
+		MaxTimeout: 3 * time.Minute,
+	})
+}
+
+func (s *pingTimeoutConfigSuite) TestInvalid(c *gc.C) {
+	for i, test := range []struct {
+		values map[string]string
+		err    string
+	}{{
+		values: map[string]string{agent.APIPingTimeout: "soon"},
+		err:    `invalid API ping timeout: "soon"`,
+	}, {
+		values: map[string]string{agent.APIPingTimeoutAdaptive: "maybe"},
+		err:    `invalid API ping timeout adaptive setting: "maybe"`,
+	}, {
+		values: map[string]string{agent.APIPingTimeoutMax: "later"},
+		err:    `invalid API ping timeout maximum: "later"`,
+	}, {
+		values: map[string]string{
+			agent.APIPingTimeout:    "2m",
+			agent.APIPingTimeoutMax: "1m",
+		},
+		err: "invalid API ping timeout settings: MaxTimeout less than Timeout not valid",
+	}, {
+		values: map[string]string{agent.APIPingTimeoutMax: "10m"},
+		err:    "invalid API ping timeout settings: MaxTimeout greater than 3m0s not valid",
+	}} {
+		c.Logf("test %d", i)
+		_, err := newPingTimeoutConfig(&mockAgentConfig{values: test.values})
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
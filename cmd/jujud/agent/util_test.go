@@ -286,6 +286,7 @@ type mockAgentConfig struct {
 	agent.Config
 	providerType string
 	tag          names.Tag
+	values       map[string]string
 }
 
 func (m *mockAgentConfig) Tag() names.Tag {
@@ -296,7 +297,7 @@ func (m *mockAgentConfig) Value(key string) string {
 	if key == agent.ProviderType {
 		return m.providerType
 	}
-	return ""
+	return m.values[key]
 }
 
 type singularRunnerRecord struct {
@@ -42,6 +42,14 @@ func main() {
 	if isInteractive || commandName != names.Jujud {
 		os.Exit(Main(os.Args))
 	} else {
+		// The service control manager cannot set the working
+		// directory of a service, so it is passed in the environment.
+		if dir := os.Getenv(osenv.JujuServiceWorkingDirEnvKey); dir != "" {
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+		}
 		s := service.SystemService{
 			Name: "jujud",
 			Cmd:  Main,
@@ -4,7 +4,9 @@
 package controller
 
 import (
+	"math/big"
 	"net/url"
+	"strings"
 
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
@@ -33,6 +35,12 @@ const (
 	// auditing information.
 	AuditingEnabled = "auditing-enabled"
 
+	// AuditLogMethods holds the API methods, in the form
+	// "<facade>.<method>", that the controller records in its audit
+	// log when auditing is enabled. When it is empty, every method is
+	// recorded.
+	AuditLogMethods = "audit-log-methods"
+
 	// StatePort is the port used for mongo connections.
 	StatePort = "state-port"
 
@@ -73,6 +81,24 @@ const (
 	// detault
 	MongoMemoryProfile = "mongo-memory-profile"
 
+	// APILoginRateLimit sets how many agent Login requests the API server
+	// will handle concurrently. It can be changed while the controller
+	// is running.
+	APILoginRateLimit = "api-login-rate-limit"
+
+	// APIEntityRequestLimit sets how many API calls each authenticated
+	// entity may have in progress at once; further calls fail until
+	// some have finished. Zero means there is no limit. Calls to
+	// watchers' Next methods are not counted. It can be changed while
+	// the controller is running.
+	APIEntityRequestLimit = "api-entity-request-limit"
+
+	// RevokedClientCertificates holds the serial numbers, in
+	// hexadecimal, of client certificates that may no longer be used
+	// to log in to the API, even though they were issued by the
+	// controller's CA and have not expired.
+	RevokedClientCertificates = "revoked-client-certificates"
+
 	// Attribute Defaults
 
 	// DefaultAuditingEnabled contains the default value for the
@@ -91,13 +117,24 @@ const (
 
 	// DefaultMongoMemoryProfile is the default profile used by mongo.
 	DefaultMongoMemoryProfile = MongoProfLow
+
+	// DefaultAPILoginRateLimit is the default value for the
+	// APILoginRateLimit config value.
+	DefaultAPILoginRateLimit = 10
+
+	// DefaultAPIEntityRequestLimit is the default value for the
+	// APIEntityRequestLimit config value.
+	DefaultAPIEntityRequestLimit = 0
 )
 
 // ControllerOnlyConfigAttributes are attributes which are only relevant
 // for a controller, never a model.
 var ControllerOnlyConfigAttributes = []string{
 	AllowModelAccessKey,
+	APIEntityRequestLimit,
+	APILoginRateLimit,
 	APIPort,
+	AuditLogMethods,
 	AutocertDNSNameKey,
 	AutocertURLKey,
 	CACertKey,
@@ -107,6 +144,26 @@ var ControllerOnlyConfigAttributes = []string{
 	SetNUMAControlPolicyKey,
 	StatePort,
 	MongoMemoryProfile,
+	RevokedClientCertificates,
+}
+
+// UpdatableConfigAttributes are controller attributes which may be
+// changed after the controller has been bootstrapped.
+var UpdatableConfigAttributes = []string{
+	APIEntityRequestLimit,
+	APILoginRateLimit,
+	RevokedClientCertificates,
+}
+
+// UpdatableAttribute returns true if the specified attribute name may
+// be changed after the controller has been bootstrapped.
+func UpdatableAttribute(attr string) bool {
+	for _, a := range UpdatableConfigAttributes {
+		if attr == a {
+			return true
+		}
+	}
+	return false
 }
 
 // ControllerOnlyAttribute returns true if the specified attribute name
@@ -197,6 +254,13 @@ func (c Config) AuditingEnabled() bool {
 	return false
 }
 
+// AuditLogMethods returns the API methods, in the form
+// "<facade>.<method>", that are recorded in the audit log. When none
+// are configured every method is recorded.
+func (c Config) AuditLogMethods() []string {
+	return c.stringList(AuditLogMethods)
+}
+
 // ControllerUUID returns the uuid for the model's controller.
 func (c Config) ControllerUUID() string {
 	return c.mustString(ControllerUUIDKey)
@@ -269,6 +333,77 @@ func (c Config) AllowModelAccess() bool {
 	return value
 }
 
+// APILoginRateLimit returns how many agent Login requests the API
+// server will handle concurrently.
+func (c Config) APILoginRateLimit() int {
+	switch v := c[APILoginRateLimit].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return DefaultAPILoginRateLimit
+}
+
+// APIEntityRequestLimit returns how many API calls each authenticated
+// entity may have in progress at once. Zero means there is no limit.
+func (c Config) APIEntityRequestLimit() int {
+	switch v := c[APIEntityRequestLimit].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return DefaultAPIEntityRequestLimit
+}
+
+// RevokedClientCertificates returns the serial numbers of the client
+// certificates that may no longer be used to log in to the API.
+func (c Config) RevokedClientCertificates() []*big.Int {
+	var serials []*big.Int
+	for _, s := range c.revokedClientCertificates() {
+		if serial, err := parseCertificateSerial(s); err == nil {
+			serials = append(serials, serial)
+		}
+	}
+	return serials
+}
+
+func (c Config) revokedClientCertificates() []string {
+	return c.stringList(RevokedClientCertificates)
+}
+
+func (c Config) stringList(key string) []string {
+	switch v := c[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// parseCertificateSerial parses a certificate serial number written in
+// hexadecimal, optionally with colons between the bytes as openssl
+// prints them.
+func parseCertificateSerial(s string) (*big.Int, error) {
+	serial, ok := new(big.Int).SetString(strings.Replace(s, ":", "", -1), 16)
+	if !ok {
+		return nil, errors.Errorf("invalid certificate serial number %q", s)
+	}
+	return serial, nil
+}
+
 // Validate ensures that config is a valid configuration.
 func Validate(c Config) error {
 	if v, ok := c[IdentityPublicKey].(string); ok {
@@ -310,6 +445,38 @@ func Validate(c Config) error {
 		}
 	}
 
+	if v, ok := c[APILoginRateLimit]; ok {
+		limit, err := schema.ForceInt().Coerce(v, nil)
+		if err != nil {
+			return errors.Annotatef(err, "%s", APILoginRateLimit)
+		}
+		if limit.(int) <= 0 {
+			return errors.Errorf("%s: expected positive value, got %d", APILoginRateLimit, limit)
+		}
+	}
+
+	if v, ok := c[APIEntityRequestLimit]; ok {
+		limit, err := schema.ForceInt().Coerce(v, nil)
+		if err != nil {
+			return errors.Annotatef(err, "%s", APIEntityRequestLimit)
+		}
+		if limit.(int) < 0 {
+			return errors.Errorf("%s: expected non-negative value, got %d", APIEntityRequestLimit, limit)
+		}
+	}
+
+	for _, s := range c.revokedClientCertificates() {
+		if _, err := parseCertificateSerial(s); err != nil {
+			return errors.Annotatef(err, "%s", RevokedClientCertificates)
+		}
+	}
+
+	for _, method := range c.AuditLogMethods() {
+		if parts := strings.Split(method, "."); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return errors.Errorf("%s: expected <facade>.<method>, got %q", AuditLogMethods, method)
+		}
+	}
+
 	return nil
 }
 
@@ -320,25 +487,33 @@ func GenerateControllerCertAndKey(caCert, caKey string, hostAddresses []string)
 }
 
 var configChecker = schema.FieldMap(schema.Fields{
-	AuditingEnabled:         schema.Bool(),
-	APIPort:                 schema.ForceInt(),
-	StatePort:               schema.ForceInt(),
-	IdentityURL:             schema.String(),
-	IdentityPublicKey:       schema.String(),
-	SetNUMAControlPolicyKey: schema.Bool(),
-	AutocertURLKey:          schema.String(),
-	AutocertDNSNameKey:      schema.String(),
-	AllowModelAccessKey:     schema.Bool(),
-	MongoMemoryProfile:      schema.String(),
+	AuditingEnabled:           schema.Bool(),
+	AuditLogMethods:           schema.List(schema.String()),
+	APIPort:                   schema.ForceInt(),
+	StatePort:                 schema.ForceInt(),
+	IdentityURL:               schema.String(),
+	IdentityPublicKey:         schema.String(),
+	SetNUMAControlPolicyKey:   schema.Bool(),
+	AutocertURLKey:            schema.String(),
+	AutocertDNSNameKey:        schema.String(),
+	AllowModelAccessKey:       schema.Bool(),
+	MongoMemoryProfile:        schema.String(),
+	APILoginRateLimit:         schema.ForceInt(),
+	APIEntityRequestLimit:     schema.ForceInt(),
+	RevokedClientCertificates: schema.List(schema.String()),
 }, schema.Defaults{
-	APIPort:                 DefaultAPIPort,
-	AuditingEnabled:         DefaultAuditingEnabled,
-	StatePort:               DefaultStatePort,
-	IdentityURL:             schema.Omit,
-	IdentityPublicKey:       schema.Omit,
-	SetNUMAControlPolicyKey: DefaultNUMAControlPolicy,
-	AutocertURLKey:          schema.Omit,
-	AutocertDNSNameKey:      schema.Omit,
-	AllowModelAccessKey:     schema.Omit,
-	MongoMemoryProfile:      schema.Omit,
+	APIPort:                   DefaultAPIPort,
+	AuditingEnabled:           DefaultAuditingEnabled,
+	AuditLogMethods:           schema.Omit,
+	StatePort:                 DefaultStatePort,
+	IdentityURL:               schema.Omit,
+	IdentityPublicKey:         schema.Omit,
+	SetNUMAControlPolicyKey:   DefaultNUMAControlPolicy,
+	AutocertURLKey:            schema.Omit,
+	AutocertDNSNameKey:        schema.Omit,
+	AllowModelAccessKey:       schema.Omit,
+	MongoMemoryProfile:        schema.Omit,
+	APILoginRateLimit:         schema.Omit,
+	APIEntityRequestLimit:     schema.Omit,
+	RevokedClientCertificates: schema.Omit,
 })
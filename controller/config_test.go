@@ -121,6 +121,65 @@ var validateTests = []struct {
 		controller.CACertKey:         testing.CACert,
 	},
 	expectError: `invalid identity public key: wrong length for base64 key, got 3 want 32`,
+}, {
+	about: "positive login rate limit OK",
+	config: controller.Config{
+		controller.APILoginRateLimit: 20,
+		controller.CACertKey:         testing.CACert,
+	},
+}, {
+	about: "non-positive login rate limit",
+	config: controller.Config{
+		controller.APILoginRateLimit: 0,
+		controller.CACertKey:         testing.CACert,
+	},
+	expectError: `api-login-rate-limit: expected positive value, got 0`,
+}, {
+	about: "non-numeric login rate limit",
+	config: controller.Config{
+		controller.APILoginRateLimit: "lots",
+		controller.CACertKey:         testing.CACert,
+	},
+	expectError: `api-login-rate-limit: expected number, got string\("lots"\)`,
+}, {
+	about: "zero entity request limit OK",
+	config: controller.Config{
+		controller.APIEntityRequestLimit: 0,
+		controller.CACertKey:             testing.CACert,
+	},
+}, {
+	about: "negative entity request limit",
+	config: controller.Config{
+		controller.APIEntityRequestLimit: -1,
+		controller.CACertKey:             testing.CACert,
+	},
+	expectError: `api-entity-request-limit: expected non-negative value, got -1`,
+}, {
+	about: "revoked client certificates OK",
+	config: controller.Config{
+		controller.RevokedClientCertificates: []interface{}{"0a1b", "0A:1B:2C"},
+		controller.CACertKey:                 testing.CACert,
+	},
+}, {
+	about: "invalid revoked client certificate serial",
+	config: controller.Config{
+		controller.RevokedClientCertificates: []interface{}{"0a1b", "xyz"},
+		controller.CACertKey:                 testing.CACert,
+	},
+	expectError: `revoked-client-certificates: invalid certificate serial number "xyz"`,
+}, {
+	about: "audit log methods OK",
+	config: controller.Config{
+		controller.AuditLogMethods: []interface{}{"Application.Deploy", "Client.DestroyMachines"},
+		controller.CACertKey:       testing.CACert,
+	},
+}, {
+	about: "invalid audit log method",
+	config: controller.Config{
+		controller.AuditLogMethods: []interface{}{"Application.Deploy", "Deploy"},
+		controller.CACertKey:       testing.CACert,
+	},
+	expectError: `audit-log-methods: expected <facade>.<method>, got "Deploy"`,
 }}
 
 func (s *ConfigSuite) TestValidate(c *gc.C) {
@@ -134,3 +193,54 @@ func (s *ConfigSuite) TestValidate(c *gc.C) {
 		}
 	}
 }
+
+func (s *ConfigSuite) TestAPILoginRateLimit(c *gc.C) {
+	cfg, err := controller.NewConfig(testing.ModelTag.Id(), testing.CACert, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.APILoginRateLimit(), gc.Equals, controller.DefaultAPILoginRateLimit)
+
+	cfg, err = controller.NewConfig(testing.ModelTag.Id(), testing.CACert, map[string]interface{}{
+		controller.APILoginRateLimit: "25",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.APILoginRateLimit(), gc.Equals, 25)
+}
+
+func (s *ConfigSuite) TestRevokedClientCertificates(c *gc.C) {
+	cfg, err := controller.NewConfig(testing.ModelTag.Id(), testing.CACert, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.RevokedClientCertificates(), gc.HasLen, 0)
+
+	cfg, err = controller.NewConfig(testing.ModelTag.Id(), testing.CACert, map[string]interface{}{
+		controller.RevokedClientCertificates: []interface{}{"0a1b", "01:00"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	serials := cfg.RevokedClientCertificates()
+	c.Assert(serials, gc.HasLen, 2)
+	c.Assert(serials[0].Int64(), gc.Equals, int64(0x0a1b))
+	c.Assert(serials[1].Int64(), gc.Equals, int64(0x0100))
+}
+
+func (s *ConfigSuite) TestAuditLogMethods(c *gc.C) {
+	cfg, err := controller.NewConfig(testing.ModelTag.Id(), testing.CACert, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.AuditLogMethods(), gc.HasLen, 0)
+
+	cfg, err = controller.NewConfig(testing.ModelTag.Id(), testing.CACert, map[string]interface{}{
+		controller.AuditLogMethods: []interface{}{"Application.Deploy"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.AuditLogMethods(), jc.DeepEquals, []string{"Application.Deploy"})
+}
+
+func (s *ConfigSuite) TestAPIEntityRequestLimit(c *gc.C) {
+	cfg, err := controller.NewConfig(testing.ModelTag.Id(), testing.CACert, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.APIEntityRequestLimit(), gc.Equals, controller.DefaultAPIEntityRequestLimit)
+
+	cfg, err = controller.NewConfig(testing.ModelTag.Id(), testing.CACert, map[string]interface{}{
+		controller.APIEntityRequestLimit: "4",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.APIEntityRequestLimit(), gc.Equals, 4)
+}
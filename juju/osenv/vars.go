@@ -27,6 +27,11 @@ const (
 	// of the command creation and initialisation process.
 	JujuStartupLoggingConfigEnvKey = "JUJU_STARTUP_LOGGING_CONFIG"
 
+	// JujuServiceWorkingDirEnvKey is set in the environment of a
+	// windows service to the directory jujud should change to when it
+	// starts, as the service control manager cannot set one.
+	JujuServiceWorkingDirEnvKey = "JUJU_SERVICE_WORKING_DIR"
+
 	// Registry key containing juju related information
 	JujuRegistryKey = `HKLM:\SOFTWARE\juju-core`
 
@@ -6,6 +6,7 @@ package common
 import (
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/utils/shell"
@@ -17,6 +18,19 @@ type Conf struct {
 	// Desc is the init service's description.
 	Desc string
 
+	// DisplayNameTemplate, if set, is what the init system displays
+	// as the name of the service instead of Desc, once the
+	// placeholders "{unit}" and "{model}" in it are replaced with
+	// Unit and Model.
+	// Currently only used on Windows.
+	DisplayNameTemplate string
+
+	// Unit and Model name the unit and model the service runs for,
+	// for use in DisplayNameTemplate.
+	// Currently only used on Windows.
+	Unit  string
+	Model string
+
 	// Transient indicates whether or not the service is a one-off.
 	Transient bool
 
@@ -26,7 +40,6 @@ type Conf struct {
 
 	// Env holds the environment variables that will be set when the
 	// command runs.
-	// Currently not used on Windows.
 	Env map[string]string
 
 	// TODO(ericsnow) Add a Limit type, since the possible keys are known.
@@ -46,6 +59,11 @@ type Conf struct {
 	// The command will be restarted if it exits with a non-zero exit code.
 	ExecStart string
 
+	// ExecStartWorkingDir, if set, is the directory ExecStart is run
+	// in.
+	// Currently only used on Windows.
+	ExecStartWorkingDir string
+
 	// ExecStopPost is the command that will be run after the service stops.
 	// The path to the executable must be absolute.
 	ExecStopPost string
@@ -64,6 +82,111 @@ type Conf struct {
 
 	// ServiceArgs is a string array of unquoted arguments
 	ServiceArgs []string
+
+	// StartOnNetworkAvailable indicates whether the service should
+	// also be started when the host acquires its first IP address.
+	// Currently only used on Windows.
+	StartOnNetworkAvailable bool
+
+	// Recovery, if set, describes what the init system should do when
+	// the service fails. When unset the init system's default for
+	// juju services is used.
+	// Currently only used on Windows.
+	Recovery *RecoveryConf
+
+	// StartType describes when the init system starts the service.
+	// The empty value is treated as StartAutomatic.
+	// Currently only used on Windows.
+	StartType StartType
+
+	// Dependencies holds the names of other services that must be
+	// started before this one, in the order the init system should
+	// consider them.
+	// Currently only used on Windows.
+	Dependencies []string
+}
+
+// StartType describes when an init system starts a service.
+type StartType string
+
+const (
+	// StartAutomatic starts the service at boot.
+	StartAutomatic StartType = "automatic"
+
+	// StartDelayed starts the service shortly after all automatic
+	// services have started, so it does not contend for resources at boot.
+	StartDelayed StartType = "delayed"
+
+	// StartManual only starts the service when explicitly asked to.
+	StartManual StartType = "manual"
+)
+
+// Validate checks that the start type is known.
+func (t StartType) Validate() error {
+	switch t {
+	case "", StartAutomatic, StartDelayed, StartManual:
+		return nil
+	}
+	return errors.NotValidf("start type %q", string(t))
+}
+
+// RecoveryAction is what an init system does when a service fails.
+type RecoveryAction string
+
+const (
+	// RecoveryNone leaves the failed service stopped.
+	RecoveryNone RecoveryAction = "none"
+
+	// RecoveryRestart restarts the failed service.
+	RecoveryRestart RecoveryAction = "restart"
+
+	// RecoveryReboot reboots the host.
+	RecoveryReboot RecoveryAction = "reboot"
+)
+
+// Validate checks that the action is known. The empty action is
+// treated as RecoveryNone.
+func (a RecoveryAction) Validate() error {
+	switch a {
+	case "", RecoveryNone, RecoveryRestart, RecoveryReboot:
+		return nil
+	}
+	return errors.NotValidf("recovery action %q", string(a))
+}
+
+// RecoveryConf describes how a service is recovered after it fails.
+type RecoveryConf struct {
+	// FirstFailure is the action taken the first time the service fails.
+	FirstFailure RecoveryAction
+
+	// SecondFailure is the action taken the second time the service fails.
+	SecondFailure RecoveryAction
+
+	// SubsequentFailures is the action taken on every further failure.
+	SubsequentFailures RecoveryAction
+
+	// Delay is how long to wait before taking an action.
+	Delay time.Duration
+
+	// ResetPeriod is how long the service must run without failing
+	// before its failure count is reset.
+	ResetPeriod time.Duration
+}
+
+// Validate checks the recovery conf's values for correctness.
+func (rc RecoveryConf) Validate() error {
+	for _, action := range []RecoveryAction{rc.FirstFailure, rc.SecondFailure, rc.SubsequentFailures} {
+		if err := action.Validate(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if rc.Delay < 0 {
+		return errors.NotValidf("negative recovery delay")
+	}
+	if rc.ResetPeriod < 0 {
+		return errors.NotValidf("negative recovery reset period")
+	}
+	return nil
 }
 
 // IsZero determines whether or not the conf is a zero value.
@@ -93,6 +216,22 @@ func (c Conf) Validate(renderer shell.Renderer) error {
 		}
 	}
 
+	if c.Recovery != nil {
+		if err := c.Recovery.Validate(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	if err := c.StartType.Validate(); err != nil {
+		return errors.Trace(err)
+	}
+
+	for _, dependency := range c.Dependencies {
+		if dependency == "" {
+			return errors.NotValidf("empty dependency")
+		}
+	}
+
 	return nil
 }
 
@@ -4,6 +4,9 @@
 package common_test
 
 import (
+	"time"
+
+	"github.com/juju/errors"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils/shell"
@@ -115,3 +118,86 @@ func (*confSuite) TestValidateRelativeExecStopPost(c *gc.C) {
 
 	c.Check(err, gc.ErrorMatches, `.*relative path in ExecStopPost \(.*`)
 }
+
+func (*confSuite) TestValidateRecovery(c *gc.C) {
+	conf := common.Conf{
+		Desc:      "some service",
+		ExecStart: "/path/to/some-command a b c",
+		Recovery: &common.RecoveryConf{
+			FirstFailure:       common.RecoveryRestart,
+			SecondFailure:      common.RecoveryRestart,
+			SubsequentFailures: common.RecoveryNone,
+			Delay:              time.Second,
+			ResetPeriod:        time.Hour,
+		},
+	}
+	err := conf.Validate(renderer)
+
+	c.Check(err, jc.ErrorIsNil)
+}
+
+func (*confSuite) TestValidateRecoveryUnknownAction(c *gc.C) {
+	conf := common.Conf{
+		Desc:      "some service",
+		ExecStart: "/path/to/some-command a b c",
+		Recovery: &common.RecoveryConf{
+			FirstFailure: "explode",
+		},
+	}
+	err := conf.Validate(renderer)
+
+	c.Check(err, jc.Satisfies, errors.IsNotValid)
+	c.Check(err, gc.ErrorMatches, `recovery action "explode" not valid`)
+}
+
+func (*confSuite) TestValidateRecoveryNegativeDelay(c *gc.C) {
+	conf := common.Conf{
+		Desc:      "some service",
+		ExecStart: "/path/to/some-command a b c",
+		Recovery: &common.RecoveryConf{
+			Delay: -time.Second,
+		},
+	}
+	err := conf.Validate(renderer)
+
+	c.Check(err, gc.ErrorMatches, `negative recovery delay not valid`)
+}
+
+func (*confSuite) TestValidateStartType(c *gc.C) {
+	for _, startType := range []common.StartType{
+		"", common.StartAutomatic, common.StartDelayed, common.StartManual,
+	} {
+		conf := common.Conf{
+			Desc:      "some service",
+			ExecStart: "/path/to/some-command a b c",
+			StartType: startType,
+		}
+		err := conf.Validate(renderer)
+
+		c.Check(err, jc.ErrorIsNil)
+	}
+}
+
+func (*confSuite) TestValidateUnknownStartType(c *gc.C) {
+	conf := common.Conf{
+		Desc:      "some service",
+		ExecStart: "/path/to/some-command a b c",
+		StartType: "whenever",
+	}
+	err := conf.Validate(renderer)
+
+	c.Check(err, jc.Satisfies, errors.IsNotValid)
+	c.Check(err, gc.ErrorMatches, `start type "whenever" not valid`)
+}
+
+func (*confSuite) TestValidateEmptyDependency(c *gc.C) {
+	conf := common.Conf{
+		Desc:         "some service",
+		ExecStart:    "/path/to/some-command a b c",
+		Dependencies: []string{"MSSQLSERVER", ""},
+	}
+	err := conf.Validate(renderer)
+
+	c.Check(err, jc.Satisfies, errors.IsNotValid)
+	c.Check(err, gc.ErrorMatches, `empty dependency not valid`)
+}
@@ -5,13 +5,17 @@
 package windows
 
 import (
+	"time"
+
 	"github.com/juju/testing"
+	"github.com/juju/utils/clock"
 )
 
 var (
 	JujudUser                    = jujudUser
 	ERROR_SERVICE_DOES_NOT_EXIST = c_ERROR_SERVICE_DOES_NOT_EXIST
 	ERROR_SERVICE_EXISTS         = c_ERROR_SERVICE_EXISTS
+	DisplayName                  = displayName
 )
 
 type patcher interface {
@@ -24,3 +28,15 @@ func PatchServiceManager(patcher patcher, stub *testing.Stub) *StubSvcManager {
 	patcher.PatchValue(&listServices, manager.ListServices)
 	return manager
 }
+
+// PatchListServices replaces the function used to list the installed
+// services.
+func PatchListServices(patcher patcher, listServicesFunc func() ([]string, error)) {
+	patcher.PatchValue(&listServices, listServicesFunc)
+}
+
+// PatchServiceListCache enables the cache of installed services used by
+// Service.Installed, with the given clock and ttl.
+func PatchServiceListCache(patcher patcher, clock clock.Clock, ttl time.Duration) {
+	patcher.PatchValue(&installedServices, &serviceListCache{clock: clock, ttl: ttl})
+}
@@ -7,20 +7,91 @@
 package windows
 
 import (
+	"syscall"
+	"unsafe"
+
 	"github.com/juju/testing"
+	"github.com/juju/utils/clock"
 )
 
 var (
-	ResetJujudPassword        = resetJujudPassword
-	EnsureJujudPasswordHelper = ensureJujudPasswordHelper
+	ResetJujudPassword         = resetJujudPassword
+	EnsureJujudPasswordHelper  = ensureJujudPasswordHelper
+	StopPollInterval           = stopPollInterval
+	DeleteStopTimeout          = deleteStopTimeout
+	FlapPollInterval           = flapPollInterval
+	LogonAttempts              = &logonAttempts
+	LogonRetryDelay            = &logonRetryDelay
+	EnumServicesBufferSize     = &enumServicesBufferSize
+	EnumServiceNames           = enumServiceNames
+	EnumServiceNamesWithPrefix = enumServiceNamesWithPrefix
+	ERROR_LOGON_FAILURE        = c_ERROR_LOGON_FAILURE
+	ERROR_LOGON_NOT_GRANTED    = c_ERROR_LOGON_NOT_GRANTED
+
+	ERROR_SERVICE_MARKED_FOR_DELETE = c_ERROR_SERVICE_MARKED_FOR_DELETE
 )
 
+// SetClock replaces the clock used by a service manager returned
+// by NewServiceManager.
+func SetClock(m ServiceManager, clock clock.Clock) {
+	m.(*SvcManager).clock = clock
+}
+
 func PatchMgrConnect(patcher patcher, stub *testing.Stub) *StubMgr {
 	conn := &StubMgr{Stub: stub}
 	patcher.PatchValue(&newManager, func() (windowsManager, error) { return conn, nil })
 	return conn
 }
 
+func PatchLogonUser(patcher patcher, stub *testing.Stub) *StubLogonUser {
+	l := &StubLogonUser{Stub: stub}
+	patcher.PatchValue(&logonUser, l.LogonUser)
+	return l
+}
+
+func PatchEnumServices(patcher patcher, stub *testing.Stub, names []string) *StubEnumServices {
+	e := &StubEnumServices{Stub: stub, Names: names}
+	patcher.PatchValue(&enumServices, e.EnumServicesStatus)
+	return e
+}
+
+// EnumServiceEntrySize is the size of each service entry written by
+// enumServices.
+const EnumServiceEntrySize = int(unsafe.Sizeof(enumService{}))
+
+// EnumServicesBuffer returns a buffer holding an entry for each of the
+// named services, in the given states, laid out as enumServices writes
+// them. The returned names back the entries and must be kept alive for
+// as long as the buffer is used.
+func EnumServicesBuffer(names []string, states []uint32) ([]byte, [][]uint16) {
+	size := unsafe.Sizeof(enumService{})
+	buf := make([]byte, uintptr(len(names))*size)
+	keep := make([][]uint16, len(names))
+	for i, name := range names {
+		keep[i] = syscall.StringToUTF16(name)
+		entry := (*enumService)(unsafe.Pointer(&buf[uintptr(i)*size]))
+		entry.name = &keep[i][0]
+		entry.Status.CurrentState = states[i]
+	}
+	return buf, keep
+}
+
+// DecodeEnumServices returns the names and states of the first n
+// services in buf, as read by enumServiceNames.
+func DecodeEnumServices(buf []byte, n uint32) ([]string, []uint32, error) {
+	entries, err := enumServiceEntries(buf, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	var states []uint32
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+		states = append(states, entry.Status.CurrentState)
+	}
+	return names, states, nil
+}
+
 func PatchGetPassword(patcher patcher, stub *testing.Stub) *StubGetPassword {
 	p := &StubGetPassword{Stub: stub}
 	patcher.PatchValue(&getPassword, p.GetPassword)
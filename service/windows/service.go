@@ -5,15 +5,19 @@
 package windows
 
 import (
+	"bytes"
 	"fmt"
+	"regexp"
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
 	"github.com/juju/utils/shell"
 
+	"github.com/juju/juju/juju/osenv"
 	"github.com/juju/juju/service/common"
 )
 
@@ -42,6 +46,58 @@ const (
 	jujudUser = ".\\jujud"
 )
 
+// ErrServiceLogonNotGranted is the cause of the error returned by
+// SvcManager.Create when the jujud user has not been granted the right
+// to log on as a service. Detect it with errors.Cause.
+var ErrServiceLogonNotGranted = errors.New(
+	`the jujud user has not been granted the "Log on as a service" right (SeServiceLogonRight); ` +
+		`grant it to .\jujud with the Local Security Policy editor (secpol.msc) or ntrights.exe, then retry`,
+)
+
+// maxDisplayNameLen is the longest display name the service control
+// manager accepts.
+const maxDisplayNameLen = 256
+
+// displayNamePlaceholder matches a placeholder in a display name
+// template.
+var displayNamePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// displayName returns the name the service control manager displays
+// for a service with the given config: the rendered display name
+// template if there is one, or the description.
+func displayName(conf common.Conf) string {
+	if conf.DisplayNameTemplate == "" {
+		return conf.Desc
+	}
+	return strings.NewReplacer(
+		"{unit}", conf.Unit,
+		"{model}", conf.Model,
+	).Replace(conf.DisplayNameTemplate)
+}
+
+// validateDisplayName returns an error if the display name template in
+// conf has unknown placeholders, or renders too long a display name.
+func validateDisplayName(conf common.Conf) error {
+	for _, placeholder := range displayNamePlaceholder.FindAllString(conf.DisplayNameTemplate, -1) {
+		if placeholder != "{unit}" && placeholder != "{model}" {
+			return errors.NotValidf("display name placeholder %q", placeholder)
+		}
+	}
+	if name := displayName(conf); len(name) > maxDisplayNameLen {
+		return errors.NotValidf("display name longer than %d characters", maxDisplayNameLen)
+	}
+	return nil
+}
+
+// ErrServiceMarkedForDelete is the cause of the error returned by
+// SvcManager.Delete when the service could only be marked for deletion,
+// because it has not stopped or something still holds a handle to it.
+// Detect it with errors.Cause.
+var ErrServiceMarkedForDelete = errors.New(
+	`the service is marked for deletion but could not be removed yet; ` +
+		`close anything holding it open, such as the Services console, or reboot to finish removing it`,
+)
+
 // IsRunning returns whether or not windows is the local init system.
 func IsRunning() (bool, error) {
 	return runtime.GOOS == "windows", nil
@@ -53,6 +109,13 @@ func ListServices() ([]string, error) {
 	return listServices()
 }
 
+// ListServicesWithPrefix returns the names of the installed services on
+// the local host that start with prefix, such as "jujud-". The match is
+// case sensitive.
+func ListServicesWithPrefix(prefix string) ([]string, error) {
+	return listServicesWithPrefix(prefix)
+}
+
 // ListCommand returns a command that will list the services on a host.
 func ListCommand() string {
 	return `(Get-Service).Name`
@@ -64,20 +127,61 @@ type ServiceManager interface {
 	Start(name string) error
 	// Stop stops a service.
 	Stop(name string) error
+	// StartMany starts the named services, returning the result for
+	// each of them.
+	StartMany(names []string) map[string]error
+	// StopMany stops the named services, returning the result for
+	// each of them.
+	StopMany(names []string) map[string]error
+	// StopWait stops a service and waits up to timeout for it to stop.
+	StopWait(name string, timeout time.Duration) error
+	// Pause pauses a running service.
+	Pause(name string) error
+	// Continue resumes a paused service.
+	Continue(name string) error
 	// Delete deletes a service.
 	Delete(name string) error
 	// Create creates a service with the given config.
 	Create(name string, conf common.Conf) error
+	// Update changes the config of an existing service in place.
+	Update(name string, conf common.Conf) error
 	// Running returns the status of a service.
 	Running(name string) (bool, error)
+	// Status returns the current state of a service.
+	Status(name string) (State, error)
+	// ProcessInfo returns the id of the process running a service,
+	// which is 0 if the service is not running, and the account the
+	// service runs as.
+	ProcessInfo(name string) (pid uint32, account string, err error)
+	// IsFlapping reports whether a service stopped running more than
+	// threshold times while it was sampled for window.
+	IsFlapping(name string, window time.Duration, threshold int) (bool, error)
 	// Exists checks whether the config of the installed service matches the
 	// config supplied to this function
 	Exists(name string, conf common.Conf) (bool, error)
 	// ChangeServicePassword can change the password of a service
 	// as long as it belongs to the user defined in this package
 	ChangeServicePassword(name, newPassword string) error
+	// UpdatePassword changes just the stored password of the account
+	// a service runs as.
+	UpdatePassword(name, password string) error
 }
 
+// State describes the state of a service, as reported by the
+// service control manager.
+type State string
+
+const (
+	StateStopped         State = "stopped"
+	StateStartPending    State = "start-pending"
+	StateStopPending     State = "stop-pending"
+	StateRunning         State = "running"
+	StateContinuePending State = "continue-pending"
+	StatePausePending    State = "pause-pending"
+	StatePaused          State = "paused"
+	StateUnknown         State = "unknown"
+)
+
 // Service represents a service running on the current system
 type Service struct {
 	common.Service
@@ -127,6 +231,19 @@ func (s *Service) Validate() error {
 		return errors.NotSupportedf("Conf.AfterStopped")
 	}
 
+	if err := validateDisplayName(s.Service.Conf); err != nil {
+		return errors.Trace(err)
+	}
+
+	for key := range s.Service.Conf.Env {
+		if key == "" || strings.Contains(key, "=") {
+			return errors.NotValidf("environment variable name %q", key)
+		}
+		if strings.EqualFold(key, osenv.JujuServiceWorkingDirEnvKey) {
+			return errors.NotValidf("environment variable %s (use Conf.ExecStartWorkingDir)", key)
+		}
+	}
+
 	return nil
 }
 
@@ -139,18 +256,76 @@ func (s *Service) Running() (bool, error) {
 	return s.manager.Running(s.Name())
 }
 
-// Installed returns whether the service is installed
+// Status returns the current state of the service. A service that
+// is not installed is reported as stopped.
+func (s *Service) Status() (State, error) {
+	if ok, err := s.Installed(); err != nil {
+		return StateUnknown, errors.Trace(err)
+	} else if !ok {
+		return StateStopped, nil
+	}
+	state, err := s.manager.Status(s.Name())
+	if err != nil {
+		return StateUnknown, errors.Trace(err)
+	}
+	return state, nil
+}
+
+// ProcessInfo returns the id of the process running the service, which
+// is 0 if the service is not running, and the account it runs as.
+func (s *Service) ProcessInfo() (pid uint32, account string, err error) {
+	if ok, err := s.Installed(); err != nil {
+		return 0, "", errors.Trace(err)
+	} else if !ok {
+		return 0, "", errors.NotFoundf("service %q", s.Name())
+	}
+	pid, account, err = s.manager.ProcessInfo(s.Name())
+	if err != nil {
+		return 0, "", errors.Trace(err)
+	}
+	return pid, account, nil
+}
+
+// Health describes whether a service is installed and running.
+type Health struct {
+	// Installed holds whether the service is installed.
+	Installed bool
+
+	// Running holds whether the service is running.
+	Running bool
+
+	// State holds the state of the service as reported by the
+	// service control manager. It is StateStopped when the service
+	// is not installed.
+	State State
+}
+
+// HealthCheck reports whether the service is installed and running,
+// with a single query to the service control manager rather than an
+// enumeration of all the services. A service that is not installed is
+// reported as such, not as an error.
+func (s *Service) HealthCheck() (Health, error) {
+	state, err := s.manager.Status(s.Name())
+	if errors.Cause(err) == c_ERROR_SERVICE_DOES_NOT_EXIST {
+		return Health{State: StateStopped}, nil
+	} else if err != nil {
+		return Health{State: StateUnknown}, errors.Trace(err)
+	}
+	return Health{
+		Installed: true,
+		Running:   state == StateRunning,
+		State:     state,
+	}, nil
+}
+
+// Installed returns whether the service is installed. To check many
+// services at once, use InstalledServices.
 func (s *Service) Installed() (bool, error) {
-	services, err := ListServices()
+	installed, err := InstalledServices([]string{s.Name()})
 	if err != nil {
 		return false, errors.Trace(err)
 	}
-	for _, val := range services {
-		if s.Name() == val {
-			return true, nil
-		}
-	}
-	return false, nil
+	return installed[s.Name()], nil
 }
 
 // Exists returns whether the service configuration reflects the
@@ -187,6 +362,36 @@ func (s *Service) Stop() error {
 	return err
 }
 
+// Pause pauses the service. Services that do not accept being paused
+// are reported with an error satisfying errors.IsNotSupported.
+func (s *Service) Pause() error {
+	logger.Infof("Pausing service %q", s.Service.Name)
+	if err := s.checkInstalled(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.manager.Pause(s.Name()))
+}
+
+// Continue resumes the service after it was paused.
+func (s *Service) Continue() error {
+	logger.Infof("Continuing service %q", s.Service.Name)
+	if err := s.checkInstalled(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.manager.Continue(s.Name()))
+}
+
+func (s *Service) checkInstalled() error {
+	installed, err := s.Installed()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !installed {
+		return errors.NotFoundf("service %q", s.Name())
+	}
+	return nil
+}
+
 // Remove deletes the service.
 func (s *Service) Remove() error {
 	installed, err := s.Installed()
@@ -201,6 +406,7 @@ func (s *Service) Remove() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	defer installedServices.invalidate()
 	err = s.manager.Delete(s.Name())
 	return err
 }
@@ -216,10 +422,21 @@ func (s *Service) Install() error {
 		return errors.Trace(err)
 	}
 	if installed {
-		return errors.Errorf("Service %s already installed", s.Name())
+		// A service whose config has drifted is updated in place, so
+		// that it keeps its identity and recovery history.
+		exists, err := s.Exists()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if exists {
+			return errors.Errorf("Service %s already installed", s.Name())
+		}
+		logger.Infof("Updating Service %v", s.Name())
+		return errors.Trace(s.manager.Update(s.Name(), s.Conf()))
 	}
 
 	logger.Infof("Installing Service %v", s.Name())
+	defer installedServices.invalidate()
 	err = s.manager.Create(s.Name(), s.Conf())
 	if err != nil {
 		return errors.Trace(err)
@@ -229,17 +446,51 @@ func (s *Service) Install() error {
 
 // InstallCommands returns shell commands to install the service.
 func (s *Service) InstallCommands() ([]string, error) {
+	binaryPath := quoteLiteral(binaryPathName(s.Service.Conf))
 	cmd := fmt.Sprintf(serviceCreateCommandTemplate[1:],
 		renderer.Quote(s.Service.Name),
-		renderer.Quote(s.Service.Conf.Desc),
-		renderer.Quote(s.Service.Conf.ExecStart),
+		renderer.Quote(displayName(s.Service.Conf)),
+		binaryPath,
+		renderer.Quote(s.Service.Name),
+		renderer.Quote(displayName(s.Service.Conf)),
+		binaryPath,
+		renderer.Quote(s.Service.Name),
+		renderer.Quote(s.Service.Name),
+	)
+	cmds := strings.Split(cmd, "\n")
+	if err := validateInstallCommands(cmds); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return cmds, nil
+}
+
+// InstallCommandsWithCreds returns a self-contained script to install
+// the service running as user. Unlike InstallCommands, it does not rely
+// on $jujuCreds being in scope: it creates the credential from
+// encryptedPass, the user's password as encrypted by
+// ConvertFrom-SecureString. Such a password can only be decrypted by
+// the account that encrypted it, on the same host.
+func (s *Service) InstallCommandsWithCreds(user, encryptedPass string) ([]string, error) {
+	if user == "" {
+		return nil, errors.NotValidf("empty user")
+	}
+	if encryptedPass == "" {
+		return nil, errors.NotValidf("empty encrypted password")
+	}
+	cmd := fmt.Sprintf(serviceCreateWithCredsCommandTemplate[1:],
+		quoteLiteral(encryptedPass),
+		quoteLiteral(user),
 		renderer.Quote(s.Service.Name),
-		renderer.Quote(s.Service.Conf.Desc),
-		renderer.Quote(s.Service.Conf.ExecStart),
+		renderer.Quote(displayName(s.Service.Conf)),
+		quoteLiteral(binaryPathName(s.Service.Conf)),
 		renderer.Quote(s.Service.Name),
 		renderer.Quote(s.Service.Name),
 	)
-	return strings.Split(cmd, "\n"), nil
+	cmds := strings.Split(cmd, "\n")
+	if err := validateInstallCommands(cmds); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return cmds, nil
 }
 
 // StartCommands returns shell commands to start the service.
@@ -250,9 +501,187 @@ func (s *Service) StartCommands() ([]string, error) {
 
 const serviceCreateCommandTemplate = `
 if ($jujuCreds) {
-  New-Service -Credential $jujuCreds -Name %s -DependsOn Winmgmt -DisplayName %s %s
+  New-Service -Credential $jujuCreds -Name %s -DependsOn Winmgmt -DisplayName %s -BinaryPathName %s
 } else {
-  New-Service -Name %s -DependsOn Winmgmt -DisplayName %s %s
+  New-Service -Name %s -DependsOn Winmgmt -DisplayName %s -BinaryPathName %s
 }
 sc.exe failure %s reset=5 actions=restart/1000
 sc.exe failureflag %s 1`
+
+const serviceCreateWithCredsCommandTemplate = `
+$jujuPasswd = ConvertTo-SecureString %s
+$jujuCreds = New-Object System.Management.Automation.PSCredential (%s, $jujuPasswd)
+New-Service -Credential $jujuCreds -Name %s -DependsOn Winmgmt -DisplayName %s -BinaryPathName %s
+sc.exe failure %s reset=5 actions=restart/1000
+sc.exe failureflag %s 1`
+
+// binaryPathName returns the command line the service control manager
+// runs for a service with the given config. It is composed from the
+// binary and its arguments the same way mgr.CreateService does, so
+// services installed by the install commands match those created by
+// SvcManager.Create; configs without a ServiceBinary fall back to
+// ExecStart.
+func binaryPathName(conf common.Conf) string {
+	if conf.ServiceBinary == "" {
+		return conf.ExecStart
+	}
+	path := escapeArg(conf.ServiceBinary)
+	for _, arg := range conf.ServiceArgs {
+		path += " " + escapeArg(arg)
+	}
+	return path
+}
+
+// escapeArg quotes s for a windows command line, the way
+// syscall.EscapeArg does. syscall.EscapeArg is only available on
+// windows, and the install commands are rendered on any OS.
+func escapeArg(s string) string {
+	if s == "" {
+		return `""`
+	}
+	if !strings.ContainsAny(s, " \t\"") {
+		return s
+	}
+	hasSpace := strings.ContainsAny(s, " \t")
+	var buf bytes.Buffer
+	if hasSpace {
+		buf.WriteByte('"')
+	}
+	slashes := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			slashes++
+		case '"':
+			// Backslashes before a quote, and the quote itself,
+			// must be escaped.
+			buf.WriteString(strings.Repeat(`\`, slashes+1))
+			slashes = 0
+		default:
+			slashes = 0
+		}
+		buf.WriteByte(s[i])
+	}
+	if hasSpace {
+		// Backslashes before the closing quote must be escaped.
+		buf.WriteString(strings.Repeat(`\`, slashes))
+		buf.WriteByte('"')
+	}
+	return buf.String()
+}
+
+// quoteLiteral quotes s as a PowerShell literal string, which is never
+// expanded or resolved as a provider path. Unlike renderer.Quote, it is
+// safe for UNC paths such as \\server\share\jujud.exe, whose leading
+// backslashes some PowerShell versions otherwise mangle. PowerShell
+// treats the typographic single quotes as quotes too, so they are
+// doubled along with the ASCII one.
+func quoteLiteral(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '\u2018', '\u2019', '\u201a', '\u201b':
+			buf.WriteRune(r)
+		}
+		buf.WriteRune(r)
+	}
+	buf.WriteByte('\'')
+	return buf.String()
+}
+
+// psSingleQuotes and psDoubleQuotes hold the characters PowerShell
+// treats as single and double quotes respectively.
+const (
+	psSingleQuotes = "'\u2018\u2019\u201a\u201b"
+	psDoubleQuotes = "\"\u201c\u201d\u201e"
+)
+
+// validateInstallCommands checks that each of the rendered install
+// commands would parse on its own, so that a config whose values
+// renderer.Quote cannot quote, such as a description with a newline,
+// is rejected rather than shipped to the machine. Every quoted string
+// must be closed on the line that opens it, and the New-Service
+// command must be given a service name.
+func validateInstallCommands(cmds []string) error {
+	for _, cmd := range cmds {
+		words, err := splitPowershellWords(cmd)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if len(words) == 0 || words[0] != "New-Service" {
+			continue
+		}
+		name := ""
+		for i, word := range words[:len(words)-1] {
+			if word == "-Name" {
+				name = words[i+1]
+				break
+			}
+		}
+		if name == "" {
+			return errors.NotValidf("install command %q with empty service name", cmd)
+		}
+	}
+	return nil
+}
+
+// splitPowershellWords splits a line of PowerShell into words at
+// unquoted whitespace, the way PowerShell splits command arguments,
+// removing the quotes and backtick escapes from each word. Escape
+// sequences such as `n are not expanded. It returns an error if a
+// quoted string is not closed by the end of the line, or if the line
+// ends with a backtick, which would continue the command on the next.
+func splitPowershellWords(line string) ([]string, error) {
+	var (
+		words  []string
+		word   []rune
+		inWord bool
+		quotes string
+	)
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '`' && quotes != psSingleQuotes:
+			if i+1 == len(runes) {
+				return nil, errors.NotValidf("install command %q ending with a backtick", line)
+			}
+			i++
+			word = append(word, runes[i])
+			inWord = true
+		case quotes != "" && strings.ContainsRune(quotes, r):
+			// A quote doubled inside a quoted string stands for
+			// itself.
+			if i+1 < len(runes) && strings.ContainsRune(quotes, runes[i+1]) {
+				i++
+				word = append(word, runes[i])
+			} else {
+				quotes = ""
+			}
+		case quotes != "":
+			word = append(word, r)
+		case strings.ContainsRune(psSingleQuotes, r):
+			quotes = psSingleQuotes
+			inWord = true
+		case strings.ContainsRune(psDoubleQuotes, r):
+			quotes = psDoubleQuotes
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, string(word))
+				word, inWord = nil, false
+			}
+		default:
+			word = append(word, r)
+			inWord = true
+		}
+	}
+	if quotes != "" {
+		return nil, errors.NotValidf("install command %q with unbalanced quotes", line)
+	}
+	if inWord {
+		words = append(words, string(word))
+	}
+	return words, nil
+}
@@ -7,6 +7,8 @@
 package windows
 
 import (
+	"time"
+
 	"github.com/juju/juju/service/common"
 )
 
@@ -23,6 +25,41 @@ func (s *SvcManager) Stop(name string) error {
 	return nil
 }
 
+// StartMany starts the named services, returning the result for each
+// of them.
+func (s *SvcManager) StartMany(names []string) map[string]error {
+	return manyResults(names)
+}
+
+// StopMany stops the named services, returning the result for each of
+// them.
+func (s *SvcManager) StopMany(names []string) map[string]error {
+	return manyResults(names)
+}
+
+func manyResults(names []string) map[string]error {
+	results := make(map[string]error)
+	for _, name := range names {
+		results[name] = nil
+	}
+	return results
+}
+
+// StopWait stops a service and waits up to timeout for it to stop.
+func (s *SvcManager) StopWait(name string, timeout time.Duration) error {
+	return nil
+}
+
+// Pause pauses a running service.
+func (s *SvcManager) Pause(name string) error {
+	return nil
+}
+
+// Continue resumes a paused service.
+func (s *SvcManager) Continue(name string) error {
+	return nil
+}
+
 // Delete deletes a service.
 func (s *SvcManager) Delete(name string) error {
 	return nil
@@ -33,11 +70,33 @@ func (s *SvcManager) Create(name string, conf common.Conf) error {
 	return nil
 }
 
+// Update changes the config of an existing service in place.
+func (s *SvcManager) Update(name string, conf common.Conf) error {
+	return nil
+}
+
 // Running returns the status of a service.
 func (s *SvcManager) Running(name string) (bool, error) {
 	return false, nil
 }
 
+// Status returns the current state of a service.
+func (s *SvcManager) Status(name string) (State, error) {
+	return StateStopped, nil
+}
+
+// ProcessInfo returns the id of the process running a service and the
+// account the service runs as.
+func (s *SvcManager) ProcessInfo(name string) (uint32, string, error) {
+	return 0, "", nil
+}
+
+// IsFlapping reports whether a service stopped running more than
+// threshold times while it was sampled for window.
+func (s *SvcManager) IsFlapping(name string, window time.Duration, threshold int) (bool, error) {
+	return false, nil
+}
+
 // Exists checks whether the config of the installed service matches the
 // config supplied to this function
 func (s *SvcManager) Exists(name string, conf common.Conf) (bool, error) {
@@ -50,10 +109,20 @@ func (s *SvcManager) ChangeServicePassword(name, newPassword string) error {
 	return nil
 }
 
+// UpdatePassword changes just the stored password of the account a
+// service runs as.
+func (s *SvcManager) UpdatePassword(name, password string) error {
+	return nil
+}
+
 var listServices = func() ([]string, error) {
 	return []string{}, nil
 }
 
+var listServicesWithPrefix = func(prefix string) ([]string, error) {
+	return []string{}, nil
+}
+
 var NewServiceManager = func() (ServiceManager, error) {
 	return &SvcManager{}, nil
 }
@@ -5,9 +5,15 @@
 package windows_test
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/juju/errors"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils/shell"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/juju/service/common"
@@ -81,6 +87,24 @@ func (s *serviceSuite) TestInstallAlreadyExists(c *gc.C) {
 	s.stub.CheckCallNames(c, "listServices", "Create", "listServices")
 }
 
+func (s *serviceSuite) TestInstallUpdatesDivergentService(c *gc.C) {
+	err := s.mgr.Install()
+	c.Assert(err, gc.IsNil)
+
+	conf := s.conf
+	conf.ExecStart = s.execPath + " --debug " + s.name
+	svc, err := windows.NewService(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	err = svc.Install()
+	c.Assert(err, gc.IsNil)
+
+	exists, err := s.stubMgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+	s.stub.CheckCallNames(c, "listServices", "Create", "listServices", "Update")
+	s.stub.CheckCall(c, 3, "Update", s.name, conf)
+}
+
 func (s *serviceSuite) TestStop(c *gc.C) {
 	err := s.mgr.Install()
 	c.Assert(err, gc.IsNil)
@@ -104,6 +128,67 @@ func (s *serviceSuite) TestStop(c *gc.C) {
 	c.Assert(running, jc.IsFalse)
 }
 
+func (s *serviceSuite) TestStatus(c *gc.C) {
+	state, err := s.mgr.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(state, gc.Equals, windows.StateStopped)
+
+	err = s.mgr.Install()
+	c.Assert(err, gc.IsNil)
+	err = s.mgr.Start()
+	c.Assert(err, gc.IsNil)
+
+	state, err = s.mgr.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(state, gc.Equals, windows.StateRunning)
+}
+
+func (s *serviceSuite) TestProcessInfo(c *gc.C) {
+	_, _, err := s.mgr.ProcessInfo()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	err = s.mgr.Install()
+	c.Assert(err, gc.IsNil)
+	pid, account, err := s.mgr.ProcessInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pid, gc.Equals, uint32(0))
+	c.Assert(account, gc.Equals, windows.JujudUser)
+
+	err = s.mgr.Start()
+	c.Assert(err, gc.IsNil)
+	s.stubMgr.SetPid(s.name, 1234)
+	pid, _, err = s.mgr.ProcessInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pid, gc.Equals, uint32(1234))
+}
+
+func (s *serviceSuite) TestPauseContinue(c *gc.C) {
+	err := s.mgr.Install()
+	c.Assert(err, gc.IsNil)
+	err = s.mgr.Start()
+	c.Assert(err, gc.IsNil)
+
+	err = s.mgr.Pause()
+	c.Assert(err, gc.IsNil)
+	state, err := s.mgr.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(state, gc.Equals, windows.StatePaused)
+
+	err = s.mgr.Continue()
+	c.Assert(err, gc.IsNil)
+	state, err = s.mgr.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(state, gc.Equals, windows.StateRunning)
+}
+
+func (s *serviceSuite) TestPauseNotInstalled(c *gc.C) {
+	err := s.mgr.Pause()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	err = s.mgr.Continue()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	s.stub.CheckCallNames(c, "listServices", "listServices")
+}
+
 func (s *serviceSuite) TestStopStart(c *gc.C) {
 	err := s.mgr.Install()
 	c.Assert(err, gc.IsNil)
@@ -206,3 +291,383 @@ func (s *serviceSuite) TestInstalledListError(c *gc.C) {
 	c.Assert(err.Error(), gc.Equals, listErr.Error())
 	c.Assert(exists, jc.IsFalse)
 }
+
+func (s *serviceSuite) TestInstalledCached(c *gc.C) {
+	clock := testing.NewClock(time.Now())
+	windows.PatchServiceListCache(s, clock, 5*time.Second)
+	err := s.mgr.Install()
+	c.Assert(err, gc.IsNil)
+
+	for i := 0; i < 3; i++ {
+		exists, err := s.mgr.Installed()
+		c.Assert(err, gc.IsNil)
+		c.Assert(exists, jc.IsTrue)
+	}
+	s.stub.CheckCallNames(c, "listServices", "Create", "listServices")
+
+	clock.Advance(5 * time.Second)
+	exists, err := s.mgr.Installed()
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+	s.stub.CheckCallNames(c, "listServices", "Create", "listServices", "listServices")
+}
+
+func (s *serviceSuite) TestInstalledCacheInvalidatedOnRemove(c *gc.C) {
+	windows.PatchServiceListCache(s, testing.NewClock(time.Now()), time.Minute)
+	err := s.mgr.Install()
+	c.Assert(err, gc.IsNil)
+
+	err = s.mgr.Remove()
+	c.Assert(err, gc.IsNil)
+	exists, err := s.mgr.Installed()
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+
+	s.stub.CheckCallNames(c, "listServices", "Create", "listServices", "Running", "Delete", "listServices")
+}
+
+func (s *serviceSuite) TestRefreshServiceList(c *gc.C) {
+	windows.PatchServiceListCache(s, testing.NewClock(time.Now()), time.Minute)
+	_, err := s.mgr.Installed()
+	c.Assert(err, gc.IsNil)
+	windows.RefreshServiceList()
+	_, err = s.mgr.Installed()
+	c.Assert(err, gc.IsNil)
+
+	s.stub.CheckCallNames(c, "listServices", "listServices")
+}
+
+func (s *serviceSuite) TestInstalledCacheListError(c *gc.C) {
+	windows.PatchServiceListCache(s, testing.NewClock(time.Now()), time.Minute)
+	listErr := errors.New("random error")
+	s.stub.SetErrors(listErr)
+
+	_, err := s.mgr.Installed()
+	c.Assert(errors.Cause(err), gc.Equals, listErr)
+
+	// Errors are not cached.
+	_, err = s.mgr.Installed()
+	c.Assert(err, gc.IsNil)
+	s.stub.CheckCallNames(c, "listServices", "listServices")
+}
+
+func (s *serviceSuite) TestInstalledCacheConcurrent(c *gc.C) {
+	windows.PatchServiceListCache(s, testing.NewClock(time.Now()), time.Minute)
+	err := s.mgr.Install()
+	c.Assert(err, gc.IsNil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			exists, err := s.mgr.Installed()
+			c.Check(err, gc.IsNil)
+			c.Check(exists, jc.IsTrue)
+		}()
+	}
+	wg.Wait()
+	s.stub.CheckCallNames(c, "listServices", "Create", "listServices")
+}
+
+func (s *serviceSuite) TestInstalledServices(c *gc.C) {
+	var all []string
+	for i := 0; i < 5000; i++ {
+		all = append(all, fmt.Sprintf("service-%d", i))
+	}
+	calls := 0
+	windows.PatchListServices(s, func() ([]string, error) {
+		calls++
+		return all, nil
+	})
+
+	var names []string
+	expected := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		installed := fmt.Sprintf("service-%d", i*97)
+		missing := fmt.Sprintf("unit-%d", i)
+		names = append(names, installed, missing)
+		expected[installed] = true
+		expected[missing] = false
+	}
+	installed, err := windows.InstalledServices(names)
+	c.Assert(err, gc.IsNil)
+	c.Assert(installed, jc.DeepEquals, expected)
+	c.Assert(calls, gc.Equals, 1)
+}
+
+func (s *serviceSuite) TestInstalledServicesNoNames(c *gc.C) {
+	installed, err := windows.InstalledServices(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(installed, gc.HasLen, 0)
+}
+
+func (s *serviceSuite) TestInstalledServicesError(c *gc.C) {
+	listErr := errors.New("random error")
+	s.stub.SetErrors(listErr)
+
+	_, err := windows.InstalledServices([]string{s.name})
+	c.Assert(errors.Cause(err), gc.Equals, listErr)
+	s.stub.CheckCallNames(c, "listServices")
+}
+
+func (s *serviceSuite) TestDisplayName(c *gc.C) {
+	conf := common.Conf{Desc: "juju unit agent for mysql/0"}
+	c.Assert(windows.DisplayName(conf), gc.Equals, "juju unit agent for mysql/0")
+
+	conf.DisplayNameTemplate = "juju {unit} ({model})"
+	conf.Unit = "mysql/0"
+	conf.Model = "prod"
+	c.Assert(windows.DisplayName(conf), gc.Equals, "juju mysql/0 (prod)")
+}
+
+func (s *serviceSuite) TestValidateDisplayNameTemplate(c *gc.C) {
+	s.conf.DisplayNameTemplate = "juju {unit} ({model})"
+	svc, err := windows.NewService(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Validate(), gc.IsNil)
+
+	s.conf.DisplayNameTemplate = "juju {machine}"
+	svc, err = windows.NewService(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Validate(), gc.ErrorMatches, `display name placeholder "{machine}" not valid`)
+
+	s.conf.DisplayNameTemplate = "{model}"
+	s.conf.Model = strings.Repeat("m", 257)
+	svc, err = windows.NewService(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Validate(), gc.ErrorMatches, `display name longer than 256 characters not valid`)
+}
+
+func (s *serviceSuite) TestInstallDisplayNameTemplate(c *gc.C) {
+	err := s.mgr.Install()
+	c.Assert(err, gc.IsNil)
+
+	// A template rendering to the description matches the installed
+	// service.
+	conf := s.conf
+	conf.DisplayNameTemplate = "service for {unit}"
+	conf.Unit = s.name
+	exists, err := s.stubMgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+
+	// A different display name means the service is updated.
+	conf.Unit = "mysql/0"
+	svc, err := windows.NewService(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	err = svc.Install()
+	c.Assert(err, gc.IsNil)
+	s.stub.CheckCallNames(c, "listServices", "Create", "listServices", "Update")
+}
+
+func (s *serviceSuite) TestInstallCommandsBinaryPath(c *gc.C) {
+	for i, test := range []struct {
+		about    string
+		binary   string
+		args     []string
+		expected string
+	}{{
+		about:    "drive letter path",
+		binary:   `C:\Juju\bin\jujud.exe`,
+		args:     []string{"machine", "--data-dir", `C:\Juju`},
+		expected: `'C:\Juju\bin\jujud.exe machine --data-dir C:\Juju'`,
+	}, {
+		about:    "path with spaces",
+		binary:   `C:\Program Files\Juju\jujud.exe`,
+		args:     []string{"machine", "--data-dir", `C:\Juju Data\`},
+		expected: `'"C:\Program Files\Juju\jujud.exe" machine --data-dir "C:\Juju Data\\"'`,
+	}, {
+		about:    "UNC path",
+		binary:   `\\server\share\jujud.exe`,
+		args:     []string{"machine"},
+		expected: `'\\server\share\jujud.exe machine'`,
+	}, {
+		about:    "UNC path with spaces",
+		binary:   `\\server\juju share\jujud.exe`,
+		args:     []string{"machine"},
+		expected: `'"\\server\juju share\jujud.exe" machine'`,
+	}, {
+		about:    "path with single quotes",
+		binary:   `C:\Bob's\jujud.exe`,
+		expected: `'C:\Bob''s\jujud.exe'`,
+	}} {
+		c.Logf("test %d: %s", i, test.about)
+		conf := s.conf
+		conf.ServiceBinary = test.binary
+		conf.ServiceArgs = test.args
+		svc, err := windows.NewService(s.name, conf)
+		c.Assert(err, gc.IsNil)
+
+		cmds, err := svc.InstallCommands()
+		c.Assert(err, gc.IsNil)
+		c.Assert(cmds[1], gc.Equals, fmt.Sprintf(
+			"  New-Service -Credential $jujuCreds -Name 'machine-1' -DependsOn Winmgmt -DisplayName 'service for machine-1' -BinaryPathName %s",
+			test.expected,
+		))
+		c.Assert(cmds[3], gc.Equals, fmt.Sprintf(
+			"  New-Service -Name 'machine-1' -DependsOn Winmgmt -DisplayName 'service for machine-1' -BinaryPathName %s",
+			test.expected,
+		))
+	}
+}
+
+func (s *serviceSuite) TestInstallCommandsExecStart(c *gc.C) {
+	cmds, err := s.mgr.InstallCommands()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cmds[3], gc.Equals,
+		`  New-Service -Name 'machine-1' -DependsOn Winmgmt -DisplayName 'service for machine-1' -BinaryPathName 'C:\juju\bin\jujud.exe machine-1'`,
+	)
+}
+
+func (s *serviceSuite) TestInstallCommandsDescription(c *gc.C) {
+	for i, test := range []struct {
+		about       string
+		desc        string
+		displayName string
+		err         string
+	}{{
+		about:       "single quotes",
+		desc:        `o'brien's service`,
+		displayName: (&shell.PowershellRenderer{}).Quote(`o'brien's service`),
+	}, {
+		about:       "double quotes",
+		desc:        `the "machine" service`,
+		displayName: `'the "machine" service'`,
+	}, {
+		about:       "backticks",
+		desc:        "run `whoami` first`",
+		displayName: "'run `whoami` first`'",
+	}, {
+		about: "typographic single quote",
+		desc:  "o\u2019brien service",
+		err:   `install command ".*" with unbalanced quotes not valid`,
+	}, {
+		about: "newline",
+		desc:  "service for\nmachine-1",
+		err:   `install command ".*" with unbalanced quotes not valid`,
+	}, {
+		about: "carriage return and newline",
+		desc:  "service for\r\nmachine-1",
+		err:   `install command ".*" with unbalanced quotes not valid`,
+	}} {
+		c.Logf("test %d: %s", i, test.about)
+		conf := s.conf
+		conf.Desc = test.desc
+		svc, err := windows.NewService(s.name, conf)
+		c.Assert(err, gc.IsNil)
+
+		cmds, err := svc.InstallCommands()
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			c.Assert(err, jc.Satisfies, errors.IsNotValid)
+			c.Assert(cmds, gc.IsNil)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Assert(cmds[3], gc.Equals, fmt.Sprintf(
+			`  New-Service -Name 'machine-1' -DependsOn Winmgmt -DisplayName %s -BinaryPathName 'C:\juju\bin\jujud.exe machine-1'`,
+			test.displayName,
+		))
+	}
+}
+
+func (s *serviceSuite) TestInstallCommandsEmptyName(c *gc.C) {
+	svc, err := windows.NewService("", s.conf)
+	c.Assert(err, gc.IsNil)
+
+	_, err = svc.InstallCommands()
+	c.Assert(err, gc.ErrorMatches, `install command ".*" with empty service name not valid`)
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+
+	_, err = svc.InstallCommandsWithCreds(`.\jujud`, "01000000d08c9ddf")
+	c.Assert(err, gc.ErrorMatches, `install command ".*" with empty service name not valid`)
+}
+
+func (s *serviceSuite) TestInstallCommandsWithCredsDescriptionNewline(c *gc.C) {
+	conf := s.conf
+	conf.Desc = "service for\nmachine-1"
+	svc, err := windows.NewService(s.name, conf)
+	c.Assert(err, gc.IsNil)
+
+	_, err = svc.InstallCommandsWithCreds(`.\jujud`, "01000000d08c9ddf")
+	c.Assert(err, gc.ErrorMatches, `install command ".*" with unbalanced quotes not valid`)
+}
+
+func (s *serviceSuite) TestInstallCommandsWithCreds(c *gc.C) {
+	cmds, err := s.mgr.InstallCommandsWithCreds(`.\jujud`, "01000000d08c9ddf")
+	c.Assert(err, gc.IsNil)
+	c.Assert(cmds, jc.DeepEquals, []string{
+		`$jujuPasswd = ConvertTo-SecureString '01000000d08c9ddf'`,
+		`$jujuCreds = New-Object System.Management.Automation.PSCredential ('.\jujud', $jujuPasswd)`,
+		`New-Service -Credential $jujuCreds -Name 'machine-1' -DependsOn Winmgmt -DisplayName 'service for machine-1' -BinaryPathName 'C:\juju\bin\jujud.exe machine-1'`,
+		`sc.exe failure 'machine-1' reset=5 actions=restart/1000`,
+		`sc.exe failureflag 'machine-1' 1`,
+	})
+}
+
+func (s *serviceSuite) TestInstallCommandsWithCredsQuotesUser(c *gc.C) {
+	cmds, err := s.mgr.InstallCommandsWithCreds(`HOST\o'brien`, "01000000d08c9ddf")
+	c.Assert(err, gc.IsNil)
+	c.Assert(cmds[1], gc.Equals,
+		`$jujuCreds = New-Object System.Management.Automation.PSCredential ('HOST\o''brien', $jujuPasswd)`,
+	)
+}
+
+func (s *serviceSuite) TestInstallCommandsWithCredsMissing(c *gc.C) {
+	_, err := s.mgr.InstallCommandsWithCreds("", "01000000d08c9ddf")
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+	_, err = s.mgr.InstallCommandsWithCreds(`.\jujud`, "")
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+}
+
+func (s *serviceSuite) TestValidateEnv(c *gc.C) {
+	s.conf.Env = map[string]string{"JUJU_DEV_FEATURE_FLAGS": "a,b"}
+	svc, err := windows.NewService(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Validate(), gc.IsNil)
+
+	s.conf.Env = map[string]string{"A=B": "c"}
+	svc, err = windows.NewService(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Validate(), gc.ErrorMatches, `environment variable name "A=B" not valid`)
+
+	s.conf.Env = map[string]string{"JUJU_SERVICE_WORKING_DIR": `C:\Juju`}
+	svc, err = windows.NewService(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Validate(), gc.ErrorMatches, `environment variable JUJU_SERVICE_WORKING_DIR \(use Conf.ExecStartWorkingDir\) not valid`)
+}
+
+func (s *serviceSuite) TestHealthCheck(c *gc.C) {
+	err := s.mgr.Install()
+	c.Assert(err, gc.IsNil)
+	s.stub.ResetCalls()
+
+	health, err := s.mgr.HealthCheck()
+	c.Assert(err, gc.IsNil)
+	c.Assert(health, jc.DeepEquals, windows.Health{
+		Installed: true,
+		State:     windows.StateStopped,
+	})
+
+	err = s.mgr.Start()
+	c.Assert(err, gc.IsNil)
+	s.stub.ResetCalls()
+
+	health, err = s.mgr.HealthCheck()
+	c.Assert(err, gc.IsNil)
+	c.Assert(health, jc.DeepEquals, windows.Health{
+		Installed: true,
+		Running:   true,
+		State:     windows.StateRunning,
+	})
+	// The services are not enumerated.
+	s.stub.CheckCallNames(c, "Status")
+}
+
+func (s *serviceSuite) TestHealthCheckNotInstalled(c *gc.C) {
+	health, err := s.mgr.HealthCheck()
+	c.Assert(err, gc.IsNil)
+	c.Assert(health, jc.DeepEquals, windows.Health{State: windows.StateStopped})
+	s.stub.CheckCallNames(c, "Status")
+}
@@ -5,17 +5,25 @@
 package windows
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"syscall"
+	"time"
+	"unicode/utf16"
 	"unsafe"
 
 	// https://bugs.launchpad.net/juju-core/+bug/1470820
 	"github.com/juju/errors"
+	"github.com/juju/utils/clock"
 	"github.com/juju/utils/series"
 	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
 
+	"github.com/juju/juju/juju/osenv"
 	"github.com/juju/juju/service/common"
 )
 
@@ -29,6 +37,14 @@ const (
 )
 
 //sys enumServicesStatus(h windows.Handle, InfoLevel SC_ENUM_TYPE, dwServiceType uint32, dwServiceState uint32, lpServices uintptr, cbBufSize uint32, pcbBytesNeeded *uint32, lpServicesReturned *uint32, lpResumeHandle *uint32, pszGroupName *uint32) (err error) [failretval==0] = advapi32.EnumServicesStatusExW
+//sys logonUserW(username *uint16, domain *uint16, password *uint16, logonType uint32, logonProvider uint32, token *syscall.Handle) (err error) [failretval==0] = advapi32.LogonUserW
+//sys queryServiceStatusEx(h windows.Handle, infoLevel uint32, buff *byte, buffSize uint32, bytesNeeded *uint32) (err error) [failretval==0] = advapi32.QueryServiceStatusEx
+
+// https://msdn.microsoft.com/en-us/library/windows/desktop/aa378184(v=vs.85).aspx
+const (
+	LOGON32_LOGON_SERVICE    = 5
+	LOGON32_PROVIDER_DEFAULT = 0
+)
 
 const (
 	SC_ACTION_NONE = iota
@@ -59,7 +75,48 @@ type serviceFailureActionsFlag struct {
 // This is done so we can mock this function out
 var WinChangeServiceConfig2 = windows.ChangeServiceConfig2
 
-// serviceStatusProcess is used by EnumServicesStatusEx
+// https://msdn.microsoft.com/en-us/library/windows/desktop/ms685988(v=vs.85).aspx
+type serviceDelayedAutoStartInfo struct {
+	fDelayedAutostart int32
+}
+
+// https://msdn.microsoft.com/en-us/library/windows/desktop/ms681988(v=vs.85).aspx
+const SERVICE_CONFIG_DELAYED_AUTO_START_INFO = 3
+
+// https://msdn.microsoft.com/en-us/library/windows/desktop/dd405515(v=vs.85).aspx
+const (
+	SERVICE_CONFIG_TRIGGER_INFO = 8
+
+	SERVICE_TRIGGER_TYPE_IP_ADDRESS_AVAILABILITY = 2
+	SERVICE_TRIGGER_ACTION_SERVICE_START         = 1
+)
+
+// NETWORK_MANAGER_FIRST_IP_ADDRESS_ARRIVAL_GUID is the trigger subtype
+// fired when the first IP address on the TCP/IP stack becomes available.
+var NETWORK_MANAGER_FIRST_IP_ADDRESS_ARRIVAL_GUID = windows.GUID{
+	Data1: 0x4f27f2de,
+	Data2: 0x14e2,
+	Data3: 0x430b,
+	Data4: [8]byte{0xa5, 0x49, 0x7c, 0xd4, 0x8c, 0xbc, 0x82, 0x45},
+}
+
+// https://msdn.microsoft.com/en-us/library/windows/desktop/dd405515(v=vs.85).aspx
+type serviceTrigger struct {
+	dwTriggerType   uint32
+	dwAction        uint32
+	pTriggerSubtype *windows.GUID
+	cDataItems      uint32
+	pDataItems      uintptr
+}
+
+// https://msdn.microsoft.com/en-us/library/windows/desktop/dd405516(v=vs.85).aspx
+type serviceTriggerInfo struct {
+	cTriggers uint32
+	pTriggers *serviceTrigger
+	pReserved *byte
+}
+
+// serviceStatusProcess is used by EnumServicesStatusEx and QueryServiceStatusEx
 // https://msdn.microsoft.com/en-us/library/windows/desktop/ms685992%28v=vs.85%29.aspx
 type serviceStatusProcess struct {
 	ServiceType             uint32
@@ -73,12 +130,36 @@ type serviceStatusProcess struct {
 	ServiceFlags            uint32
 }
 
+// https://msdn.microsoft.com/en-us/library/windows/desktop/ms684941(v=vs.85).aspx
+const SC_STATUS_PROCESS_INFO = 0
+
 type enumService struct {
 	name        *uint16
 	displayName *uint16
 	Status      serviceStatusProcess
 }
 
+// hasPrefix reports whether the name of the service stored in
+// enumService starts with prefix, given in UTF16. It compares the name
+// in place, without converting it to a string.
+func (s *enumService) hasPrefix(prefix []uint16) bool {
+	if len(prefix) == 0 {
+		return true
+	}
+	if s.name == nil {
+		return false
+	}
+	name := (*[1 << 16]uint16)(unsafe.Pointer(s.name))
+	for i, c := range prefix {
+		// The name is NUL terminated, so a short name never
+		// matches past its end.
+		if name[i] != c {
+			return false
+		}
+	}
+	return true
+}
+
 // Name returns the name of the service stored in enumService.
 func (s *enumService) Name() string {
 	if s.name != nil {
@@ -91,8 +172,22 @@ func (s *enumService) Name() string {
 type windowsManager interface {
 	CreateService(name, exepath string, c mgr.Config, args ...string) (windowsService, error)
 	OpenService(name string) (windowsService, error)
+	Connect() (serviceConnection, error)
 	GetHandle(name string) (windows.Handle, error)
 	CloseHandle(handle windows.Handle) error
+	ChangeServiceConfig(handle windows.Handle, serviceType, startType, errorControl uint32, binaryPathName, loadOrderGroup *uint16, tagId *uint32, dependencies, serviceStartName, password, displayName *uint16) error
+	ChangeServiceConfig2(handle windows.Handle, infoLevel uint32, info *byte) error
+	QueryServiceConfig2(handle windows.Handle, infoLevel uint32, buff *byte, buffSize uint32, bytesNeeded *uint32) error
+	QueryServiceStatusEx(handle windows.Handle) (serviceStatusProcess, error)
+	SetEnvironment(name string, env []string) error
+	Environment(name string) ([]string, error)
+}
+
+// serviceConnection is a connection to the service control manager
+// that can be used to open many services.
+type serviceConnection interface {
+	OpenService(name string) (windowsService, error)
+	Disconnect() error
 }
 
 // windowsService exposes mgr.Service methods needed by the windows service package.
@@ -135,6 +230,31 @@ func (m *manager) OpenService(name string) (windowsService, error) {
 	return s.OpenService(name)
 }
 
+// Connect returns a connection to the service control manager, which
+// must be disconnected once it is no longer needed.
+func (m *manager) Connect() (serviceConnection, error) {
+	s, err := mgr.Connect()
+	if err != nil {
+		return nil, err
+	}
+	return &connection{s}, nil
+}
+
+// connection wraps a mgr.Mgr so that OpenService returns a windowsService.
+type connection struct {
+	m *mgr.Mgr
+}
+
+// OpenService wraps Mgr.OpenService method.
+func (c *connection) OpenService(name string) (windowsService, error) {
+	return c.m.OpenService(name)
+}
+
+// Disconnect wraps Mgr.Disconnect method.
+func (c *connection) Disconnect() error {
+	return c.m.Disconnect()
+}
+
 // CreateService wraps Mgr.OpenService method but returns a windows.Handle object.
 // This is used to access a lower level function not directly exposed by
 // the sys/windows package.
@@ -157,6 +277,80 @@ func (m *manager) CloseHandle(handle windows.Handle) error {
 	return windows.CloseServiceHandle(handle)
 }
 
+// ChangeServiceConfig wraps the windows.ChangeServiceConfig method.
+// This allows us to stub out this module for testing.
+func (m *manager) ChangeServiceConfig(handle windows.Handle, serviceType, startType, errorControl uint32, binaryPathName, loadOrderGroup *uint16, tagId *uint32, dependencies, serviceStartName, password, displayName *uint16) error {
+	return windows.ChangeServiceConfig(handle, serviceType, startType, errorControl, binaryPathName, loadOrderGroup, tagId, dependencies, serviceStartName, password, displayName)
+}
+
+// ChangeServiceConfig2 wraps the windows.ChangeServiceConfig2 method.
+// This allows us to stub out this module for testing.
+func (m *manager) ChangeServiceConfig2(handle windows.Handle, infoLevel uint32, info *byte) error {
+	return windows.ChangeServiceConfig2(handle, infoLevel, info)
+}
+
+// QueryServiceConfig2 wraps the windows.QueryServiceConfig2 method.
+// This allows us to stub out this module for testing.
+func (m *manager) QueryServiceConfig2(handle windows.Handle, infoLevel uint32, buff *byte, buffSize uint32, bytesNeeded *uint32) error {
+	return windows.QueryServiceConfig2(handle, infoLevel, buff, buffSize, bytesNeeded)
+}
+
+// QueryServiceStatusEx wraps the QueryServiceStatusEx winapi call,
+// which sys/windows does not expose.
+// This allows us to stub out this module for testing.
+func (m *manager) QueryServiceStatusEx(handle windows.Handle) (serviceStatusProcess, error) {
+	var status serviceStatusProcess
+	var needed uint32
+	err := queryServiceStatusEx(handle, SC_STATUS_PROCESS_INFO, (*byte)(unsafe.Pointer(&status)), uint32(unsafe.Sizeof(status)), &needed)
+	return status, err
+}
+
+// serviceEnvironmentValue is the registry value, under the service key,
+// holding the environment of a service as KEY=value strings. mgr.Config
+// does not expose it.
+const serviceEnvironmentValue = "Environment"
+
+// serviceKeyPath returns the path of the registry key of the named
+// service, relative to HKEY_LOCAL_MACHINE.
+func serviceKeyPath(name string) string {
+	return `SYSTEM\CurrentControlSet\Services\` + name
+}
+
+// SetEnvironment sets the environment of the named service in the
+// registry. An empty env removes the environment.
+func (m *manager) SetEnvironment(name string, env []string) error {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, serviceKeyPath(name), registry.SET_VALUE)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer key.Close()
+	if len(env) == 0 {
+		err := key.DeleteValue(serviceEnvironmentValue)
+		if err == registry.ErrNotExist {
+			return nil
+		}
+		return errors.Trace(err)
+	}
+	return errors.Trace(key.SetStringsValue(serviceEnvironmentValue, env))
+}
+
+// Environment returns the environment of the named service, as set in
+// the registry.
+func (m *manager) Environment(name string) ([]string, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, serviceKeyPath(name), registry.QUERY_VALUE)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer key.Close()
+	env, _, err := key.GetStringsValue(serviceEnvironmentValue)
+	if err == registry.ErrNotExist {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return env, nil
+}
+
 var newManager = func() (windowsManager, error) {
 	return &manager{}, nil
 }
@@ -171,10 +365,59 @@ var getPassword = func() (string, error) {
 	return passwd, nil
 }
 
+// logonUser logs on the given user and returns a token for it. It is
+// defined as a variable to allow us to mock it out for testing.
+var logonUser = func(username, domain, password *uint16, logonType, logonProvider uint32) (syscall.Handle, error) {
+	var token syscall.Handle
+	err := logonUserW(username, domain, password, logonType, logonProvider, &token)
+	return token, err
+}
+
+// validateJujudPassword checks that the jujud user can log on as a
+// service with password, so that a bad password is reported as such
+// rather than as a failure to create or start the service.
+func validateJujudPassword(password string) error {
+	// jujudUser is qualified with the domain; "." is the local
+	// account database.
+	domain, user := ".", jujudUser
+	if i := strings.LastIndex(jujudUser, `\`); i >= 0 {
+		domain, user = jujudUser[:i], jujudUser[i+1:]
+	}
+	userp, err := syscall.UTF16PtrFromString(user)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	domainp, err := syscall.UTF16PtrFromString(domain)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	passp, err := syscall.UTF16PtrFromString(password)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	token, err := logonUser(userp, domainp, passp, LOGON32_LOGON_SERVICE, LOGON32_PROVIDER_DEFAULT)
+	if err != nil {
+		return err
+	}
+	if token != syscall.InvalidHandle {
+		if err := syscall.CloseHandle(token); err != nil {
+			logger.Warningf("cannot close logon token for %q: %v", jujudUser, err)
+		}
+	}
+	return nil
+}
+
 // listServices returns an array of strings containing all the services on
 // the current system. It is defined as a variable to allow us to mock it out
 // for testing
-var listServices = func() (services []string, err error) {
+var listServices = func() ([]string, error) {
+	return listServicesWithPrefix("")
+}
+
+// listServicesWithPrefix returns the names of the services on the
+// current system that start with prefix. It is defined as a variable to
+// allow us to mock it out for testing.
+var listServicesWithPrefix = func(prefix string) (services []string, err error) {
 	host := syscall.StringToUTF16Ptr(".")
 
 	sc, err := windows.OpenSCManager(host, nil, windows.SC_MANAGER_ALL_ACCESS)
@@ -188,38 +431,89 @@ var listServices = func() (services []string, err error) {
 		return nil, err
 	}
 
-	var needed uint32
-	var returned uint32
-	var resume uint32 = 0
-	var enum []enumService
+	return enumServiceNamesWithPrefix(sc, prefix)
+}
+
+// enumServices enumerates services. It is defined as a variable to allow
+// us to mock it out for testing.
+var enumServices = enumServicesStatus
+
+// enumServicesBufferSize is the size in bytes of the buffer first passed
+// to enumServices. It grows as needed.
+var enumServicesBufferSize = 64 * 1024
+
+// enumServiceEntries returns the first n entries written to buf by
+// enumServices. Each entry is read at its own offset in buf, so that
+// no more of buf is ever addressed than it holds; it is an error for n
+// entries not to fit.
+func enumServiceEntries(buf []byte, n uint32) ([]*enumService, error) {
+	size := unsafe.Sizeof(enumService{})
+	if uintptr(n) > uintptr(len(buf))/size {
+		return nil, errors.Errorf("cannot read %d services from %d byte buffer", n, len(buf))
+	}
+	entries := make([]*enumService, n)
+	for i := range entries {
+		entries[i] = (*enumService)(unsafe.Pointer(&buf[uintptr(i)*size]))
+	}
+	return entries, nil
+}
+
+// enumServiceNames returns the names of all the win32 services known to
+// the service control manager sc. Services may be added while they are
+// being enumerated, so it keeps calling enumServices with the resume
+// handle, growing the buffer whenever it is too small, until the
+// enumeration is complete.
+func enumServiceNames(sc windows.Handle) ([]string, error) {
+	return enumServiceNamesWithPrefix(sc, "")
+}
 
+// enumServiceNamesWithPrefix is like enumServiceNames, but only returns
+// the names starting with prefix. Names are matched before they are
+// converted to strings, so the names of other services are never
+// allocated. The match is case sensitive.
+func enumServiceNamesWithPrefix(sc windows.Handle, prefix string) ([]string, error) {
+	prefix16 := utf16.Encode([]rune(prefix))
+	var (
+		needed   uint32
+		returned uint32
+		resume   uint32
+		names    []string
+	)
+	buf := make([]byte, enumServicesBufferSize)
 	for {
-		var buf [512]enumService
-		err := enumServicesStatus(sc, SC_ENUM_PROCESS_INFO, windows.SERVICE_WIN32,
-			windows.SERVICE_STATE_ALL, uintptr(unsafe.Pointer(&buf[0])), uint32(unsafe.Sizeof(buf)), &needed, &returned, &resume, nil)
-		if err != nil {
-			if err == windows.ERROR_MORE_DATA {
-				enum = append(enum, buf[:returned]...)
-				continue
-			}
+		err := enumServices(sc, SC_ENUM_PROCESS_INFO, windows.SERVICE_WIN32,
+			windows.SERVICE_STATE_ALL, uintptr(unsafe.Pointer(&buf[0])), uint32(len(buf)), &needed, &returned, &resume, nil)
+		if err != nil && err != windows.ERROR_MORE_DATA {
 			return nil, err
 		}
-		enum = append(enum, buf[:returned]...)
-		break
-	}
-
-	services = make([]string, len(enum))
-	for i, v := range enum {
-		services[i] = v.Name()
+		// The names point into buf, so read them before it is reused.
+		entries, parseErr := enumServiceEntries(buf, returned)
+		if parseErr != nil {
+			return nil, errors.Trace(parseErr)
+		}
+		for _, entry := range entries {
+			if entry.hasPrefix(prefix16) {
+				names = append(names, entry.Name())
+			}
+		}
+		if err == nil {
+			return names, nil
+		}
+		if int(needed) > len(buf) {
+			buf = make([]byte, needed)
+		} else if returned == 0 {
+			return nil, errors.Errorf("cannot enumerate services: no progress with %d byte buffer", len(buf))
+		}
 	}
-	return services, nil
 }
 
-// SvcManager implements ServiceManager interface
+// SvcManager implements ServiceManager interface. It holds no state
+// about individual services: each operation opens, and closes, its own
+// handle to the service it acts on, so a SvcManager is safe to use from
+// multiple goroutines.
 type SvcManager struct {
-	svc         windowsService
-	mgr         windowsManager
-	serviceConf common.Conf
+	mgr   windowsManager
+	clock clock.Clock
 }
 
 func (s *SvcManager) getService(name string) (windowsService, error) {
@@ -288,14 +582,12 @@ func (s *SvcManager) escapeExecPath(exePath string, args []string) string {
 func (s *SvcManager) Exists(name string, conf common.Conf) (bool, error) {
 	// We escape and compose BinaryPathName the same way mgr.CreateService does.
 	execStart := s.escapeExecPath(conf.ServiceBinary, conf.ServiceArgs)
+	start, delayed := startType(conf.StartType)
 	cfg := mgr.Config{
-		// make this service dependent on WMI service. WMI is needed for almost
-		// all installers to work properly, and is needed for all of the advanced windows
-		// instrumentation bits (powershell included). Juju agents must start after this
-		// service to ensure hooks run properly.
-		Dependencies:     []string{"Winmgmt"},
-		StartType:        mgr.StartAutomatic,
-		DisplayName:      conf.Desc,
+		Dependencies:     serviceDependencies(conf.Dependencies),
+		ErrorControl:     mgr.ErrorSevere,
+		StartType:        start,
+		DisplayName:      displayName(conf),
 		ServiceStartName: jujudUser,
 		BinaryPathName:   execStart,
 	}
@@ -304,10 +596,53 @@ func (s *SvcManager) Exists(name string, conf common.Conf) (bool, error) {
 		return false, err
 	}
 
-	if reflect.DeepEqual(cfg, currentConfig) {
+	if !reflect.DeepEqual(cfg, comparableConfig(currentConfig)) {
+		return false, nil
+	}
+	triggered, err := s.networkTriggerConfigured(name)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if triggered != conf.StartOnNetworkAvailable {
+		return false, nil
+	}
+	delayedConfigured, err := s.delayedAutoStart(name)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if delayedConfigured != delayed {
+		return false, nil
+	}
+	envConfigured, err := s.environmentConfigured(name, conf)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if !envConfigured {
+		return false, nil
+	}
+	// Recovery is only compared when explicitly configured, as services
+	// created before it was configurable have the default failure actions.
+	if conf.Recovery == nil {
 		return true, nil
 	}
-	return false, nil
+	recovered, err := s.recoveryConfigured(name, conf.Recovery)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return recovered, nil
+}
+
+// comparableConfig returns a copy of cfg holding only the fields that
+// juju manages, leaving out the password and any values the OS fills in.
+func comparableConfig(cfg mgr.Config) mgr.Config {
+	return mgr.Config{
+		Dependencies:     cfg.Dependencies,
+		ErrorControl:     cfg.ErrorControl,
+		StartType:        cfg.StartType,
+		DisplayName:      cfg.DisplayName,
+		ServiceStartName: cfg.ServiceStartName,
+		BinaryPathName:   cfg.BinaryPathName,
+	}
 }
 
 // Stop stops a service.
@@ -331,8 +666,189 @@ func (s *SvcManager) Stop(name string) error {
 	return nil
 }
 
+// StartMany starts the named services over a single connection to the
+// service control manager. It returns the result of starting each
+// service; a failure to start one does not stop the others from being
+// started.
+func (s *SvcManager) StartMany(names []string) map[string]error {
+	return s.forEachService(names, startService)
+}
+
+// StopMany stops the named services over a single connection to the
+// service control manager. It returns the result of stopping each
+// service; a failure to stop one does not stop the others from being
+// stopped.
+func (s *SvcManager) StopMany(names []string) map[string]error {
+	return s.forEachService(names, stopService)
+}
+
+// forEachService opens each named service over a single connection to
+// the service control manager and calls f with it, returning the
+// results keyed by service name.
+func (s *SvcManager) forEachService(names []string, f func(windowsService) error) map[string]error {
+	results := make(map[string]error)
+	conn, err := s.mgr.Connect()
+	if err != nil {
+		err = errors.Annotate(err, "cannot connect to service manager")
+		for _, name := range names {
+			results[name] = err
+		}
+		return results
+	}
+	defer conn.Disconnect()
+	for _, name := range names {
+		if _, ok := results[name]; ok {
+			continue
+		}
+		results[name] = func() error {
+			service, err := conn.OpenService(name)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			defer service.Close()
+			return f(service)
+		}()
+	}
+	return results
+}
+
+// startService starts the given service unless it is already running.
+func startService(service windowsService) error {
+	status, err := service.Query()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if status.State == svc.Running {
+		return nil
+	}
+	return errors.Trace(service.Start())
+}
+
+// stopService stops the given service if it is running.
+func stopService(service windowsService) error {
+	status, err := service.Query()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if status.State != svc.Running {
+		return nil
+	}
+	_, err = service.Control(svc.Stop)
+	return errors.Trace(err)
+}
+
+// Pause pauses a running service.
+func (s *SvcManager) Pause(name string) error {
+	return s.pauseOrContinue(name, svc.Pause, svc.Paused)
+}
+
+// Continue resumes a paused service.
+func (s *SvcManager) Continue(name string) error {
+	return s.pauseOrContinue(name, svc.Continue, svc.Running)
+}
+
+// pauseOrContinue sends cmd, which must be svc.Pause or svc.Continue,
+// to the named service unless it is already in the target state.
+func (s *SvcManager) pauseOrContinue(name string, cmd svc.Cmd, target svc.State) error {
+	service, err := s.getService(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer service.Close()
+	status, err := service.Query()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if status.State == target {
+		return nil
+	}
+	if status.Accepts&svc.AcceptPauseAndContinue == 0 {
+		return errors.NotSupportedf("pausing and continuing service %q", name)
+	}
+	_, err = service.Control(cmd)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// deleteStopTimeout is how long Delete waits for a service that is
+// stopping to stop.
+var deleteStopTimeout = 30 * time.Second
+
+// stopPollInterval is how often StopWait checks whether a service
+// has stopped.
+var stopPollInterval = 250 * time.Millisecond
+
+// StopWait stops a service and waits up to timeout for it to report
+// that it has stopped. A service that is still starting is stopped
+// once it is running.
+func (s *SvcManager) StopWait(name string, timeout time.Duration) error {
+	deadline := s.clock.After(timeout)
+	for {
+		status, err := s.status(name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		switch status {
+		case svc.Stopped:
+			return nil
+		case svc.Running:
+			if err := s.Stop(name); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		select {
+		case <-deadline:
+			return errors.Errorf("timed out after %v waiting for service %q to stop (state %q)", timeout, name, serviceStates[status])
+		case <-s.clock.After(stopPollInterval):
+		}
+	}
+}
+
+// flapPollInterval is how often IsFlapping samples the state of a service.
+var flapPollInterval = time.Second
+
+// IsFlapping samples the state of a service over window, and reports
+// whether it stopped running more than threshold times. This is what a
+// crashing service restarted by its recovery actions looks like.
+func (s *SvcManager) IsFlapping(name string, window time.Duration, threshold int) (bool, error) {
+	deadline := s.clock.After(window)
+	var (
+		wasRunning bool
+		stops      int
+	)
+	for {
+		status, err := s.status(name)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		running := status == svc.Running
+		if wasRunning && !running {
+			stops++
+		}
+		wasRunning = running
+		select {
+		case <-deadline:
+			if stops > threshold {
+				logger.Warningf("service %q stopped %d times in %v", name, stops, window)
+				return true, nil
+			}
+			return false, nil
+		case <-s.clock.After(flapPollInterval):
+		}
+	}
+}
+
 // Delete deletes a service.
+//
+// The SCM only removes a service once it has stopped and every handle
+// to it is closed, so a service still stopping is first given up to
+// deleteStopTimeout to stop. If it does not, or the service was already
+// marked for deletion, the returned error's cause is
+// ErrServiceMarkedForDelete.
 func (s *SvcManager) Delete(name string) error {
+	defer installedServices.invalidate()
 	exists, err := s.exists(name)
 	if err != nil {
 		return err
@@ -345,17 +861,34 @@ func (s *SvcManager) Delete(name string) error {
 		return errors.Trace(err)
 	}
 	defer service.Close()
+	status, err := service.Query()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var stopErr error
+	if status.State == svc.StopPending {
+		stopErr = s.StopWait(name, deleteStopTimeout)
+		if stopErr != nil {
+			logger.Warningf("deleting service %q before it stopped: %v", name, stopErr)
+		}
+	}
 	err = service.Delete()
 	if err == c_ERROR_SERVICE_DOES_NOT_EXIST {
 		return nil
+	} else if err == c_ERROR_SERVICE_MARKED_FOR_DELETE {
+		return errors.Annotatef(errors.Wrap(err, ErrServiceMarkedForDelete), "cannot delete service %q", name)
 	} else if err != nil {
 		return errors.Trace(err)
 	}
+	if stopErr != nil {
+		return errors.Annotatef(errors.Wrap(stopErr, ErrServiceMarkedForDelete), "service %q did not stop within %v", name, deleteStopTimeout)
+	}
 	return nil
 }
 
 // Create creates a service with the given config.
 func (s *SvcManager) Create(name string, conf common.Conf) error {
+	defer installedServices.invalidate()
 	serviceStartName := "LocalSystem"
 	var passwd string
 	hostSeries, err := series.HostSeries()
@@ -367,43 +900,320 @@ func (s *SvcManager) Create(name string, conf common.Conf) error {
 		if err != nil {
 			return errors.Trace(err)
 		}
+		err = s.withLogonRetry("validate jujud password", func() error {
+			return validateJujudPassword(password)
+		})
+		if err != nil {
+			return errors.Annotatef(serviceLogonError(err), "cannot log on as %q with the reset jujud password", jujudUser)
+		}
 		passwd = password
 		serviceStartName = jujudUser
 	}
+	start, delayed := startType(conf.StartType)
 	cfg := mgr.Config{
-		Dependencies:     []string{"Winmgmt"},
+		Dependencies:     serviceDependencies(conf.Dependencies),
 		ErrorControl:     mgr.ErrorSevere,
-		StartType:        mgr.StartAutomatic,
-		DisplayName:      conf.Desc,
+		StartType:        start,
+		DisplayName:      displayName(conf),
 		ServiceStartName: serviceStartName,
 		Password:         passwd,
 	}
 	// mgr.CreateService actually does correct argument escaping itself. There is no
 	// need for quoted strings of any kind passed to this function. It takes in
 	// a binary name, and an array or arguments.
-	service, err := s.mgr.CreateService(name, conf.ServiceBinary, cfg, conf.ServiceArgs...)
+	var service windowsService
+	err = s.withLogonRetry(fmt.Sprintf("create service %q", name), func() error {
+		var err error
+		service, err = s.mgr.CreateService(name, conf.ServiceBinary, cfg, conf.ServiceArgs...)
+		return err
+	})
+	if err != nil {
+		return errors.Trace(serviceLogonError(err))
+	}
+	defer service.Close()
+	err = s.ensureRecovery(name, conf.Recovery)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if env := serviceEnvironment(conf); len(env) > 0 {
+		err = s.mgr.SetEnvironment(name, env)
+		if err != nil {
+			return errors.Annotate(err, "cannot set environment")
+		}
+	}
+	if conf.StartOnNetworkAvailable {
+		err = s.ensureNetworkTrigger(name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if delayed {
+		err = s.ensureDelayedAutoStart(name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// Update changes the config of the existing service to match conf in
+// place, rather than deleting and creating it again, so the service
+// keeps its SID and recovery history and is not unavailable meanwhile.
+// The account the service runs as and its password are not changed.
+// Changes to the binary path take effect when the service is next
+// started.
+func (s *SvcManager) Update(name string, conf common.Conf) error {
+	cfg, err := s.Config(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	start, delayed := startType(conf.StartType)
+	// We escape and compose BinaryPathName the same way mgr.CreateService does.
+	cfg.BinaryPathName = s.escapeExecPath(conf.ServiceBinary, conf.ServiceArgs)
+	cfg.DisplayName = displayName(conf)
+	cfg.StartType = start
+	cfg.ErrorControl = mgr.ErrorSevere
+	cfg.Dependencies = serviceDependencies(conf.Dependencies)
+	// The password is left as Config returned it: the OS never returns
+	// it, and an empty password leaves it unchanged.
+	service, err := s.getService(name)
 	if err != nil {
 		return errors.Trace(err)
 	}
 	defer service.Close()
-	err = s.ensureRestartOnFailure(name)
+	if err := service.UpdateConfig(cfg); err != nil {
+		return errors.Annotatef(err, "cannot update service %q", name)
+	}
+
+	// Recovery is only changed when explicitly configured, as for
+	// Exists.
+	if conf.Recovery != nil {
+		if err := s.ensureRecovery(name, conf.Recovery); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	triggered, err := s.networkTriggerConfigured(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	switch {
+	case conf.StartOnNetworkAvailable && !triggered:
+		err = s.ensureNetworkTrigger(name)
+	case !conf.StartOnNetworkAvailable && triggered:
+		err = s.removeTriggers(name)
+	}
 	if err != nil {
 		return errors.Trace(err)
 	}
+	delayedConfigured, err := s.delayedAutoStart(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if delayedConfigured != delayed {
+		if err := s.setDelayedAutoStart(name, delayed); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := s.mgr.SetEnvironment(name, serviceEnvironment(conf)); err != nil {
+		return errors.Annotate(err, "cannot set environment")
+	}
 	return nil
 }
 
+const (
+	// c_ERROR_LOGON_FAILURE is returned by the OS when the user name or
+	// password of the account a service runs as is rejected.
+	// https://msdn.microsoft.com/en-us/library/windows/desktop/ms681385(v=vs.85).aspx
+	c_ERROR_LOGON_FAILURE syscall.Errno = 0x52E
+
+	// c_ERROR_LOGON_NOT_GRANTED is returned by the OS when the account a
+	// service runs as has not been granted the requested logon type.
+	c_ERROR_LOGON_NOT_GRANTED syscall.Errno = 0x564
+
+	// c_ERROR_SERVICE_MARKED_FOR_DELETE is returned by the OS when a
+	// service has already been marked for deletion.
+	c_ERROR_SERVICE_MARKED_FOR_DELETE syscall.Errno = 0x430
+)
+
+var (
+	// logonAttempts is how many times Create tries a call that depends on
+	// the jujud credentials while they are rejected with a logon error.
+	logonAttempts = 5
+
+	// logonRetryDelay is how long Create waits before its first retry;
+	// the delay doubles with each subsequent retry.
+	logonRetryDelay = 2 * time.Second
+)
+
+// isTransientLogonError returns whether err is a logon error that may
+// go away by itself. Right after boot the jujud user profile may not be
+// fully materialized yet, and its credentials are rejected until it is.
+func isTransientLogonError(err error) bool {
+	switch errors.Cause(err) {
+	case c_ERROR_LOGON_FAILURE, c_ERROR_LOGON_NOT_GRANTED:
+		return true
+	}
+	return false
+}
+
+// serviceLogonError returns err, or ErrServiceLogonNotGranted wrapping
+// it if err reports that the jujud user may not log on as a service.
+func serviceLogonError(err error) error {
+	if errors.Cause(err) == c_ERROR_LOGON_NOT_GRANTED {
+		return errors.Wrap(err, ErrServiceLogonNotGranted)
+	}
+	return err
+}
+
+// withLogonRetry calls f, which does what describes, retrying with
+// backoff while it fails with a transient logon error.
+func (s *SvcManager) withLogonRetry(what string, f func() error) error {
+	delay := logonRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || !isTransientLogonError(err) || attempt >= logonAttempts {
+			return err
+		}
+		logger.Warningf("cannot %s (attempt %d of %d), retrying in %v: %v",
+			what, attempt, logonAttempts, delay, err)
+		<-s.clock.After(delay)
+		delay *= 2
+	}
+}
+
+// serviceDependencies returns the services a juju service depends on,
+// in order. Every juju service depends on the WMI service: WMI is needed
+// for almost all installers to work properly, and for all of the
+// advanced windows instrumentation bits (powershell included). Juju
+// agents must start after this service to ensure hooks run properly.
+func serviceDependencies(extra []string) []string {
+	dependencies := []string{"Winmgmt"}
+	for _, dependency := range extra {
+		// Service names are case insensitive.
+		if !strings.EqualFold(dependency, "Winmgmt") {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+	return dependencies
+}
+
+// startType returns the mgr start type matching t, and whether an
+// automatic start should also be delayed.
+func startType(t common.StartType) (uint32, bool) {
+	switch t {
+	case common.StartManual:
+		return mgr.StartManual, false
+	case common.StartDelayed:
+		return mgr.StartAutomatic, true
+	}
+	return mgr.StartAutomatic, false
+}
+
+// serviceEnvironment returns the environment of the service described
+// by conf, as the sorted KEY=value strings the service control manager
+// expects.
+//
+// The SCM has no notion of a working directory, so one set in
+// conf.ExecStartWorkingDir is passed in the environment too, as
+// osenv.JujuServiceWorkingDirEnvKey; jujud changes to that directory
+// when it starts as a service.
+func serviceEnvironment(conf common.Conf) []string {
+	var result []string
+	for key, value := range conf.Env {
+		result = append(result, key+"="+value)
+	}
+	if conf.ExecStartWorkingDir != "" {
+		result = append(result, osenv.JujuServiceWorkingDirEnvKey+"="+conf.ExecStartWorkingDir)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// environmentConfigured returns whether the environment of the service
+// matches that described by conf.
+func (s *SvcManager) environmentConfigured(name string, conf common.Conf) (bool, error) {
+	current, err := s.mgr.Environment(name)
+	if err != nil {
+		return false, errors.Annotate(err, "cannot read environment")
+	}
+	expected := serviceEnvironment(conf)
+	if len(current) == 0 && len(expected) == 0 {
+		return true, nil
+	}
+	sort.Strings(current)
+	return reflect.DeepEqual(current, expected), nil
+}
+
+// serviceStates maps the states reported by the service control
+// manager to State values.
+var serviceStates = map[svc.State]State{
+	svc.Stopped:         StateStopped,
+	svc.StartPending:    StateStartPending,
+	svc.StopPending:     StateStopPending,
+	svc.Running:         StateRunning,
+	svc.ContinuePending: StateContinuePending,
+	svc.PausePending:    StatePausePending,
+	svc.Paused:          StatePaused,
+}
+
+// Status returns the current state of a service.
+func (s *SvcManager) Status(name string) (State, error) {
+	status, err := s.status(name)
+	if err != nil {
+		return StateUnknown, errors.Trace(err)
+	}
+	if state, ok := serviceStates[status]; ok {
+		return state, nil
+	}
+	return StateUnknown, nil
+}
+
 // Running returns the status of a service.
 func (s *SvcManager) Running(name string) (bool, error) {
 	status, err := s.status(name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
-	logger.Infof("Service %q Status %v", name, status)
-	if status == svc.Running {
-		return true, nil
+	if status != svc.Running {
+		logger.Infof("Service %q Status %v", name, status)
+		return false, nil
 	}
-	return false, nil
+	pid, err := s.processID(name)
+	if err != nil {
+		logger.Debugf("cannot get process id of service %q: %v", name, err)
+		logger.Infof("Service %q Status %v", name, status)
+	} else {
+		logger.Infof("Service %q Status %v, PID %d", name, status, pid)
+	}
+	return true, nil
+}
+
+// ProcessInfo returns the id of the process running the named service,
+// which is 0 if the service is not running, and the account the
+// service runs as.
+func (s *SvcManager) ProcessInfo(name string) (pid uint32, account string, err error) {
+	pid, err = s.processID(name)
+	if err != nil {
+		return 0, "", errors.Trace(err)
+	}
+	config, err := s.Config(name)
+	if err != nil {
+		return 0, "", errors.Trace(err)
+	}
+	return pid, config.ServiceStartName, nil
+}
+
+// processID returns the id of the process running the named service.
+func (s *SvcManager) processID(name string) (pid uint32, err error) {
+	err = s.withServiceHandle(name, func(handle windows.Handle) error {
+		status, err := s.mgr.QueryServiceStatusEx(handle)
+		if err != nil {
+			return errors.Annotate(err, "cannot query service process")
+		}
+		pid = status.ProcessId
+		return nil
+	})
+	return pid, errors.Trace(err)
 }
 
 // Config returns the mgr.Config of the service. This config reflects the actual
@@ -424,7 +1234,9 @@ func (s *SvcManager) Config(name string) (mgr.Config, error) {
 	return service.Config()
 }
 
-func (s *SvcManager) ensureRestartOnFailure(name string) (err error) {
+// withServiceHandle calls f with a low level handle to the named
+// service, closing the handle once f returns.
+func (s *SvcManager) withServiceHandle(name string, f func(windows.Handle) error) (err error) {
 	handle, err := s.mgr.GetHandle(name)
 	if err != nil {
 		return errors.Trace(err)
@@ -440,29 +1252,215 @@ func (s *SvcManager) ensureRestartOnFailure(name string) (err error) {
 			}
 		}
 	}()
-	action := serviceAction{
-		actionType: SC_ACTION_RESTART,
-		delay:      5000,
-	}
-	failActions := serviceFailureActions{
-		dwResetPeriod: 5,
-		lpRebootMsg:   nil,
-		lpCommand:     nil,
-		cActions:      1,
-		scAction:      &action,
+	return f(handle)
+}
+
+// recoveryActionTypes maps recovery actions to SC_ACTION types.
+var recoveryActionTypes = map[common.RecoveryAction]uint16{
+	"":                     SC_ACTION_NONE,
+	common.RecoveryNone:    SC_ACTION_NONE,
+	common.RecoveryRestart: SC_ACTION_RESTART,
+	common.RecoveryReboot:  SC_ACTION_REBOOT,
+}
+
+// failureActions returns the reset period, in seconds, and the failure
+// actions the service should be configured with for recovery. When
+// recovery is nil the service is restarted 5 seconds after every failure.
+func failureActions(recovery *common.RecoveryConf) (uint32, []serviceAction) {
+	if recovery == nil {
+		return 5, []serviceAction{{
+			actionType: SC_ACTION_RESTART,
+			delay:      5000,
+		}}
 	}
-	err = WinChangeServiceConfig2(handle, SERVICE_CONFIG_FAILURE_ACTIONS, (*byte)(unsafe.Pointer(&failActions)))
-	if err != nil {
-		return errors.Trace(err)
+	delay := uint32(recovery.Delay / time.Millisecond)
+	// The SCM repeats the last action for all subsequent failures.
+	var actions []serviceAction
+	for _, action := range []common.RecoveryAction{
+		recovery.FirstFailure,
+		recovery.SecondFailure,
+		recovery.SubsequentFailures,
+	} {
+		actions = append(actions, serviceAction{
+			actionType: recoveryActionTypes[action],
+			delay:      delay,
+		})
 	}
-	flag := serviceFailureActionsFlag{
-		failureActionsOnNonCrashFailures: 1,
+	return uint32(recovery.ResetPeriod / time.Second), actions
+}
+
+// ensureRecovery sets the failure actions for the service, as
+// described by recovery.
+func (s *SvcManager) ensureRecovery(name string, recovery *common.RecoveryConf) error {
+	return s.withServiceHandle(name, func(handle windows.Handle) error {
+		resetPeriod, actions := failureActions(recovery)
+		failActions := serviceFailureActions{
+			dwResetPeriod: resetPeriod,
+			lpRebootMsg:   nil,
+			lpCommand:     nil,
+			cActions:      uint32(len(actions)),
+			scAction:      &actions[0],
+		}
+		err := WinChangeServiceConfig2(handle, SERVICE_CONFIG_FAILURE_ACTIONS, (*byte)(unsafe.Pointer(&failActions)))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		flag := serviceFailureActionsFlag{
+			failureActionsOnNonCrashFailures: 1,
+		}
+		err = WinChangeServiceConfig2(handle, SERVICE_CONFIG_FAILURE_ACTIONS_FLAG, (*byte)(unsafe.Pointer(&flag)))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return nil
+	})
+}
+
+// recoveryConfigured returns whether the failure actions of the service
+// match those described by recovery.
+func (s *SvcManager) recoveryConfigured(name string, recovery *common.RecoveryConf) (configured bool, err error) {
+	resetPeriod, expected := failureActions(recovery)
+	err = s.withServiceHandle(name, func(handle windows.Handle) error {
+		buf, err := s.queryServiceConfig2(handle, SERVICE_CONFIG_FAILURE_ACTIONS)
+		if err != nil {
+			return errors.Annotate(err, "cannot query failure actions")
+		}
+		if buf == nil {
+			return nil
+		}
+		info := (*serviceFailureActions)(unsafe.Pointer(&buf[0]))
+		if info.cActions == 0 {
+			return nil
+		}
+		actions := (*[1 << 10]serviceAction)(unsafe.Pointer(info.scAction))[:info.cActions:info.cActions]
+		configured = info.dwResetPeriod == resetPeriod && reflect.DeepEqual(actions, expected)
+		return nil
+	})
+	return configured, errors.Trace(err)
+}
+
+// queryServiceConfig2 returns the optional configuration identified by
+// infoLevel for the service with the given handle. The returned buffer
+// starts with the structure matching infoLevel, or is nil if the
+// service holds no such configuration.
+func (s *SvcManager) queryServiceConfig2(handle windows.Handle, infoLevel uint32) ([]byte, error) {
+	var needed uint32
+	err := s.mgr.QueryServiceConfig2(handle, infoLevel, nil, 0, &needed)
+	if err == nil {
+		return nil, nil
+	} else if err != windows.ERROR_INSUFFICIENT_BUFFER {
+		return nil, errors.Trace(err)
 	}
-	err = WinChangeServiceConfig2(handle, SERVICE_CONFIG_FAILURE_ACTIONS_FLAG, (*byte)(unsafe.Pointer(&flag)))
+	buf := make([]byte, needed)
+	err = s.mgr.QueryServiceConfig2(handle, infoLevel, &buf[0], needed, &needed)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
-	return nil
+	return buf, nil
+}
+
+// ensureDelayedAutoStart makes the automatically started service start
+// after the other automatic services. mgr.Config cannot express this.
+func (s *SvcManager) ensureDelayedAutoStart(name string) error {
+	return s.setDelayedAutoStart(name, true)
+}
+
+// setDelayedAutoStart sets whether the automatically started service
+// starts after the other automatic services.
+func (s *SvcManager) setDelayedAutoStart(name string, delayed bool) error {
+	return s.withServiceHandle(name, func(handle windows.Handle) error {
+		var info serviceDelayedAutoStartInfo
+		if delayed {
+			info.fDelayedAutostart = 1
+		}
+		err := s.mgr.ChangeServiceConfig2(handle, SERVICE_CONFIG_DELAYED_AUTO_START_INFO, (*byte)(unsafe.Pointer(&info)))
+		if err != nil {
+			if delayed {
+				return errors.Annotate(err, "cannot delay automatic start")
+			}
+			return errors.Annotate(err, "cannot undelay automatic start")
+		}
+		return nil
+	})
+}
+
+// delayedAutoStart returns whether the service's automatic start is delayed.
+func (s *SvcManager) delayedAutoStart(name string) (delayed bool, err error) {
+	err = s.withServiceHandle(name, func(handle windows.Handle) error {
+		buf, err := s.queryServiceConfig2(handle, SERVICE_CONFIG_DELAYED_AUTO_START_INFO)
+		if err != nil {
+			return errors.Annotate(err, "cannot query delayed automatic start")
+		}
+		if buf == nil {
+			return nil
+		}
+		info := (*serviceDelayedAutoStartInfo)(unsafe.Pointer(&buf[0]))
+		delayed = info.fDelayedAutostart != 0
+		return nil
+	})
+	return delayed, errors.Trace(err)
+}
+
+// ensureNetworkTrigger makes the service start when the host acquires
+// its first IP address, replacing any other configured triggers.
+func (s *SvcManager) ensureNetworkTrigger(name string) error {
+	return s.withServiceHandle(name, func(handle windows.Handle) error {
+		subtype := NETWORK_MANAGER_FIRST_IP_ADDRESS_ARRIVAL_GUID
+		trigger := serviceTrigger{
+			dwTriggerType:   SERVICE_TRIGGER_TYPE_IP_ADDRESS_AVAILABILITY,
+			dwAction:        SERVICE_TRIGGER_ACTION_SERVICE_START,
+			pTriggerSubtype: &subtype,
+		}
+		info := serviceTriggerInfo{
+			cTriggers: 1,
+			pTriggers: &trigger,
+		}
+		err := s.mgr.ChangeServiceConfig2(handle, SERVICE_CONFIG_TRIGGER_INFO, (*byte)(unsafe.Pointer(&info)))
+		if err != nil {
+			return errors.Annotate(err, "cannot set network trigger")
+		}
+		return nil
+	})
+}
+
+// removeTriggers removes all the start triggers of the service.
+func (s *SvcManager) removeTriggers(name string) error {
+	return s.withServiceHandle(name, func(handle windows.Handle) error {
+		info := serviceTriggerInfo{}
+		err := s.mgr.ChangeServiceConfig2(handle, SERVICE_CONFIG_TRIGGER_INFO, (*byte)(unsafe.Pointer(&info)))
+		if err != nil {
+			return errors.Annotate(err, "cannot remove triggers")
+		}
+		return nil
+	})
+}
+
+// networkTriggerConfigured returns whether the service is configured to
+// start when the host acquires its first IP address.
+func (s *SvcManager) networkTriggerConfigured(name string) (configured bool, err error) {
+	err = s.withServiceHandle(name, func(handle windows.Handle) error {
+		buf, err := s.queryServiceConfig2(handle, SERVICE_CONFIG_TRIGGER_INFO)
+		if err != nil {
+			return errors.Annotate(err, "cannot query triggers")
+		}
+		if buf == nil {
+			return nil
+		}
+		info := (*serviceTriggerInfo)(unsafe.Pointer(&buf[0]))
+		if info.cTriggers == 0 {
+			return nil
+		}
+		triggers := (*[1 << 10]serviceTrigger)(unsafe.Pointer(info.pTriggers))[:info.cTriggers:info.cTriggers]
+		for _, trigger := range triggers {
+			if trigger.dwTriggerType == SERVICE_TRIGGER_TYPE_IP_ADDRESS_AVAILABILITY &&
+				trigger.dwAction == SERVICE_TRIGGER_ACTION_SERVICE_START {
+				configured = true
+				return nil
+			}
+		}
+		return nil
+	})
+	return configured, errors.Trace(err)
 }
 
 // ChangeServicePassword can change the password of a service
@@ -479,21 +1477,34 @@ func (s *SvcManager) ChangeServicePassword(svcName, newPassword string) error {
 		}
 		return errors.Trace(err)
 	}
-	if currentConfig.ServiceStartName == jujudUser {
-		currentConfig.Password = newPassword
-		service, err := s.getService(svcName)
-		if err != nil {
-			return errors.Trace(err)
-		}
-		defer service.Close()
-		err = service.UpdateConfig(currentConfig)
-		if err != nil {
-			return errors.Trace(err)
-		}
+	// An empty password leaves the stored one unchanged.
+	if currentConfig.ServiceStartName != jujudUser || newPassword == "" {
+		return nil
+	}
+	return errors.Trace(s.UpdatePassword(svcName, newPassword))
+}
+
+// UpdatePassword changes the password the service control manager
+// stores for the account the named service runs as, leaving the rest
+// of its config untouched, so that the service need not be reinstalled
+// when the account's password is rotated. The new password is used
+// when the service is next started.
+func (s *SvcManager) UpdatePassword(name, password string) error {
+	if password == "" {
+		return errors.NotValidf("empty password")
 	}
+	passwordp, err := syscall.UTF16PtrFromString(password)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	err = s.withServiceHandle(name, func(handle windows.Handle) error {
+		return s.mgr.ChangeServiceConfig(handle,
+			windows.SERVICE_NO_CHANGE, windows.SERVICE_NO_CHANGE, windows.SERVICE_NO_CHANGE,
+			nil, nil, nil, nil, nil, passwordp, nil)
+	})
+	if err != nil {
+		return errors.Annotatef(err, "cannot update password of service %q", name)
+	}
 	return nil
 }
 
@@ -503,6 +1514,7 @@ var NewServiceManager = func() (ServiceManager, error) {
 		return nil, errors.Trace(err)
 	}
 	return &SvcManager{
-		mgr: m,
+		mgr:   m,
+		clock: clock.WallClock,
 	}, nil
 }
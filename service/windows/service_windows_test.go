@@ -8,13 +8,17 @@ package windows_test
 
 import (
 	"fmt"
+	"runtime"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
 	win "golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/juju/service/common"
@@ -27,8 +31,10 @@ type serviceManagerSuite struct {
 
 	stub       *testing.Stub
 	passwdStub *testing.Stub
+	logonStub  *testing.Stub
 	conn       *windows.StubMgr
 	getPasswd  *windows.StubGetPassword
+	logonUser  *windows.StubLogonUser
 
 	name string
 	conf common.Conf
@@ -48,6 +54,8 @@ func (s *serviceManagerSuite) SetUpTest(c *gc.C) {
 	s.passwdStub = &testing.Stub{}
 	s.conn = windows.PatchMgrConnect(s, s.stub)
 	s.getPasswd = windows.PatchGetPassword(s, s.passwdStub)
+	s.logonStub = &testing.Stub{}
+	s.logonUser = windows.PatchLogonUser(s, s.logonStub)
 	windows.WinChangeServiceConfig2 = func(win.Handle, uint32, *byte) error {
 		return nil
 	}
@@ -145,6 +153,586 @@ func (s *serviceManagerSuite) TestCreateMultipleServices(c *gc.C) {
 	c.Assert(svcs, gc.HasLen, 2)
 }
 
+func (s *serviceManagerSuite) TestCreateValidatesPassword(c *gc.C) {
+	s.getPasswd.SetPasswd("fake")
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, jc.ErrorIsNil)
+	s.logonUser.CheckCalls(c, []testing.StubCall{
+		{"logonUser", []interface{}{"jujud", "."}},
+	})
+}
+
+func (s *serviceManagerSuite) TestCreatePasswordRejected(c *gc.C) {
+	s.PatchValue(windows.LogonAttempts, 2)
+	s.PatchValue(windows.LogonRetryDelay, time.Millisecond)
+	s.logonStub.SetErrors(windows.ERROR_LOGON_FAILURE, windows.ERROR_LOGON_FAILURE)
+
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.ErrorMatches, `cannot log on as ".\\\\jujud" with the reset jujud password: .*`)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ERROR_LOGON_FAILURE)
+	s.logonUser.CheckCallNames(c, "logonUser", "logonUser")
+	// The service is never created with the bad password.
+	c.Assert(s.createServiceCalls(), gc.Equals, 0)
+	c.Assert(s.conn.Exists(s.name), jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestCreatePasswordValidationError(c *gc.C) {
+	s.logonStub.SetErrors(syscall.ERROR_ACCESS_DENIED)
+
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(errors.Cause(err), gc.Equals, syscall.ERROR_ACCESS_DENIED)
+	s.logonUser.CheckCallNames(c, "logonUser")
+	c.Assert(s.conn.Exists(s.name), jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) createServiceCalls() int {
+	var calls int
+	for _, call := range s.stub.Calls() {
+		if call.FuncName == "CreateService" {
+			calls++
+		}
+	}
+	return calls
+}
+
+func (s *serviceManagerSuite) TestCreateRetriesTransientLogonErrors(c *gc.C) {
+	s.PatchValue(windows.LogonRetryDelay, time.Millisecond)
+	s.stub.SetErrors(windows.ERROR_LOGON_FAILURE, windows.ERROR_LOGON_NOT_GRANTED)
+
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.createServiceCalls(), gc.Equals, 3)
+	c.Assert(s.conn.Exists(s.name), jc.IsTrue)
+	// The password is only fetched once.
+	c.Assert(s.getPasswd.Calls(), gc.HasLen, 1)
+}
+
+func (s *serviceManagerSuite) TestCreateRetriesBounded(c *gc.C) {
+	s.PatchValue(windows.LogonAttempts, 3)
+	s.PatchValue(windows.LogonRetryDelay, time.Millisecond)
+	s.stub.SetErrors(
+		windows.ERROR_LOGON_FAILURE,
+		windows.ERROR_LOGON_FAILURE,
+		windows.ERROR_LOGON_FAILURE,
+	)
+
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ERROR_LOGON_FAILURE)
+	c.Assert(s.createServiceCalls(), gc.Equals, 3)
+	c.Assert(s.conn.Exists(s.name), jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestCreateLogonNotGranted(c *gc.C) {
+	s.PatchValue(windows.LogonAttempts, 2)
+	s.PatchValue(windows.LogonRetryDelay, time.Millisecond)
+	s.stub.SetErrors(windows.ERROR_LOGON_NOT_GRANTED, windows.ERROR_LOGON_NOT_GRANTED)
+
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ErrServiceLogonNotGranted)
+	c.Assert(err, gc.ErrorMatches, `the jujud user has not been granted the "Log on as a service" right .*`)
+	c.Assert(s.createServiceCalls(), gc.Equals, 2)
+	c.Assert(s.conn.Exists(s.name), jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestCreatePasswordLogonNotGranted(c *gc.C) {
+	s.PatchValue(windows.LogonAttempts, 1)
+	s.logonStub.SetErrors(windows.ERROR_LOGON_NOT_GRANTED)
+
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ErrServiceLogonNotGranted)
+	c.Assert(err, gc.ErrorMatches, `cannot log on as ".\\\\jujud" with the reset jujud password: the jujud user has not been granted .*`)
+	c.Assert(s.createServiceCalls(), gc.Equals, 0)
+}
+
+func (s *serviceManagerSuite) TestCreateDoesNotRetryPermanentErrors(c *gc.C) {
+	s.PatchValue(windows.LogonRetryDelay, time.Millisecond)
+	s.stub.SetErrors(syscall.ERROR_ACCESS_DENIED)
+
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(errors.Cause(err), gc.Equals, syscall.ERROR_ACCESS_DENIED)
+	c.Assert(s.createServiceCalls(), gc.Equals, 1)
+	c.Assert(s.conn.Exists(s.name), jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestCreateRetryBackoff(c *gc.C) {
+	clock := testing.NewClock(time.Now())
+	windows.SetClock(s.mgr, clock)
+	s.stub.SetErrors(windows.ERROR_LOGON_FAILURE, windows.ERROR_LOGON_FAILURE)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- s.mgr.Create(s.name, s.conf)
+	}()
+
+	delay := *windows.LogonRetryDelay
+	waitAlarms(c, clock, 1)
+	clock.Advance(delay)
+	waitAlarms(c, clock, 1)
+	// The delay doubles.
+	clock.Advance(2*delay - time.Nanosecond)
+	select {
+	case err := <-result:
+		c.Fatalf("Create returned early: %v", err)
+	case <-time.After(coretesting.ShortWait):
+	}
+	clock.Advance(time.Nanosecond)
+	c.Assert(waitResult(c, result), jc.ErrorIsNil)
+	c.Assert(s.createServiceCalls(), gc.Equals, 3)
+}
+
+func (s *serviceManagerSuite) TestExists(c *gc.C) {
+	s.getPasswd.SetPasswd("fake")
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	exists, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+}
+
+func (s *serviceManagerSuite) TestExistsIgnoresPassword(c *gc.C) {
+	s.getPasswd.SetPasswd("fake")
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.getPasswd.Calls(), gc.HasLen, 1)
+
+	err = s.mgr.ChangeServicePassword(s.name, "obviously-better-password")
+	c.Assert(err, gc.IsNil)
+
+	exists, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+	// The password was never needed to check the config.
+	c.Assert(s.getPasswd.Calls(), gc.HasLen, 1)
+}
+
+func (s *serviceManagerSuite) TestExistsDetectsDrift(c *gc.C) {
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	conf := s.conf
+	conf.Desc = "a different description"
+	exists, err := s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+
+	conf = s.conf
+	conf.ServiceBinary = s.execPath
+	conf.ServiceArgs = []string{"--debug"}
+	exists, err = s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestCreateDisplayNameTemplate(c *gc.C) {
+	s.conf.DisplayNameTemplate = "juju {unit} in {model}"
+	s.conf.Unit = "mysql/0"
+	s.conf.Model = "prod"
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	m, ok := s.mgr.(*windows.SvcManager)
+	c.Assert(ok, jc.IsTrue)
+	cfg, err := m.Config(s.name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.DisplayName, gc.Equals, "juju mysql/0 in prod")
+
+	exists, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+
+	// The rendered name is compared, so a different unit or model
+	// is drift.
+	conf := s.conf
+	conf.Model = "staging"
+	exists, err = s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+
+	// Without a template the description is displayed.
+	conf = s.conf
+	conf.DisplayNameTemplate = ""
+	exists, err = s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestExistsInexistent(c *gc.C) {
+	_, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ERROR_SERVICE_DOES_NOT_EXIST)
+}
+
+func (s *serviceManagerSuite) TestCreateNetworkTrigger(c *gc.C) {
+	s.conf.StartOnNetworkAvailable = true
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	s.stub.CheckCallNames(c,
+		"CreateService",
+		"GetHandle", "CloseHandle",
+		"GetHandle", "ChangeServiceConfig2", "CloseHandle",
+		"Close",
+	)
+	s.stub.CheckCall(c, 4, "ChangeServiceConfig2", uint32(windows.SERVICE_CONFIG_TRIGGER_INFO))
+
+	exists, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+}
+
+func (s *serviceManagerSuite) TestCreateNoNetworkTriggerByDefault(c *gc.C) {
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	s.stub.CheckCallNames(c, "CreateService", "GetHandle", "CloseHandle", "Close")
+
+	conf := s.conf
+	conf.StartOnNetworkAvailable = true
+	exists, err := s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestExistsDetectsNetworkTriggerDrift(c *gc.C) {
+	conf := s.conf
+	conf.StartOnNetworkAvailable = true
+	err := s.mgr.Create(s.name, conf)
+	c.Assert(err, gc.IsNil)
+
+	exists, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestCreateNetworkTriggerError(c *gc.C) {
+	s.conf.StartOnNetworkAvailable = true
+	s.stub.SetErrors(nil, nil, nil, nil, errors.New("zoinks"))
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.ErrorMatches, "cannot set network trigger: zoinks")
+}
+
+func (s *serviceManagerSuite) TestCreateRecovery(c *gc.C) {
+	windows.WinChangeServiceConfig2 = s.conn.ChangeServiceConfig2
+	s.conf.Recovery = &common.RecoveryConf{
+		FirstFailure:       common.RecoveryRestart,
+		SecondFailure:      common.RecoveryRestart,
+		SubsequentFailures: common.RecoveryNone,
+		Delay:              time.Minute,
+		ResetPeriod:        24 * time.Hour,
+	}
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	s.stub.CheckCallNames(c,
+		"CreateService",
+		"GetHandle", "ChangeServiceConfig2", "ChangeServiceConfig2", "CloseHandle",
+		"Close",
+	)
+	s.stub.CheckCall(c, 2, "ChangeServiceConfig2", uint32(windows.SERVICE_CONFIG_FAILURE_ACTIONS))
+	s.stub.CheckCall(c, 3, "ChangeServiceConfig2", uint32(windows.SERVICE_CONFIG_FAILURE_ACTIONS_FLAG))
+
+	exists, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+}
+
+func (s *serviceManagerSuite) TestExistsDetectsRecoveryDrift(c *gc.C) {
+	windows.WinChangeServiceConfig2 = s.conn.ChangeServiceConfig2
+	s.conf.Recovery = &common.RecoveryConf{
+		FirstFailure:       common.RecoveryRestart,
+		SecondFailure:      common.RecoveryRestart,
+		SubsequentFailures: common.RecoveryRestart,
+		Delay:              time.Minute,
+		ResetPeriod:        time.Hour,
+	}
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	conf := s.conf
+	recovery := *s.conf.Recovery
+	recovery.SubsequentFailures = common.RecoveryReboot
+	conf.Recovery = &recovery
+	exists, err := s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+
+	recovery = *s.conf.Recovery
+	recovery.ResetPeriod = 2 * time.Hour
+	exists, err = s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestExistsDefaultRecovery(c *gc.C) {
+	windows.WinChangeServiceConfig2 = s.conn.ChangeServiceConfig2
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	exists, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+
+	// The default restarts on every failure, so it differs from
+	// an explicit recovery configuration that gives up.
+	conf := s.conf
+	conf.Recovery = &common.RecoveryConf{
+		FirstFailure: common.RecoveryRestart,
+		Delay:        5 * time.Second,
+		ResetPeriod:  5 * time.Second,
+	}
+	exists, err = s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestCreateStartTypes(c *gc.C) {
+	for _, test := range []struct {
+		startType common.StartType
+		expected  uint32
+	}{
+		{"", mgr.StartAutomatic},
+		{common.StartAutomatic, mgr.StartAutomatic},
+		{common.StartManual, mgr.StartManual},
+		{common.StartDelayed, mgr.StartAutomatic},
+	} {
+		c.Logf("start type %q", test.startType)
+		s.conn.Clear()
+		s.stub.ResetCalls()
+		conf := s.conf
+		conf.StartType = test.startType
+		err := s.mgr.Create(s.name, conf)
+		c.Assert(err, gc.IsNil)
+
+		cfg, err := s.mgr.Config(s.name)
+		c.Assert(err, gc.IsNil)
+		c.Assert(cfg.StartType, gc.Equals, test.expected)
+
+		exists, err := s.mgr.Exists(s.name, conf)
+		c.Assert(err, gc.IsNil)
+		c.Assert(exists, jc.IsTrue)
+	}
+}
+
+func (s *serviceManagerSuite) TestCreateDelayedAutoStart(c *gc.C) {
+	s.conf.StartType = common.StartDelayed
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	s.stub.CheckCallNames(c,
+		"CreateService",
+		"GetHandle", "CloseHandle",
+		"GetHandle", "ChangeServiceConfig2", "CloseHandle",
+		"Close",
+	)
+	s.stub.CheckCall(c, 4, "ChangeServiceConfig2", uint32(windows.SERVICE_CONFIG_DELAYED_AUTO_START_INFO))
+}
+
+func (s *serviceManagerSuite) TestExistsDetectsStartTypeDrift(c *gc.C) {
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	for _, startType := range []common.StartType{common.StartManual, common.StartDelayed} {
+		conf := s.conf
+		conf.StartType = startType
+		exists, err := s.mgr.Exists(s.name, conf)
+		c.Assert(err, gc.IsNil)
+		c.Assert(exists, jc.IsFalse)
+	}
+}
+
+func (s *serviceManagerSuite) TestCreateDependencies(c *gc.C) {
+	s.conf.Dependencies = []string{"MSSQLSERVER", "winmgmt", "W3SVC"}
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	cfg, err := s.mgr.Config(s.name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Dependencies, jc.DeepEquals, []string{"Winmgmt", "MSSQLSERVER", "W3SVC"})
+
+	exists, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+}
+
+func (s *serviceManagerSuite) TestCreateDefaultDependencies(c *gc.C) {
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	cfg, err := s.mgr.Config(s.name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Dependencies, jc.DeepEquals, []string{"Winmgmt"})
+}
+
+func (s *serviceManagerSuite) TestExistsDependencyOrderSignificant(c *gc.C) {
+	s.conf.Dependencies = []string{"MSSQLSERVER", "W3SVC"}
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	conf := s.conf
+	conf.Dependencies = []string{"W3SVC", "MSSQLSERVER"}
+	exists, err := s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+
+	conf.Dependencies = []string{"MSSQLSERVER"}
+	exists, err = s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestUpdate(c *gc.C) {
+	s.getPasswd.SetPasswd("fake")
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	created := windows.Services[s.name]
+
+	conf := s.conf
+	conf.Desc = "a different description"
+	conf.ServiceBinary = s.execPath
+	conf.ServiceArgs = []string{"--debug"}
+	conf.StartType = common.StartDelayed
+	conf.StartOnNetworkAvailable = true
+	conf.Dependencies = []string{"W3SVC"}
+	exists, err := s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsFalse)
+
+	s.stub.ResetCalls()
+	err = s.mgr.Update(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	for _, call := range s.stub.Calls() {
+		c.Check(call.FuncName, gc.Not(gc.Equals), "CreateService")
+		c.Check(call.FuncName, gc.Not(gc.Equals), "Control")
+	}
+
+	// The service was changed in place.
+	c.Assert(windows.Services[s.name], gc.Equals, created)
+	exists, err = s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+
+	cfg, err := s.mgr.(*windows.SvcManager).Config(s.name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.ServiceStartName, gc.Equals, windows.JujudUser)
+	c.Assert(cfg.Password, gc.Equals, "fake")
+	c.Assert(s.getPasswd.Calls(), gc.HasLen, 1)
+}
+
+func (s *serviceManagerSuite) TestUpdateRemovesNetworkTriggerAndDelay(c *gc.C) {
+	conf := s.conf
+	conf.StartType = common.StartDelayed
+	conf.StartOnNetworkAvailable = true
+	err := s.mgr.Create(s.name, conf)
+	c.Assert(err, gc.IsNil)
+
+	err = s.mgr.Update(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	exists, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+}
+
+func (s *serviceManagerSuite) TestUpdateRecovery(c *gc.C) {
+	windows.WinChangeServiceConfig2 = s.conn.ChangeServiceConfig2
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	conf := s.conf
+	conf.Recovery = &common.RecoveryConf{
+		FirstFailure: common.RecoveryRestart,
+		Delay:        time.Minute,
+		ResetPeriod:  time.Hour,
+	}
+	err = s.mgr.Update(s.name, conf)
+	c.Assert(err, gc.IsNil)
+
+	exists, err := s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+}
+
+func (s *serviceManagerSuite) TestUpdateInexistent(c *gc.C) {
+	err := s.mgr.Update(s.name, s.conf)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ERROR_SERVICE_DOES_NOT_EXIST)
+}
+
+func (s *serviceManagerSuite) TestCreateEnvironment(c *gc.C) {
+	s.conf.Env = map[string]string{"B": "2", "A": "1"}
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	s.stub.CheckCallNames(c,
+		"CreateService",
+		"GetHandle", "CloseHandle",
+		"SetEnvironment",
+		"Close",
+	)
+	s.stub.CheckCall(c, 3, "SetEnvironment", s.name, []string{"A=1", "B=2"})
+
+	exists, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+}
+
+func (s *serviceManagerSuite) TestExistsDetectsEnvironmentDrift(c *gc.C) {
+	s.conf.Env = map[string]string{"A": "1"}
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	for _, env := range []map[string]string{
+		nil,
+		{"A": "2"},
+		{"A": "1", "B": "2"},
+	} {
+		conf := s.conf
+		conf.Env = env
+		exists, err := s.mgr.Exists(s.name, conf)
+		c.Assert(err, gc.IsNil)
+		c.Assert(exists, jc.IsFalse)
+	}
+}
+
+func (s *serviceManagerSuite) TestCreateEnvironmentError(c *gc.C) {
+	s.conf.Env = map[string]string{"A": "1"}
+	s.stub.SetErrors(nil, nil, nil, errors.New("zoinks"))
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.ErrorMatches, "cannot set environment: zoinks")
+}
+
+func (s *serviceManagerSuite) TestCreateWorkingDir(c *gc.C) {
+	s.conf.Env = map[string]string{"A": "1"}
+	s.conf.ExecStartWorkingDir = `C:\Juju\lib\juju`
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	s.stub.CheckCall(c, 3, "SetEnvironment", s.name, []string{
+		"A=1",
+		`JUJU_SERVICE_WORKING_DIR=C:\Juju\lib\juju`,
+	})
+
+	exists, err := s.mgr.Exists(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+
+	for _, dir := range []string{"", `C:\Juju`} {
+		conf := s.conf
+		conf.ExecStartWorkingDir = dir
+		exists, err := s.mgr.Exists(s.name, conf)
+		c.Assert(err, gc.IsNil)
+		c.Assert(exists, jc.IsFalse)
+	}
+}
+
+func (s *serviceManagerSuite) TestUpdateEnvironment(c *gc.C) {
+	s.conf.Env = map[string]string{"A": "1"}
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+
+	conf := s.conf
+	conf.Env = nil
+	err = s.mgr.Update(s.name, conf)
+	c.Assert(err, gc.IsNil)
+
+	exists, err := s.mgr.Exists(s.name, conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(exists, jc.IsTrue)
+}
+
 func (s *serviceManagerSuite) TestStart(c *gc.C) {
 	windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: svc.Stopped})
 
@@ -188,6 +776,310 @@ func (s *serviceManagerSuite) TestStartStop(c *gc.C) {
 	c.Assert(running, jc.IsFalse)
 }
 
+func (s *serviceManagerSuite) TestPauseContinue(c *gc.C) {
+	windows.AddService(s.name, s.execPath, s.stub, svc.Status{
+		State:   svc.Running,
+		Accepts: svc.AcceptStop | svc.AcceptPauseAndContinue,
+	})
+
+	err := s.mgr.Pause(s.name)
+	c.Assert(err, jc.ErrorIsNil)
+	state, err := s.mgr.Status(s.name)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(state, gc.Equals, windows.StatePaused)
+
+	err = s.mgr.Continue(s.name)
+	c.Assert(err, jc.ErrorIsNil)
+	state, err = s.mgr.Status(s.name)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(state, gc.Equals, windows.StateRunning)
+}
+
+func (s *serviceManagerSuite) TestPauseTwice(c *gc.C) {
+	windows.AddService(s.name, s.execPath, s.stub, svc.Status{
+		State:   svc.Paused,
+		Accepts: svc.AcceptPauseAndContinue,
+	})
+
+	err := s.mgr.Pause(s.name)
+	c.Assert(err, jc.ErrorIsNil)
+	s.stub.CheckCallNames(c, "OpenService", "Query", "Close")
+}
+
+func (s *serviceManagerSuite) TestPauseNotAccepted(c *gc.C) {
+	windows.AddService(s.name, s.execPath, s.stub, svc.Status{
+		State:   svc.Running,
+		Accepts: svc.AcceptStop,
+	})
+
+	err := s.mgr.Pause(s.name)
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+	c.Assert(err, gc.ErrorMatches, `pausing and continuing service "machine-1" not supported`)
+	state, err := s.mgr.Status(s.name)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(state, gc.Equals, windows.StateRunning)
+}
+
+func (s *serviceManagerSuite) TestContinueNotAccepted(c *gc.C) {
+	windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: svc.Paused})
+
+	err := s.mgr.Continue(s.name)
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+}
+
+func (s *serviceManagerSuite) TestPauseInexistent(c *gc.C) {
+	err := s.mgr.Pause(s.name)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ERROR_SERVICE_DOES_NOT_EXIST)
+}
+
+func (s *serviceManagerSuite) TestStatus(c *gc.C) {
+	for _, test := range []struct {
+		state    svc.State
+		expected windows.State
+	}{
+		{svc.Stopped, windows.StateStopped},
+		{svc.StartPending, windows.StateStartPending},
+		{svc.StopPending, windows.StateStopPending},
+		{svc.Running, windows.StateRunning},
+		{svc.ContinuePending, windows.StateContinuePending},
+		{svc.PausePending, windows.StatePausePending},
+		{svc.Paused, windows.StatePaused},
+		{svc.State(42), windows.StateUnknown},
+	} {
+		c.Logf("state %d", test.state)
+		s.conn.Clear()
+		windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: test.state})
+
+		state, err := s.mgr.Status(s.name)
+		c.Assert(err, gc.IsNil)
+		c.Assert(state, gc.Equals, test.expected)
+	}
+}
+
+func (s *serviceManagerSuite) TestStatusInexistent(c *gc.C) {
+	_, err := s.mgr.Status(s.name)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ERROR_SERVICE_DOES_NOT_EXIST)
+}
+
+func (s *serviceManagerSuite) TestProcessInfo(c *gc.C) {
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	err = s.mgr.Start(s.name)
+	c.Assert(err, gc.IsNil)
+	windows.Services[s.name].Pid = 1234
+	s.stub.ResetCalls()
+
+	pid, account, err := s.mgr.ProcessInfo(s.name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(pid, gc.Equals, uint32(1234))
+	c.Assert(account, gc.Equals, windows.JujudUser)
+	s.stub.CheckCallNames(c,
+		"GetHandle", "QueryServiceStatusEx", "CloseHandle",
+		"OpenService", "Close", "OpenService", "Close",
+	)
+}
+
+func (s *serviceManagerSuite) TestProcessInfoNotRunning(c *gc.C) {
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	windows.Services[s.name].Pid = 1234
+
+	pid, account, err := s.mgr.ProcessInfo(s.name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(pid, gc.Equals, uint32(0))
+	c.Assert(account, gc.Equals, windows.JujudUser)
+}
+
+func (s *serviceManagerSuite) TestProcessInfoInexistent(c *gc.C) {
+	_, _, err := s.mgr.ProcessInfo(s.name)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ERROR_SERVICE_DOES_NOT_EXIST)
+}
+
+func (s *serviceManagerSuite) TestProcessInfoQueryError(c *gc.C) {
+	windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: svc.Running})
+	s.stub.SetErrors(nil, errors.New("zoinks"))
+
+	_, _, err := s.mgr.ProcessInfo(s.name)
+	c.Assert(err, gc.ErrorMatches, "cannot query service process: zoinks")
+	s.stub.CheckCallNames(c, "GetHandle", "QueryServiceStatusEx", "CloseHandle")
+}
+
+func (s *serviceManagerSuite) TestRunningQueriesProcessID(c *gc.C) {
+	windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: svc.Running})
+	windows.Services[s.name].Pid = 1234
+
+	running, err := s.mgr.Running(s.name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(running, jc.IsTrue)
+	s.stub.CheckCallNames(c, "OpenService", "Query", "Close", "GetHandle", "QueryServiceStatusEx", "CloseHandle")
+	c.Assert(c.GetTestLog(), jc.Contains, fmt.Sprintf("Service %q Status %v, PID 1234", s.name, svc.Running))
+}
+
+func (s *serviceManagerSuite) TestRunningProcessIDError(c *gc.C) {
+	windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: svc.Running})
+	s.stub.SetErrors(nil, nil, nil, nil, errors.New("zoinks"))
+
+	running, err := s.mgr.Running(s.name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(running, jc.IsTrue)
+}
+
+func (s *serviceManagerSuite) startStopWait(c *gc.C, status svc.State, timeout time.Duration) (*testing.Clock, <-chan error) {
+	clock := testing.NewClock(time.Now())
+	windows.SetClock(s.mgr, clock)
+	windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: status})
+	result := make(chan error, 1)
+	go func() {
+		result <- s.mgr.StopWait(s.name, timeout)
+	}()
+	return clock, result
+}
+
+func waitAlarms(c *gc.C, clock *testing.Clock, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-clock.Alarms():
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for alarm %d", i)
+		}
+	}
+}
+
+func waitResult(c *gc.C, result <-chan error) error {
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("call did not return")
+	}
+	return nil
+}
+
+func (s *serviceManagerSuite) TestStopWait(c *gc.C) {
+	_, result := s.startStopWait(c, svc.Running, time.Minute)
+	err := waitResult(c, result)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(windows.Services[s.name].Status.State, gc.Equals, svc.Stopped)
+}
+
+func (s *serviceManagerSuite) TestStopWaitPollsUntilStopped(c *gc.C) {
+	clock, result := s.startStopWait(c, svc.StopPending, time.Minute)
+	// The deadline and the first poll.
+	waitAlarms(c, clock, 2)
+	clock.Advance(windows.StopPollInterval)
+	waitAlarms(c, clock, 1)
+	select {
+	case err := <-result:
+		c.Fatalf("StopWait returned early: %v", err)
+	default:
+	}
+
+	windows.Services[s.name].SetStatus(svc.Status{State: svc.Stopped})
+	clock.Advance(windows.StopPollInterval)
+	err := waitResult(c, result)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *serviceManagerSuite) TestStopWaitStopsServiceOnceStarted(c *gc.C) {
+	clock, result := s.startStopWait(c, svc.StartPending, time.Minute)
+	waitAlarms(c, clock, 2)
+	s.stub.CheckCallNames(c, "OpenService", "Query", "Close")
+
+	windows.Services[s.name].SetStatus(svc.Status{State: svc.Running})
+	clock.Advance(windows.StopPollInterval)
+	waitAlarms(c, clock, 1)
+	c.Assert(windows.Services[s.name].Status.State, gc.Equals, svc.Stopped)
+
+	clock.Advance(windows.StopPollInterval)
+	err := waitResult(c, result)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *serviceManagerSuite) TestStopWaitTimeout(c *gc.C) {
+	clock, result := s.startStopWait(c, svc.StopPending, 10*time.Second)
+	waitAlarms(c, clock, 2)
+	clock.Advance(10 * time.Second)
+	err := waitResult(c, result)
+	c.Assert(err, gc.ErrorMatches, `timed out after 10s waiting for service "machine-1" to stop \(state "stop-pending"\)`)
+}
+
+func (s *serviceManagerSuite) TestStopWaitInexistent(c *gc.C) {
+	err := s.mgr.StopWait(s.name, time.Minute)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ERROR_SERVICE_DOES_NOT_EXIST)
+}
+
+// sampleStates runs IsFlapping on a service that goes through the
+// given states, one per poll, and returns its result.
+func (s *serviceManagerSuite) sampleStates(c *gc.C, states []svc.State, window time.Duration, threshold int) (bool, error) {
+	clock := testing.NewClock(time.Now())
+	windows.SetClock(s.mgr, clock)
+	windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: states[0]})
+	type result struct {
+		flapping bool
+		err      error
+	}
+	results := make(chan result, 1)
+	go func() {
+		flapping, err := s.mgr.IsFlapping(s.name, window, threshold)
+		results <- result{flapping, err}
+	}()
+	// The deadline and the first poll.
+	waitAlarms(c, clock, 2)
+	for _, state := range states[1:] {
+		windows.Services[s.name].SetStatus(svc.Status{State: state})
+		clock.Advance(windows.FlapPollInterval)
+		waitAlarms(c, clock, 1)
+	}
+	clock.Advance(window)
+	select {
+	case r := <-results:
+		return r.flapping, r.err
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("IsFlapping did not return")
+	}
+	return false, nil
+}
+
+func (s *serviceManagerSuite) TestIsFlapping(c *gc.C) {
+	flapping, err := s.sampleStates(c, []svc.State{
+		svc.Running, svc.Stopped,
+		svc.StartPending, svc.Running, svc.Stopped,
+		svc.Running, svc.StopPending, svc.Stopped,
+		svc.Running,
+	}, time.Minute, 2)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(flapping, jc.IsTrue)
+}
+
+func (s *serviceManagerSuite) TestIsFlappingStable(c *gc.C) {
+	flapping, err := s.sampleStates(c, []svc.State{
+		svc.Running, svc.Running, svc.Running, svc.Running, svc.Running,
+	}, time.Minute, 2)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(flapping, jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestIsFlappingAtThreshold(c *gc.C) {
+	flapping, err := s.sampleStates(c, []svc.State{
+		svc.Running, svc.Stopped, svc.Running, svc.Stopped, svc.Running,
+	}, time.Minute, 2)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(flapping, jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestIsFlappingStopped(c *gc.C) {
+	flapping, err := s.sampleStates(c, []svc.State{
+		svc.Stopped, svc.Stopped, svc.Stopped,
+	}, time.Minute, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(flapping, jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestIsFlappingInexistent(c *gc.C) {
+	_, err := s.mgr.IsFlapping(s.name, time.Minute, 2)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ERROR_SERVICE_DOES_NOT_EXIST)
+}
+
 func (s *serviceManagerSuite) TestStop(c *gc.C) {
 	windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: svc.Running})
 
@@ -301,6 +1193,76 @@ func (s *serviceManagerSuite) TestChangePasswordErrorOut(c *gc.C) {
 
 }
 
+func (s *serviceManagerSuite) TestChangePasswordEmptyLeavesPassword(c *gc.C) {
+	s.getPasswd.SetPasswd("fake")
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	s.stub.ResetCalls()
+
+	err = s.mgr.ChangeServicePassword(s.name, "")
+	c.Assert(err, gc.IsNil)
+
+	s.stub.CheckCallNames(c, "OpenService", "Close")
+	m := s.mgr.(*windows.SvcManager)
+	cfg, err := m.Config(s.name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Password, gc.Equals, "fake")
+}
+
+func (s *serviceManagerSuite) TestUpdatePassword(c *gc.C) {
+	s.getPasswd.SetPasswd("fake")
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	s.stub.ResetCalls()
+
+	err = s.mgr.UpdatePassword(s.name, "rotated-password")
+	c.Assert(err, gc.IsNil)
+
+	// Only the password is changed.
+	noChange := uint32(win.SERVICE_NO_CHANGE)
+	s.stub.CheckCalls(c, []testing.StubCall{
+		{"GetHandle", []interface{}{s.name}},
+		{"ChangeServiceConfig", []interface{}{noChange, noChange, noChange}},
+		{"CloseHandle", nil},
+	})
+	m := s.mgr.(*windows.SvcManager)
+	cfg, err := m.Config(s.name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Password, gc.Equals, "rotated-password")
+	c.Assert(cfg.ServiceStartName, gc.Equals, windows.JujudUser)
+}
+
+func (s *serviceManagerSuite) TestUpdatePasswordEmpty(c *gc.C) {
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	s.stub.ResetCalls()
+
+	err = s.mgr.UpdatePassword(s.name, "")
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+	s.stub.CheckNoCalls(c)
+}
+
+func (s *serviceManagerSuite) TestUpdatePasswordNotFound(c *gc.C) {
+	err := s.mgr.UpdatePassword(s.name, "rotated-password")
+	c.Assert(err, gc.ErrorMatches, `cannot update password of service "machine-1": .*`)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ERROR_SERVICE_DOES_NOT_EXIST)
+}
+
+func (s *serviceManagerSuite) TestUpdatePasswordError(c *gc.C) {
+	s.getPasswd.SetPasswd("fake")
+	err := s.mgr.Create(s.name, s.conf)
+	c.Assert(err, gc.IsNil)
+	s.stub.SetErrors(nil, errors.New("poof"))
+
+	err = s.mgr.UpdatePassword(s.name, "rotated-password")
+	c.Assert(err, gc.ErrorMatches, `cannot update password of service "machine-1": poof`)
+
+	m := s.mgr.(*windows.SvcManager)
+	cfg, err := m.Config(s.name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Password, gc.Equals, "fake")
+}
+
 func (s *serviceManagerSuite) TestDelete(c *gc.C) {
 	windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: svc.Running})
 
@@ -310,6 +1272,49 @@ func (s *serviceManagerSuite) TestDelete(c *gc.C) {
 	c.Assert(exists, jc.IsFalse)
 }
 
+func (s *serviceManagerSuite) startDelete(c *gc.C, status svc.State) (*testing.Clock, <-chan error) {
+	clock := testing.NewClock(time.Now())
+	windows.SetClock(s.mgr, clock)
+	windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: status})
+	result := make(chan error, 1)
+	go func() {
+		result <- s.mgr.Delete(s.name)
+	}()
+	return clock, result
+}
+
+func (s *serviceManagerSuite) TestDeleteWaitsForStopPending(c *gc.C) {
+	clock, result := s.startDelete(c, svc.StopPending)
+	// The deadline and the first poll.
+	waitAlarms(c, clock, 2)
+	c.Assert(s.conn.Exists(s.name), jc.IsTrue)
+
+	windows.Services[s.name].SetStatus(svc.Status{State: svc.Stopped})
+	clock.Advance(windows.StopPollInterval)
+	err := waitResult(c, result)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.conn.Exists(s.name), jc.IsFalse)
+}
+
+func (s *serviceManagerSuite) TestDeleteStopPendingTimeout(c *gc.C) {
+	clock, result := s.startDelete(c, svc.StopPending)
+	waitAlarms(c, clock, 2)
+	clock.Advance(windows.DeleteStopTimeout)
+	err := waitResult(c, result)
+	c.Assert(err, gc.ErrorMatches, `service "machine-1" did not stop within 30s: the service is marked for deletion .*`)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ErrServiceMarkedForDelete)
+}
+
+func (s *serviceManagerSuite) TestDeleteMarkedForDelete(c *gc.C) {
+	windows.AddService(s.name, s.execPath, s.stub, svc.Status{State: svc.Stopped})
+	// OpenService, Close, OpenService, Query and then Delete.
+	s.stub.SetErrors(nil, nil, nil, nil, windows.ERROR_SERVICE_MARKED_FOR_DELETE)
+
+	err := s.mgr.Delete(s.name)
+	c.Assert(errors.Cause(err), gc.Equals, windows.ErrServiceMarkedForDelete)
+	c.Assert(err, gc.ErrorMatches, `cannot delete service "machine-1": the service is marked for deletion .*`)
+}
+
 func (s *serviceManagerSuite) TestDeleteInexistent(c *gc.C) {
 	exists := s.conn.Exists(s.name)
 	c.Assert(exists, jc.IsFalse)
@@ -336,12 +1341,217 @@ func (s *serviceManagerSuite) TestCloseCalled(c *gc.C) {
 
 	err = s.mgr.Stop(s.name)
 	c.Assert(err, gc.IsNil)
-	s.stub.CheckCallNames(c, "OpenService", "Query", "Close", "OpenService", "Control", "Close")
+	s.stub.CheckCallNames(c, "OpenService", "Query", "Close", "GetHandle", "QueryServiceStatusEx", "CloseHandle", "OpenService", "Control", "Close")
 	s.stub.ResetCalls()
 
 	err = s.mgr.Delete(s.name)
 	c.Assert(err, gc.IsNil)
-	s.stub.CheckCallNames(c, "OpenService", "Close", "OpenService", "Control", "Close")
+	s.stub.CheckCallNames(c, "OpenService", "Close", "OpenService", "Query", "Control", "Close")
 	s.stub.ResetCalls()
 
 }
+
+func (s *serviceManagerSuite) TestStartMany(c *gc.C) {
+	windows.AddService("service-a", s.execPath, s.stub, svc.Status{State: svc.Stopped})
+	windows.AddService("service-b", s.execPath, s.stub, svc.Status{State: svc.Running})
+
+	results := s.mgr.StartMany([]string{"service-a", "missing", "service-b"})
+	c.Assert(results, gc.HasLen, 3)
+	c.Assert(results["service-a"], jc.ErrorIsNil)
+	c.Assert(errors.Cause(results["missing"]), gc.Equals, windows.ERROR_SERVICE_DOES_NOT_EXIST)
+	c.Assert(results["service-b"], jc.ErrorIsNil)
+	c.Assert(windows.Services["service-a"].Status.State, gc.Equals, svc.Running)
+	c.Assert(windows.Services["service-b"].Status.State, gc.Equals, svc.Running)
+	s.stub.CheckCallNames(c,
+		"Connect",
+		"OpenService", "Query", "Start", "Close",
+		"OpenService",
+		"OpenService", "Query", "Close",
+		"Disconnect",
+	)
+}
+
+func (s *serviceManagerSuite) TestStartManyContinuesAfterFailure(c *gc.C) {
+	windows.AddService("service-a", s.execPath, s.stub, svc.Status{State: svc.Stopped})
+	windows.AddService("service-b", s.execPath, s.stub, svc.Status{State: svc.Stopped})
+	s.stub.SetErrors(nil, nil, nil, errors.New("zoinks"))
+
+	results := s.mgr.StartMany([]string{"service-a", "service-b"})
+	c.Assert(results["service-a"], gc.ErrorMatches, "zoinks")
+	c.Assert(results["service-b"], jc.ErrorIsNil)
+	c.Assert(windows.Services["service-b"].Status.State, gc.Equals, svc.Running)
+}
+
+func (s *serviceManagerSuite) TestStartManyConnectError(c *gc.C) {
+	windows.AddService("service-a", s.execPath, s.stub, svc.Status{State: svc.Stopped})
+	s.stub.SetErrors(syscall.ERROR_ACCESS_DENIED)
+
+	results := s.mgr.StartMany([]string{"service-a", "service-b"})
+	c.Assert(results, gc.HasLen, 2)
+	for _, name := range []string{"service-a", "service-b"} {
+		c.Assert(results[name], gc.ErrorMatches, "cannot connect to service manager: .*")
+	}
+	c.Assert(windows.Services["service-a"].Status.State, gc.Equals, svc.Stopped)
+	s.stub.CheckCallNames(c, "Connect")
+}
+
+func (s *serviceManagerSuite) TestStopMany(c *gc.C) {
+	windows.AddService("service-a", s.execPath, s.stub, svc.Status{State: svc.Running})
+	windows.AddService("service-b", s.execPath, s.stub, svc.Status{State: svc.Stopped})
+
+	results := s.mgr.StopMany([]string{"service-a", "missing", "service-b", "service-a"})
+	c.Assert(results, gc.HasLen, 3)
+	c.Assert(results["service-a"], jc.ErrorIsNil)
+	c.Assert(errors.Cause(results["missing"]), gc.Equals, windows.ERROR_SERVICE_DOES_NOT_EXIST)
+	c.Assert(results["service-b"], jc.ErrorIsNil)
+	c.Assert(windows.Services["service-a"].Status.State, gc.Equals, svc.Stopped)
+	s.stub.CheckCallNames(c,
+		"Connect",
+		"OpenService", "Query", "Control", "Close",
+		"OpenService",
+		"OpenService", "Query", "Close",
+		"Disconnect",
+	)
+}
+
+func (s *serviceManagerSuite) TestConcurrentOperations(c *gc.C) {
+	windows.AddService("service-a", s.execPath, s.stub, svc.Status{State: svc.Running})
+	windows.AddService("service-b", s.execPath, s.stub, svc.Status{State: svc.Running})
+
+	const iterations = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*iterations)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			if err := s.mgr.Stop("service-a"); err != nil {
+				errs <- err
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			running, err := s.mgr.Running("service-b")
+			if err != nil {
+				errs <- err
+			} else if !running {
+				errs <- errors.New("service-b stopped")
+			}
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		c.Check(err, jc.ErrorIsNil)
+	}
+
+	// Stopping one service never acted on the other.
+	c.Assert(windows.Services["service-a"].Status.State, gc.Equals, svc.Stopped)
+	c.Assert(windows.Services["service-b"].Status.State, gc.Equals, svc.Running)
+}
+
+func (s *serviceManagerSuite) TestEnumServiceNamesAcrossCalls(c *gc.C) {
+	stub := &testing.Stub{}
+	names := []string{"a", "b", "c", "d", "e"}
+	enum := windows.PatchEnumServices(s, stub, names)
+	enum.PerCall = 2
+
+	services, err := windows.EnumServiceNames(0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(services, jc.DeepEquals, names)
+
+	size := uint32(*windows.EnumServicesBufferSize)
+	stub.CheckCalls(c, []testing.StubCall{
+		{"EnumServicesStatus", []interface{}{size, uint32(0)}},
+		{"EnumServicesStatus", []interface{}{size, uint32(2)}},
+		{"EnumServicesStatus", []interface{}{size, uint32(4)}},
+	})
+}
+
+func (s *serviceManagerSuite) TestEnumServiceNamesGrowsBuffer(c *gc.C) {
+	stub := &testing.Stub{}
+	s.PatchValue(windows.EnumServicesBufferSize, windows.EnumServiceEntrySize)
+	names := []string{"a", "b", "c"}
+	windows.PatchEnumServices(s, stub, names)
+
+	services, err := windows.EnumServiceNames(0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(services, jc.DeepEquals, names)
+
+	// The first call only has room for one service, and reports how
+	// much room the rest need.
+	entrySize := uint32(windows.EnumServiceEntrySize)
+	stub.CheckCalls(c, []testing.StubCall{
+		{"EnumServicesStatus", []interface{}{entrySize, uint32(0)}},
+		{"EnumServicesStatus", []interface{}{2 * entrySize, uint32(1)}},
+	})
+}
+
+func (s *serviceManagerSuite) TestDecodeEnumServices(c *gc.C) {
+	names := []string{"jujud-machine-0", "", "ünïcode-svc"}
+	states := []uint32{uint32(svc.Running), uint32(svc.Stopped), uint32(svc.StartPending)}
+	buf, keep := windows.EnumServicesBuffer(names, states)
+
+	decodedNames, decodedStates, err := windows.DecodeEnumServices(buf, uint32(len(names)))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(decodedNames, jc.DeepEquals, names)
+	c.Assert(decodedStates, jc.DeepEquals, states)
+
+	// Only the entries returned are decoded.
+	decodedNames, decodedStates, err = windows.DecodeEnumServices(buf, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(decodedNames, jc.DeepEquals, names[:1])
+	c.Assert(decodedStates, jc.DeepEquals, states[:1])
+	runtime.KeepAlive(keep)
+}
+
+func (s *serviceManagerSuite) TestDecodeEnumServicesShortBuffer(c *gc.C) {
+	buf, keep := windows.EnumServicesBuffer([]string{"a", "b"}, []uint32{0, 0})
+
+	_, _, err := windows.DecodeEnumServices(buf[:len(buf)-1], 2)
+	c.Assert(err, gc.ErrorMatches, fmt.Sprintf("cannot read 2 services from %d byte buffer", len(buf)-1))
+	runtime.KeepAlive(keep)
+}
+
+func (s *serviceManagerSuite) TestEnumServiceNamesError(c *gc.C) {
+	stub := &testing.Stub{}
+	enum := windows.PatchEnumServices(s, stub, []string{"a", "b"})
+	enum.PerCall = 1
+	stub.SetErrors(nil, syscall.ERROR_ACCESS_DENIED)
+
+	_, err := windows.EnumServiceNames(0)
+	c.Assert(err, gc.Equals, syscall.ERROR_ACCESS_DENIED)
+}
+
+func (s *serviceManagerSuite) TestEnumServiceNamesWithPrefix(c *gc.C) {
+	stub := &testing.Stub{}
+	enum := windows.PatchEnumServices(s, stub, []string{
+		"jujud-machine-0", "Winmgmt", "jujud-unit-mysql-0", "jujud", "Jujud-unit-foo-0", "W3SVC",
+	})
+	enum.PerCall = 2
+
+	services, err := windows.EnumServiceNamesWithPrefix(0, "jujud-")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(services, jc.DeepEquals, []string{"jujud-machine-0", "jujud-unit-mysql-0"})
+}
+
+func (s *serviceManagerSuite) TestEnumServiceNamesWithEmptyPrefix(c *gc.C) {
+	stub := &testing.Stub{}
+	names := []string{"a", "b", "c"}
+	windows.PatchEnumServices(s, stub, names)
+
+	services, err := windows.EnumServiceNamesWithPrefix(0, "")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(services, jc.DeepEquals, names)
+}
+
+func (s *serviceManagerSuite) TestEnumServiceNamesWithPrefixNoMatch(c *gc.C) {
+	stub := &testing.Stub{}
+	windows.PatchEnumServices(s, stub, []string{"jujud", "Winmgmt"})
+
+	services, err := windows.EnumServiceNamesWithPrefix(0, "jujud-")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(services, gc.HasLen, 0)
+}
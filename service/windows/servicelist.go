@@ -0,0 +1,95 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package windows
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils/clock"
+	"github.com/juju/utils/set"
+)
+
+// serviceListCache holds the names of the installed services for a
+// short time, so that checking whether a service is installed does not
+// enumerate the whole service control manager database every time.
+type serviceListCache struct {
+	clock clock.Clock
+
+	// mu guards the fields below it. It is held while the services
+	// are listed, so that concurrent callers share a single listing,
+	// and an invalidation never races with a listing in progress.
+	mu       sync.Mutex
+	ttl      time.Duration
+	services []string
+	expires  time.Time
+}
+
+var installedServices = &serviceListCache{clock: clock.WallClock}
+
+// SetServiceListCacheTTL makes Service.Installed reuse the list of
+// installed services for up to ttl after listing them. The cache is
+// discarded whenever a service is created or deleted through this
+// package. A ttl of zero, the default, disables the cache.
+func SetServiceListCacheTTL(ttl time.Duration) {
+	installedServices.mu.Lock()
+	defer installedServices.mu.Unlock()
+	installedServices.ttl = ttl
+	installedServices.services = nil
+}
+
+// RefreshServiceList discards the cached list of installed services,
+// so that the next Service.Installed call lists them again.
+func RefreshServiceList() {
+	installedServices.invalidate()
+}
+
+// InstalledServices reports which of the named services are
+// installed, enumerating the installed services only once however many
+// names are given. Like Service.Installed, it uses the cache enabled by
+// SetServiceListCacheTTL.
+func InstalledServices(names []string) (map[string]bool, error) {
+	services, err := installedServices.list()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	installed := set.NewStrings(services...)
+	result := make(map[string]bool, len(names))
+	for _, name := range names {
+		result[name] = installed.Contains(name)
+	}
+	return result, nil
+}
+
+// list returns the names of the installed services, from the cache
+// if it is enabled and fresh.
+func (c *serviceListCache) list() ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 {
+		return listServices()
+	}
+	now := c.clock.Now()
+	if c.services != nil && now.Before(c.expires) {
+		return c.services, nil
+	}
+	services, err := listServices()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if services == nil {
+		services = []string{}
+	}
+	c.services = services
+	c.expires = now.Add(c.ttl)
+	return services, nil
+}
+
+// invalidate discards the cached list of installed services.
+func (c *serviceListCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.services = nil
+}
@@ -5,6 +5,8 @@
 package windows
 
 import (
+	"time"
+
 	"github.com/juju/testing"
 
 	"github.com/juju/juju/service/common"
@@ -12,6 +14,8 @@ import (
 
 type service struct {
 	running bool
+	paused  bool
+	pid     uint32
 
 	conf common.Conf
 }
@@ -44,6 +48,69 @@ func (s *StubSvcManager) Stop(name string) error {
 	return nil
 }
 
+func (s *StubSvcManager) StartMany(names []string) map[string]error {
+	s.Stub.AddCall("StartMany", names)
+
+	results := make(map[string]error)
+	for _, name := range names {
+		if svc, ok := MgrServices[name]; !ok {
+			results[name] = c_ERROR_SERVICE_DOES_NOT_EXIST
+		} else {
+			svc.running = true
+			results[name] = nil
+		}
+	}
+	return results
+}
+
+func (s *StubSvcManager) StopMany(names []string) map[string]error {
+	s.Stub.AddCall("StopMany", names)
+
+	results := make(map[string]error)
+	for _, name := range names {
+		if svc, ok := MgrServices[name]; !ok {
+			results[name] = c_ERROR_SERVICE_DOES_NOT_EXIST
+		} else {
+			svc.running = false
+			results[name] = nil
+		}
+	}
+	return results
+}
+
+func (s *StubSvcManager) StopWait(name string, timeout time.Duration) error {
+	s.Stub.AddCall("StopWait", name, timeout)
+
+	if svc, ok := MgrServices[name]; !ok {
+		return c_ERROR_SERVICE_DOES_NOT_EXIST
+	} else {
+		svc.running = false
+	}
+	return nil
+}
+
+func (s *StubSvcManager) Pause(name string) error {
+	s.Stub.AddCall("Pause", name)
+
+	if svc, ok := MgrServices[name]; !ok {
+		return c_ERROR_SERVICE_DOES_NOT_EXIST
+	} else {
+		svc.paused = true
+	}
+	return s.NextErr()
+}
+
+func (s *StubSvcManager) Continue(name string) error {
+	s.Stub.AddCall("Continue", name)
+
+	if svc, ok := MgrServices[name]; !ok {
+		return c_ERROR_SERVICE_DOES_NOT_EXIST
+	} else {
+		svc.paused = false
+	}
+	return s.NextErr()
+}
+
 func (s *StubSvcManager) Delete(name string) error {
 	s.Stub.AddCall("Delete", name)
 
@@ -68,6 +135,17 @@ func (s *StubSvcManager) Create(name string, conf common.Conf) error {
 	return nil
 }
 
+func (s *StubSvcManager) Update(name string, conf common.Conf) error {
+	s.Stub.AddCall("Update", name, conf)
+
+	svc, ok := MgrServices[name]
+	if !ok {
+		return c_ERROR_SERVICE_DOES_NOT_EXIST
+	}
+	svc.conf = conf
+	return s.NextErr()
+}
+
 func (s *StubSvcManager) Running(name string) (bool, error) {
 	s.Stub.AddCall("Running", name)
 
@@ -77,9 +155,54 @@ func (s *StubSvcManager) Running(name string) (bool, error) {
 	return false, c_ERROR_SERVICE_DOES_NOT_EXIST
 }
 
+func (s *StubSvcManager) Status(name string) (State, error) {
+	s.Stub.AddCall("Status", name)
+
+	if svc, ok := MgrServices[name]; ok {
+		if svc.running && svc.paused {
+			return StatePaused, nil
+		}
+		if svc.running {
+			return StateRunning, nil
+		}
+		return StateStopped, nil
+	}
+	return StateUnknown, c_ERROR_SERVICE_DOES_NOT_EXIST
+}
+
+func (s *StubSvcManager) ProcessInfo(name string) (uint32, string, error) {
+	s.Stub.AddCall("ProcessInfo", name)
+
+	svc, ok := MgrServices[name]
+	if !ok {
+		return 0, "", c_ERROR_SERVICE_DOES_NOT_EXIST
+	}
+	if !svc.running {
+		return 0, jujudUser, s.NextErr()
+	}
+	return svc.pid, jujudUser, s.NextErr()
+}
+
+// SetPid sets the id of the process reported while the named service
+// is running.
+func (s *StubSvcManager) SetPid(name string, pid uint32) {
+	if svc, ok := MgrServices[name]; ok {
+		svc.pid = pid
+	}
+}
+
+func (s *StubSvcManager) IsFlapping(name string, window time.Duration, threshold int) (bool, error) {
+	s.Stub.AddCall("IsFlapping", name, window, threshold)
+
+	if _, ok := MgrServices[name]; !ok {
+		return false, c_ERROR_SERVICE_DOES_NOT_EXIST
+	}
+	return false, s.NextErr()
+}
+
 func (s *StubSvcManager) Exists(name string, conf common.Conf) (bool, error) {
-	if _, ok := MgrServices[name]; ok {
-		return true, nil
+	if svc, ok := MgrServices[name]; ok {
+		return displayName(svc.conf) == displayName(conf) && svc.conf.ExecStart == conf.ExecStart, nil
 	}
 	return false, nil
 }
@@ -96,6 +219,15 @@ func (s *StubSvcManager) ChangeServicePassword(name, newPassword string) error {
 	return nil
 }
 
+func (s *StubSvcManager) UpdatePassword(name, password string) error {
+	s.Stub.AddCall("UpdatePassword", name, password)
+
+	if _, ok := MgrServices[name]; !ok {
+		return c_ERROR_SERVICE_DOES_NOT_EXIST
+	}
+	return s.NextErr()
+}
+
 func (s *StubSvcManager) ListServices() ([]string, error) {
 	s.Stub.AddCall("listServices")
 
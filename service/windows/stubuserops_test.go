@@ -8,6 +8,7 @@ package windows
 
 import (
 	"syscall"
+	"unsafe"
 
 	"github.com/juju/testing"
 )
@@ -41,7 +42,8 @@ type StubLogonUser struct {
 func (p *StubLogonUser) LogonUser(username *uint16, domain *uint16,
 	password *uint16, logonType uint32,
 	logonProvider uint32) (handle syscall.Handle, err error) {
-	p.AddCall("logonUser")
+	p.AddCall("logonUser", syscall.UTF16ToString((*[1 << 16]uint16)(unsafe.Pointer(username))[:]),
+		syscall.UTF16ToString((*[1 << 16]uint16)(unsafe.Pointer(domain))[:]))
 
 	err = p.NextErr()
 	if err != nil {
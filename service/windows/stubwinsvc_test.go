@@ -7,6 +7,11 @@
 package windows
 
 import (
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/juju/errors"
 	"github.com/juju/testing"
 	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
@@ -26,6 +31,24 @@ type StubService struct {
 	Closed    bool
 
 	Status svc.Status
+
+	// Pid holds the id of the process reported while the service
+	// is running.
+	Pid uint32
+
+	// triggers holds the service triggers set through ChangeServiceConfig2.
+	triggers []serviceTrigger
+
+	// resetPeriod and failureActions hold the failure actions set
+	// through ChangeServiceConfig2.
+	resetPeriod    uint32
+	failureActions []serviceAction
+
+	// delayedAutoStart is set through ChangeServiceConfig2.
+	delayedAutoStart bool
+
+	// env holds the environment set through SetEnvironment.
+	env []string
 }
 
 func AddService(name, execStart string, stub *testing.Stub, status svc.Status) {
@@ -60,16 +83,17 @@ func (s *StubService) Config() (mgr.Config, error) {
 func (s *StubService) Control(c svc.Cmd) (svc.Status, error) {
 	s.Stub.AddCall("Control", c)
 
+	// The controls a service accepts don't change with its state.
 	switch c {
 	case svc.Interrogate:
 	case svc.Stop:
-		s.Status = svc.Status{State: svc.Stopped}
+		s.Status = svc.Status{State: svc.Stopped, Accepts: s.Status.Accepts}
 	case svc.Pause:
-		s.Status = svc.Status{State: svc.Paused}
+		s.Status = svc.Status{State: svc.Paused, Accepts: s.Status.Accepts}
 	case svc.Continue:
-		s.Status = svc.Status{State: svc.Running}
+		s.Status = svc.Status{State: svc.Running, Accepts: s.Status.Accepts}
 	case svc.Shutdown:
-		s.Status = svc.Status{State: svc.Stopped}
+		s.Status = svc.Status{State: svc.Stopped, Accepts: s.Status.Accepts}
 	}
 	return s.Status, s.NextErr()
 }
@@ -93,12 +117,26 @@ func (s *StubService) Query() (svc.Status, error) {
 func (s *StubService) Start(args ...string) error {
 	s.Stub.AddCall("Start", args)
 
-	s.Status = svc.Status{State: svc.Running}
+	s.Status = svc.Status{State: svc.Running, Accepts: s.Status.Accepts}
 	return s.NextErr()
 }
 
 type StubMgr struct {
 	*testing.Stub
+
+	// mu guards handles.
+	mu sync.Mutex
+
+	// handles maps the handles returned by GetHandle to service names.
+	handles map[windows.Handle]string
+}
+
+// handleService returns the name of the service the given handle
+// was returned for.
+func (m *StubMgr) handleService(handle windows.Handle) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.handles[handle]
 }
 
 func (m *StubMgr) CreateService(name, exepath string, c mgr.Config, args ...string) (windowsService, error) {
@@ -107,6 +145,14 @@ func (m *StubMgr) CreateService(name, exepath string, c mgr.Config, args ...stri
 	if _, ok := Services[name]; ok {
 		return nil, c_ERROR_SERVICE_EXISTS
 	}
+	if err := m.NextErr(); err != nil {
+		return nil, err
+	}
+	// Compose BinaryPathName the same way mgr.CreateService does.
+	c.BinaryPathName = syscall.EscapeArg(exepath)
+	for _, v := range args {
+		c.BinaryPathName += " " + syscall.EscapeArg(v)
+	}
 	stubSvc := &StubService{
 		Name:      name,
 		ExecStart: exepath,
@@ -115,7 +161,7 @@ func (m *StubMgr) CreateService(name, exepath string, c mgr.Config, args ...stri
 		Stub:      m.Stub,
 	}
 	Services[name] = stubSvc
-	return stubSvc, m.NextErr()
+	return stubSvc, nil
 }
 
 func (m *StubMgr) Disconnect() error {
@@ -131,9 +177,26 @@ func (m *StubMgr) OpenService(name string) (windowsService, error) {
 	return nil, c_ERROR_SERVICE_DOES_NOT_EXIST
 }
 
+// Connect returns the StubMgr itself, which can open services and be
+// disconnected.
+func (m *StubMgr) Connect() (serviceConnection, error) {
+	m.Stub.AddCall("Connect")
+	if err := m.NextErr(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (m *StubMgr) GetHandle(name string) (handle windows.Handle, err error) {
 	m.Stub.AddCall("GetHandle", name)
 	if _, ok := Services[name]; ok {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.handles == nil {
+			m.handles = make(map[windows.Handle]string)
+		}
+		handle = windows.Handle(len(m.handles) + 1)
+		m.handles[handle] = name
 		return handle, m.NextErr()
 	}
 	return handle, c_ERROR_SERVICE_DOES_NOT_EXIST
@@ -144,6 +207,174 @@ func (m *StubMgr) CloseHandle(handle windows.Handle) (err error) {
 	return m.NextErr()
 }
 
+// ChangeServiceConfig only supports changing the password, the way
+// SvcManager.UpdatePassword does.
+func (m *StubMgr) ChangeServiceConfig(handle windows.Handle, serviceType, startType, errorControl uint32, binaryPathName, loadOrderGroup *uint16, tagId *uint32, dependencies, serviceStartName, password, displayName *uint16) error {
+	m.Stub.AddCall("ChangeServiceConfig", serviceType, startType, errorControl)
+	if err := m.NextErr(); err != nil {
+		return err
+	}
+	stubSvc, ok := Services[m.handleService(handle)]
+	if !ok {
+		return c_ERROR_SERVICE_DOES_NOT_EXIST
+	}
+	if password != nil {
+		stubSvc.config.Password = syscall.UTF16ToString((*[1 << 16]uint16)(unsafe.Pointer(password))[:])
+	}
+	return nil
+}
+
+func (m *StubMgr) ChangeServiceConfig2(handle windows.Handle, infoLevel uint32, info *byte) error {
+	m.Stub.AddCall("ChangeServiceConfig2", infoLevel)
+	if err := m.NextErr(); err != nil {
+		return err
+	}
+	stubSvc, ok := Services[m.handleService(handle)]
+	if !ok {
+		return c_ERROR_SERVICE_DOES_NOT_EXIST
+	}
+	switch infoLevel {
+	case SERVICE_CONFIG_DELAYED_AUTO_START_INFO:
+		delayedInfo := (*serviceDelayedAutoStartInfo)(unsafe.Pointer(info))
+		stubSvc.delayedAutoStart = delayedInfo.fDelayedAutostart != 0
+	case SERVICE_CONFIG_FAILURE_ACTIONS:
+		failActions := (*serviceFailureActions)(unsafe.Pointer(info))
+		stubSvc.resetPeriod = failActions.dwResetPeriod
+		stubSvc.failureActions = nil
+		if failActions.cActions > 0 {
+			n := failActions.cActions
+			actions := (*[1 << 10]serviceAction)(unsafe.Pointer(failActions.scAction))[:n:n]
+			stubSvc.failureActions = append(stubSvc.failureActions, actions...)
+		}
+	case SERVICE_CONFIG_TRIGGER_INFO:
+		triggerInfo := (*serviceTriggerInfo)(unsafe.Pointer(info))
+		stubSvc.triggers = nil
+		if triggerInfo.cTriggers > 0 {
+			n := triggerInfo.cTriggers
+			triggers := (*[1 << 10]serviceTrigger)(unsafe.Pointer(triggerInfo.pTriggers))[:n:n]
+			for _, trigger := range triggers {
+				subtype := *trigger.pTriggerSubtype
+				trigger.pTriggerSubtype = &subtype
+				stubSvc.triggers = append(stubSvc.triggers, trigger)
+			}
+		}
+	}
+	return nil
+}
+
+// QueryServiceConfig2 lays out the result the way the winapi does: the
+// structure requested by infoLevel followed by the data it points to.
+func (m *StubMgr) QueryServiceConfig2(handle windows.Handle, infoLevel uint32, buff *byte, buffSize uint32, bytesNeeded *uint32) error {
+	m.Stub.AddCall("QueryServiceConfig2", infoLevel)
+	if err := m.NextErr(); err != nil {
+		return err
+	}
+	stubSvc, ok := Services[m.handleService(handle)]
+	if !ok {
+		return c_ERROR_SERVICE_DOES_NOT_EXIST
+	}
+	base := uintptr(unsafe.Pointer(buff))
+	switch infoLevel {
+	case SERVICE_CONFIG_DELAYED_AUTO_START_INFO:
+		needed := unsafe.Sizeof(serviceDelayedAutoStartInfo{})
+		*bytesNeeded = uint32(needed)
+		if uintptr(buffSize) < needed {
+			return windows.ERROR_INSUFFICIENT_BUFFER
+		}
+		info := (*serviceDelayedAutoStartInfo)(unsafe.Pointer(base))
+		*info = serviceDelayedAutoStartInfo{}
+		if stubSvc.delayedAutoStart {
+			info.fDelayedAutostart = 1
+		}
+	case SERVICE_CONFIG_FAILURE_ACTIONS:
+		n := uintptr(len(stubSvc.failureActions))
+		infoSize := unsafe.Sizeof(serviceFailureActions{})
+		needed := infoSize + n*unsafe.Sizeof(serviceAction{})
+		*bytesNeeded = uint32(needed)
+		if uintptr(buffSize) < needed {
+			return windows.ERROR_INSUFFICIENT_BUFFER
+		}
+		info := (*serviceFailureActions)(unsafe.Pointer(base))
+		*info = serviceFailureActions{
+			dwResetPeriod: stubSvc.resetPeriod,
+			cActions:      uint32(n),
+		}
+		if n > 0 {
+			actions := (*[1 << 10]serviceAction)(unsafe.Pointer(base + infoSize))[:n:n]
+			copy(actions, stubSvc.failureActions)
+			info.scAction = &actions[0]
+		}
+	case SERVICE_CONFIG_TRIGGER_INFO:
+		n := uintptr(len(stubSvc.triggers))
+		infoSize := unsafe.Sizeof(serviceTriggerInfo{})
+		triggerSize := unsafe.Sizeof(serviceTrigger{})
+		needed := infoSize + n*(triggerSize+unsafe.Sizeof(windows.GUID{}))
+		*bytesNeeded = uint32(needed)
+		if uintptr(buffSize) < needed {
+			return windows.ERROR_INSUFFICIENT_BUFFER
+		}
+		info := (*serviceTriggerInfo)(unsafe.Pointer(base))
+		*info = serviceTriggerInfo{cTriggers: uint32(n)}
+		if n > 0 {
+			triggers := (*[1 << 10]serviceTrigger)(unsafe.Pointer(base + infoSize))[:n:n]
+			guids := (*[1 << 10]windows.GUID)(unsafe.Pointer(base + infoSize + n*triggerSize))[:n:n]
+			for i, trigger := range stubSvc.triggers {
+				guids[i] = *trigger.pTriggerSubtype
+				trigger.pTriggerSubtype = &guids[i]
+				triggers[i] = trigger
+			}
+			info.pTriggers = &triggers[0]
+		}
+	default:
+		return errors.NotSupportedf("info level %d", infoLevel)
+	}
+	return nil
+}
+
+func (m *StubMgr) QueryServiceStatusEx(handle windows.Handle) (serviceStatusProcess, error) {
+	m.Stub.AddCall("QueryServiceStatusEx")
+	if err := m.NextErr(); err != nil {
+		return serviceStatusProcess{}, err
+	}
+	stubSvc, ok := Services[m.handleService(handle)]
+	if !ok {
+		return serviceStatusProcess{}, c_ERROR_SERVICE_DOES_NOT_EXIST
+	}
+	status := serviceStatusProcess{
+		CurrentState:     uint32(stubSvc.Status.State),
+		ControlsAccepted: uint32(stubSvc.Status.Accepts),
+	}
+	if stubSvc.Status.State == svc.Running {
+		status.ProcessId = stubSvc.Pid
+	}
+	return status, nil
+}
+
+func (m *StubMgr) SetEnvironment(name string, env []string) error {
+	m.Stub.AddCall("SetEnvironment", name, env)
+	if err := m.NextErr(); err != nil {
+		return err
+	}
+	stubSvc, ok := Services[name]
+	if !ok {
+		return c_ERROR_SERVICE_DOES_NOT_EXIST
+	}
+	stubSvc.env = env
+	return nil
+}
+
+func (m *StubMgr) Environment(name string) ([]string, error) {
+	m.Stub.AddCall("Environment", name)
+	if err := m.NextErr(); err != nil {
+		return nil, err
+	}
+	stubSvc, ok := Services[name]
+	if !ok {
+		return nil, c_ERROR_SERVICE_DOES_NOT_EXIST
+	}
+	return stubSvc.env, nil
+}
+
 func (m *StubMgr) Exists(name string) bool {
 	if _, ok := Services[name]; ok {
 		return true
@@ -162,3 +393,49 @@ func (m *StubMgr) List() []string {
 func (m *StubMgr) Clear() {
 	Services = map[string]*StubService{}
 }
+
+// StubEnumServices mocks enumServices, returning the services in Names
+// across as many calls as the buffer size and PerCall allow.
+type StubEnumServices struct {
+	*testing.Stub
+
+	Names []string
+
+	// PerCall limits how many services are returned by each call,
+	// if it is positive.
+	PerCall int
+
+	// names keeps the UTF16 names written to the buffer alive, as
+	// the garbage collector does not see pointers in it.
+	names [][]uint16
+}
+
+func (e *StubEnumServices) EnumServicesStatus(h windows.Handle, infoLevel SC_ENUM_TYPE, serviceType uint32, serviceState uint32, lpServices uintptr, bufSize uint32, needed *uint32, returned *uint32, resume *uint32, groupName *uint32) error {
+	e.AddCall("EnumServicesStatus", bufSize, *resume)
+	if err := e.NextErr(); err != nil {
+		return err
+	}
+	entrySize := uint32(unsafe.Sizeof(enumService{}))
+	remaining := e.Names[*resume:]
+	n := int(bufSize / entrySize)
+	if e.PerCall > 0 && n > e.PerCall {
+		n = e.PerCall
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	for i := 0; i < n; i++ {
+		name := syscall.StringToUTF16(remaining[i])
+		e.names = append(e.names, name)
+		entry := (*enumService)(unsafe.Pointer(lpServices + uintptr(i)*uintptr(entrySize)))
+		*entry = enumService{name: &name[0]}
+	}
+	*returned = uint32(n)
+	*resume += uint32(n)
+	if n < len(remaining) {
+		*needed = uint32(len(remaining)-n) * entrySize
+		return windows.ERROR_MORE_DATA
+	}
+	*needed = 0
+	return nil
+}
@@ -16,6 +16,8 @@ var (
 	modadvapi32 = syscall.NewLazyDLL("advapi32.dll")
 
 	procEnumServicesStatusExW = modadvapi32.NewProc("EnumServicesStatusExW")
+	procLogonUserW            = modadvapi32.NewProc("LogonUserW")
+	procQueryServiceStatusEx  = modadvapi32.NewProc("QueryServiceStatusEx")
 )
 
 func enumServicesStatus(h windows.Handle, InfoLevel SC_ENUM_TYPE, dwServiceType uint32, dwServiceState uint32, lpServices uintptr, cbBufSize uint32, pcbBytesNeeded *uint32, lpServicesReturned *uint32, lpResumeHandle *uint32, pszGroupName *uint32) (err error) {
@@ -29,3 +31,27 @@ func enumServicesStatus(h windows.Handle, InfoLevel SC_ENUM_TYPE, dwServiceType
 	}
 	return
 }
+
+func logonUserW(username *uint16, domain *uint16, password *uint16, logonType uint32, logonProvider uint32, token *syscall.Handle) (err error) {
+	r1, _, e1 := syscall.Syscall6(procLogonUserW.Addr(), 6, uintptr(unsafe.Pointer(username)), uintptr(unsafe.Pointer(domain)), uintptr(unsafe.Pointer(password)), uintptr(logonType), uintptr(logonProvider), uintptr(unsafe.Pointer(token)))
+	if r1 == 0 {
+		if e1 != 0 {
+			err = error(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func queryServiceStatusEx(h windows.Handle, infoLevel uint32, buff *byte, buffSize uint32, bytesNeeded *uint32) (err error) {
+	r1, _, e1 := syscall.Syscall6(procQueryServiceStatusEx.Addr(), 5, uintptr(h), uintptr(infoLevel), uintptr(unsafe.Pointer(buff)), uintptr(buffSize), uintptr(unsafe.Pointer(bytesNeeded)), 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = error(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
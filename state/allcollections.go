@@ -4,6 +4,8 @@
 package state
 
 import (
+	"time"
+
 	"github.com/juju/utils/featureflag"
 	"gopkg.in/mgo.v2"
 
@@ -199,6 +201,18 @@ func allCollections() collectionSchema {
 			rawAccess: true,
 		},
 
+		// This collection holds the key used to sign resource download
+		// URLs, and the signatures of revoked URLs until they expire.
+		resourceURLsC: {
+			global:    true,
+			rawAccess: true,
+			indexes: []mgo.Index{{
+				Key:         []string{"expire-at"},
+				Sparse:      true,
+				ExpireAfter: time.Second,
+			}},
+		},
+
 		// This collection holds the last time the model user connected
 		// to the model.
 		modelUserLastConnectionC: {
@@ -483,6 +497,7 @@ const (
 	rebootC                  = "reboot"
 	relationScopesC          = "relationscopes"
 	relationsC               = "relations"
+	resourceURLsC            = "resourceURLs"
 	restoreInfoC             = "restoreInfo"
 	sequenceC                = "sequence"
 	applicationsC            = "applications"
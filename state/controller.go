@@ -33,3 +33,32 @@ func (st *State) ControllerConfig() (jujucontroller.Config, error) {
 	}
 	return settings.Map(), nil
 }
+
+// UpdateControllerConfig changes the given controller config values,
+// and removes the given keys so that their defaults apply. Only
+// attributes that may be changed after bootstrap can be updated.
+func (st *State) UpdateControllerConfig(updateAttrs map[string]interface{}, removeAttrs []string) error {
+	for k := range updateAttrs {
+		if !jujucontroller.UpdatableAttribute(k) {
+			return errors.Errorf("can not change %q after bootstrap", k)
+		}
+	}
+	for _, k := range removeAttrs {
+		if !jujucontroller.UpdatableAttribute(k) {
+			return errors.Errorf("can not change %q after bootstrap", k)
+		}
+	}
+	settings, err := readSettings(st, controllersC, controllerSettingsGlobalKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	settings.Update(updateAttrs)
+	for _, k := range removeAttrs {
+		settings.Delete(k)
+	}
+	if err := jujucontroller.Validate(settings.Map()); err != nil {
+		return errors.Trace(err)
+	}
+	_, err = settings.Write()
+	return errors.Trace(err)
+}
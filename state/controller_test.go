@@ -24,12 +24,16 @@ func (s *ControllerConfigSuite) TestControllerAndModelConfigInitialisation(c *gc
 	c.Assert(err, jc.ErrorIsNil)
 
 	optional := map[string]bool{
-		controller.IdentityURL:         true,
-		controller.IdentityPublicKey:   true,
-		controller.AutocertURLKey:      true,
-		controller.AutocertDNSNameKey:  true,
-		controller.AllowModelAccessKey: true,
-		controller.MongoMemoryProfile:  true,
+		controller.IdentityURL:               true,
+		controller.IdentityPublicKey:         true,
+		controller.AutocertURLKey:            true,
+		controller.AutocertDNSNameKey:        true,
+		controller.AllowModelAccessKey:       true,
+		controller.MongoMemoryProfile:        true,
+		controller.APILoginRateLimit:         true,
+		controller.APIEntityRequestLimit:     true,
+		controller.RevokedClientCertificates: true,
+		controller.AuditLogMethods:           true,
 	}
 	for _, controllerAttr := range controller.ControllerOnlyConfigAttributes {
 		v, ok := controllerSettings.Get(controllerAttr)
@@ -47,3 +51,39 @@ func (s *ControllerConfigSuite) TestControllerConfig(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(cfg["controller-uuid"], gc.Equals, m.ControllerUUID())
 }
+
+func (s *ControllerConfigSuite) TestUpdateControllerConfig(c *gc.C) {
+	err := s.State.UpdateControllerConfig(map[string]interface{}{
+		controller.APILoginRateLimit: 42,
+	}, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	cfg, err := s.State.ControllerConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.APILoginRateLimit(), gc.Equals, 42)
+
+	err = s.State.UpdateControllerConfig(nil, []string{controller.APILoginRateLimit})
+	c.Assert(err, jc.ErrorIsNil)
+	cfg, err = s.State.ControllerConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.APILoginRateLimit(), gc.Equals, controller.DefaultAPILoginRateLimit)
+}
+
+func (s *ControllerConfigSuite) TestUpdateControllerConfigRejectsFixedAttributes(c *gc.C) {
+	err := s.State.UpdateControllerConfig(map[string]interface{}{
+		controller.APIPort: 1234,
+	}, nil)
+	c.Assert(err, gc.ErrorMatches, `can not change "api-port" after bootstrap`)
+
+	err = s.State.UpdateControllerConfig(nil, []string{controller.CACertKey})
+	c.Assert(err, gc.ErrorMatches, `can not change "ca-cert" after bootstrap`)
+}
+
+func (s *ControllerConfigSuite) TestUpdateControllerConfigValidates(c *gc.C) {
+	err := s.State.UpdateControllerConfig(map[string]interface{}{
+		controller.APILoginRateLimit: 0,
+	}, nil)
+	c.Assert(err, gc.ErrorMatches, `api-login-rate-limit: expected positive value, got 0`)
+	cfg, err := s.State.ControllerConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.APILoginRateLimit(), gc.Equals, controller.DefaultAPILoginRateLimit)
+}
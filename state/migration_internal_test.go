@@ -120,6 +120,9 @@ func (s *MigrationSuite) TestKnownCollections(c *gc.C) {
 		// temporary credentials in there; after migration you'll just have
 		// to log back in.
 		bakeryStorageItemsC,
+		// Signed resource URLs are short-lived and controller
+		// specific; after migration you'll just need new ones.
+		resourceURLsC,
 		// Transaction stuff.
 		"txns",
 		"txns.log",
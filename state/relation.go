@@ -5,6 +5,7 @@ package state
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -133,6 +134,65 @@ func (r *Relation) Destroy() (err error) {
 	return rel.st.run(buildTxn)
 }
 
+// DestroyWithForce destroys the relation as Destroy does, and then
+// removes any units still in its scope without running their hooks, so
+// that a relation whose units are stuck in a hook error can still be
+// removed. Callers that want to give the units a chance to leave by
+// themselves should Destroy the relation and wait before calling it.
+func (r *Relation) DestroyWithForce() error {
+	if err := r.Destroy(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := r.Refresh(); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(r.forceLeaveScopes(), "cannot force destroy relation %q", r)
+}
+
+// forceLeaveScopes makes every unit still in scope in the relation leave
+// it, which removes the dying relation along with the last of them.
+func (r *Relation) forceLeaveScopes() error {
+	relationScopes, closer := r.st.getCollection(relationScopesC)
+	defer closer()
+
+	var docs []relationScopeDoc
+	sel := bson.D{{"key", bson.D{{"$regex", "^" + regexp.QuoteMeta(r.globalScope()+"#")}}}}
+	if err := relationScopes.Find(sel).All(&docs); err != nil {
+		return errors.Annotate(err, "cannot find units in scope")
+	}
+	for _, doc := range docs {
+		// Keys are r#<id>#[<container>#]<role>#<unit>.
+		parts := strings.Split(doc.Key, "#")
+		unitName := parts[len(parts)-1]
+		applicationName, err := names.UnitApplication(unitName)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		ep, err := r.Endpoint(applicationName)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := r.Refresh(); errors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return errors.Trace(err)
+		}
+		ru := &RelationUnit{
+			st:       r.st,
+			relation: r,
+			unitName: unitName,
+			endpoint: ep,
+			scope:    strings.Join(parts[:len(parts)-2], "#"),
+		}
+		if err := ru.LeaveScope(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
 // destroyOps returns the operations necessary to destroy the relation, and
 // whether those operations will lead to the relation's removal. These
 // operations may include changes to the relation's services; however, if
@@ -301,6 +361,12 @@ func (r *Relation) Endpoint(applicationname string) (Endpoint, error) {
 	return Endpoint{}, errors.Errorf("application %q is not a member of %q", applicationname, r)
 }
 
+// UnitCount returns the number of units in the relation's scopes, as
+// of the last Refresh.
+func (r *Relation) UnitCount() int {
+	return r.doc.UnitCount
+}
+
 // Endpoints returns the endpoints for the relation.
 func (r *Relation) Endpoints() []Endpoint {
 	return r.doc.Endpoints
@@ -260,6 +260,62 @@ func (s *RelationSuite) TestDestroyRelation(c *gc.C) {
 	c.Assert(err, jc.Satisfies, errors.IsNotFound)
 }
 
+func (s *RelationSuite) TestDestroyWithForce(c *gc.C) {
+	prr := newProReqRelation(c, &s.ConnSuite, charm.ScopeGlobal)
+	for _, ru := range []*state.RelationUnit{prr.pru0, prr.pru1, prr.rru0} {
+		err := ru.EnterScope(nil)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+
+	err := prr.rel.DestroyWithForce()
+	c.Assert(err, jc.ErrorIsNil)
+	err = prr.rel.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	for _, ru := range []*state.RelationUnit{prr.pru0, prr.pru1, prr.rru0} {
+		assertNotInScope(c, ru)
+	}
+	assertNoRelations(c, prr.psvc)
+	assertNoRelations(c, prr.rsvc)
+}
+
+func (s *RelationSuite) TestDestroyWithForceContainerScope(c *gc.C) {
+	prr := newProReqRelation(c, &s.ConnSuite, charm.ScopeContainer)
+	for _, ru := range []*state.RelationUnit{prr.pru0, prr.rru0} {
+		err := ru.EnterScope(nil)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+
+	err := prr.rel.DestroyWithForce()
+	c.Assert(err, jc.ErrorIsNil)
+	err = prr.rel.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	assertNotInScope(c, prr.pru0)
+	assertNotInScope(c, prr.rru0)
+}
+
+func (s *RelationSuite) TestDestroyWithForceNoUnits(c *gc.C) {
+	prr := newProReqRelation(c, &s.ConnSuite, charm.ScopeGlobal)
+	err := prr.rel.DestroyWithForce()
+	c.Assert(err, jc.ErrorIsNil)
+	err = prr.rel.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *RelationSuite) TestDestroyWithForceDyingRelation(c *gc.C) {
+	prr := newProReqRelation(c, &s.ConnSuite, charm.ScopeGlobal)
+	err := prr.pru0.EnterScope(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = prr.rel.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+
+	// The unit did not leave by itself, so it is removed.
+	err = prr.rel.DestroyWithForce()
+	c.Assert(err, jc.ErrorIsNil)
+	err = prr.rel.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	assertNotInScope(c, prr.pru0)
+}
+
 func (s *RelationSuite) TestDestroyPeerRelation(c *gc.C) {
 	// Check that a peer relation cannot be destroyed directly.
 	riakch := s.AddTestingCharm(c, "riak")
@@ -0,0 +1,100 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"crypto/rand"
+	"io"
+	"time"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+
+	"github.com/juju/juju/mongo"
+)
+
+// resourceURLKeyId is the id of the document holding the key used to
+// sign resource download URLs.
+const resourceURLKeyId = "key"
+
+type resourceURLKeyDoc struct {
+	Id  string `bson:"_id"`
+	Key []byte `bson:"key"`
+}
+
+type resourceURLRevocationDoc struct {
+	Id       string    `bson:"_id"`
+	ExpireAt time.Time `bson:"expire-at"`
+}
+
+// ResourceURLKey returns the key used to sign resource download URLs,
+// generating it the first time it is asked for. The key is shared by
+// all the controller's API servers, so a URL signed by one of them is
+// accepted by all of them.
+func (st *State) ResourceURLKey() ([]byte, error) {
+	coll, closer := st.resourceURLsCollection()
+	defer closer()
+
+	var doc resourceURLKeyDoc
+	err := coll.FindId(resourceURLKeyId).One(&doc)
+	if err == nil {
+		return doc.Key, nil
+	}
+	if err != mgo.ErrNotFound {
+		return nil, errors.Annotate(err, "cannot get resource URL key")
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, errors.Annotate(err, "cannot generate resource URL key")
+	}
+	err = coll.Insert(resourceURLKeyDoc{Id: resourceURLKeyId, Key: key})
+	if err == nil {
+		return key, nil
+	}
+	if !mgo.IsDup(err) {
+		return nil, errors.Annotate(err, "cannot store resource URL key")
+	}
+	// Another API server stored its key first; use that one.
+	if err := coll.FindId(resourceURLKeyId).One(&doc); err != nil {
+		return nil, errors.Annotate(err, "cannot get resource URL key")
+	}
+	return doc.Key, nil
+}
+
+// RevokeResourceURL records that the signed resource URL with the given
+// signature must not be accepted. The record is removed once the URL
+// has expired, since it would be rejected anyway.
+func (st *State) RevokeResourceURL(signature string, expires time.Time) error {
+	coll, closer := st.resourceURLsCollection()
+	defer closer()
+
+	id := resourceURLRevocationId(signature)
+	_, err := coll.UpsertId(id, resourceURLRevocationDoc{
+		Id:       id,
+		ExpireAt: expires,
+	})
+	return errors.Annotate(err, "cannot revoke resource URL")
+}
+
+// IsResourceURLRevoked reports whether the signed resource URL with the
+// given signature has been revoked.
+func (st *State) IsResourceURLRevoked(signature string) (bool, error) {
+	coll, closer := st.resourceURLsCollection()
+	defer closer()
+
+	n, err := coll.FindId(resourceURLRevocationId(signature)).Count()
+	if err != nil {
+		return false, errors.Annotate(err, "cannot check resource URL revocation")
+	}
+	return n > 0, nil
+}
+
+func resourceURLRevocationId(signature string) string {
+	return "revoked#" + signature
+}
+
+func (st *State) resourceURLsCollection() (mongo.WriteCollection, func()) {
+	coll, closer := st.getCollection(resourceURLsC)
+	return coll.Writeable(), closer
+}
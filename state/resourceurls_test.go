@@ -0,0 +1,60 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	statetesting "github.com/juju/juju/state/testing"
+)
+
+type resourceURLsSuite struct {
+	statetesting.StateSuite
+}
+
+var _ = gc.Suite(&resourceURLsSuite{})
+
+func (s *resourceURLsSuite) TestResourceURLKeyStable(c *gc.C) {
+	key, err := s.State.ResourceURLKey()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(key, gc.HasLen, 32)
+
+	again, err := s.State.ResourceURLKey()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(again, jc.DeepEquals, key)
+}
+
+func (s *resourceURLsSuite) TestResourceURLKeySharedByModels(c *gc.C) {
+	key, err := s.State.ResourceURLKey()
+	c.Assert(err, jc.ErrorIsNil)
+
+	st := s.Factory.MakeModel(c, nil)
+	defer st.Close()
+	other, err := st.ResourceURLKey()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(other, jc.DeepEquals, key)
+}
+
+func (s *resourceURLsSuite) TestRevokeResourceURL(c *gc.C) {
+	revoked, err := s.State.IsResourceURLRevoked("abc")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(revoked, jc.IsFalse)
+
+	expires := time.Now().Add(time.Minute)
+	err = s.State.RevokeResourceURL("abc", expires)
+	c.Assert(err, jc.ErrorIsNil)
+	// Revoking again is not an error.
+	err = s.State.RevokeResourceURL("abc", expires)
+	c.Assert(err, jc.ErrorIsNil)
+
+	revoked, err = s.State.IsResourceURLRevoked("abc")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(revoked, jc.IsTrue)
+	revoked, err = s.State.IsResourceURLRevoked("def")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(revoked, jc.IsFalse)
+}
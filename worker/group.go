@@ -0,0 +1,107 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package worker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"gopkg.in/tomb.v1"
+)
+
+// group implements the worker returned by NewGroup.
+type group struct {
+	tomb    tomb.Tomb
+	workers []Worker
+}
+
+// NewGroup returns a worker that runs the given workers as a unit. When
+// any of them stops with an error, or the group is killed, all of them
+// are killed.
+//
+// Wait waits for every worker to stop, even after the first error, and
+// returns that first error. Errors from workers that failed after it are
+// added to its message; its cause is unchanged. A worker that stops
+// without an error does not stop the others, and the group stops with a
+// nil error once all of them have.
+func NewGroup(workers ...Worker) Worker {
+	g := &group{workers: workers}
+	go func() {
+		defer g.tomb.Done()
+		g.tomb.Kill(g.loop())
+	}()
+	return g
+}
+
+func (g *group) loop() error {
+	results := make(chan error)
+	for _, w := range g.workers {
+		go func(w Worker) {
+			results <- w.Wait()
+		}(w)
+	}
+	var errs []error
+	dying := g.tomb.Dying()
+	for remaining := len(g.workers); remaining > 0; {
+		select {
+		case <-dying:
+			g.killAll()
+			dying = nil
+		case err := <-results:
+			remaining--
+			if err == nil {
+				continue
+			}
+			errs = append(errs, err)
+			if dying != nil {
+				g.killAll()
+				dying = nil
+			}
+		}
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return &groupError{errs}
+}
+
+func (g *group) killAll() {
+	for _, w := range g.workers {
+		w.Kill()
+	}
+}
+
+// Kill is part of the Worker interface.
+func (g *group) Kill() {
+	g.tomb.Kill(nil)
+}
+
+// Wait is part of the Worker interface.
+func (g *group) Wait() error {
+	return g.tomb.Wait()
+}
+
+// groupError is returned by a group when more than one of its workers
+// failed. Its cause is the cause of the first error.
+type groupError struct {
+	errs []error
+}
+
+// Error is part of the error interface.
+func (e *groupError) Error() string {
+	later := make([]string, len(e.errs)-1)
+	for i, err := range e.errs[1:] {
+		later[i] = err.Error()
+	}
+	return fmt.Sprintf("%v (also: %s)", e.errs[0], strings.Join(later, "; "))
+}
+
+// Cause returns the cause of the first error, for errors.Cause.
+func (e *groupError) Cause() error {
+	return errors.Cause(e.errs[0])
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package worker
+
+import (
+	"errors"
+	"time"
+
+	jujuerrors "github.com/juju/errors"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/testing"
+)
+
+type groupSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&groupSuite{})
+
+// waitUntilKilled is a worker function that runs until it is killed.
+func waitUntilKilled(stopCh <-chan struct{}) error {
+	<-stopCh
+	return nil
+}
+
+func waitGroup(c *gc.C, w Worker) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- w.Wait()
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for group to stop")
+	}
+	panic("unreachable")
+}
+
+func (s *groupSuite) TestChildErrorKillsOthers(c *gc.C) {
+	fail := make(chan struct{})
+	failing := NewSimpleWorker(func(stopCh <-chan struct{}) error {
+		<-fail
+		return testError
+	})
+	others := []SimpleWorker{
+		NewSimpleWorker(waitUntilKilled),
+		NewSimpleWorker(waitUntilKilled),
+	}
+	g := NewGroup(others[0], failing, others[1])
+
+	close(fail)
+	c.Assert(waitGroup(c, g), gc.Equals, testError)
+	for _, w := range others {
+		select {
+		case <-w.Dying():
+		default:
+			c.Fatalf("worker not killed")
+		}
+		c.Assert(w.Wait(), gc.IsNil)
+	}
+}
+
+func (s *groupSuite) TestKillKillsAll(c *gc.C) {
+	workers := []SimpleWorker{
+		NewSimpleWorker(waitUntilKilled),
+		NewSimpleWorker(waitUntilKilled),
+	}
+	g := NewGroup(workers[0], workers[1])
+
+	g.Kill()
+	c.Assert(waitGroup(c, g), gc.IsNil)
+	for _, w := range workers {
+		c.Assert(w.Wait(), gc.IsNil)
+	}
+}
+
+func (s *groupSuite) TestWaitsForAllChildren(c *gc.C) {
+	stopped := make(chan struct{})
+	slow := NewSimpleWorker(func(stopCh <-chan struct{}) error {
+		<-stopCh
+		<-stopped
+		return nil
+	})
+	g := NewGroup(slow, NewSimpleWorker(func(<-chan struct{}) error {
+		return testError
+	}))
+
+	select {
+	case <-Dead(g):
+		c.Fatalf("group stopped before all its workers")
+	case <-time.After(testing.ShortWait):
+	}
+	close(stopped)
+	c.Assert(waitGroup(c, g), gc.Equals, testError)
+}
+
+func (s *groupSuite) TestLaterErrorsAggregated(c *gc.C) {
+	otherError := errors.New("other error")
+	g := NewGroup(
+		NewSimpleWorker(func(<-chan struct{}) error {
+			return testError
+		}),
+		NewSimpleWorker(func(stopCh <-chan struct{}) error {
+			<-stopCh
+			return otherError
+		}),
+	)
+
+	err := waitGroup(c, g)
+	c.Assert(err, gc.ErrorMatches, "test error \\(also: other error\\)")
+	c.Assert(jujuerrors.Cause(err), gc.Equals, testError)
+}
+
+func (s *groupSuite) TestCleanExitDoesNotKillOthers(c *gc.C) {
+	running := NewSimpleWorker(waitUntilKilled)
+	g := NewGroup(running, NewSimpleWorker(func(<-chan struct{}) error {
+		return nil
+	}))
+
+	select {
+	case <-running.Dying():
+		c.Fatalf("worker killed by clean exit of another")
+	case <-time.After(testing.ShortWait):
+	}
+	g.Kill()
+	c.Assert(waitGroup(c, g), gc.IsNil)
+}
+
+func (s *groupSuite) TestEmpty(c *gc.C) {
+	c.Assert(waitGroup(c, NewGroup()), gc.IsNil)
+}
@@ -64,6 +64,14 @@ func (ctx *limitedContext) UnitName() string {
 // SetProcess implements runner.Context.
 func (ctx *limitedContext) SetProcess(process context.HookProcess) {}
 
+// GetProcess implements runner.Context.
+func (ctx *limitedContext) GetProcess() context.HookProcess {
+	return nil
+}
+
+// Discard implements runner.Context.
+func (ctx *limitedContext) Discard() {}
+
 // ActionData implements runner.Context.
 func (ctx *limitedContext) ActionData() (*context.ActionData, error) {
 	return nil, jujuc.ErrRestrictedContext
@@ -71,6 +71,14 @@ func (ctx *hookContext) addJujuUnitsMetric() error {
 // SetProcess implements runner.Context.
 func (ctx *hookContext) SetProcess(process context.HookProcess) {}
 
+// GetProcess implements runner.Context.
+func (ctx *hookContext) GetProcess() context.HookProcess {
+	return nil
+}
+
+// Discard implements runner.Context.
+func (ctx *hookContext) Discard() {}
+
 // ActionData implements runner.Context.
 func (ctx *hookContext) ActionData() (*context.ActionData, error) {
 	return nil, jujuc.ErrRestrictedContext
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/juju/utils/clock"
 	"gopkg.in/tomb.v1"
 )
 
@@ -63,21 +64,60 @@ func NewTimer(d time.Duration) PeriodicTimer {
 	return &Timer{time.NewTimer(d)}
 }
 
+// NewClockTimerFunc returns a NewTimerFunc whose timers are created by
+// the given clock, so that tests can control when a periodic worker
+// fires.
+func NewClockTimerFunc(clock clock.Clock) NewTimerFunc {
+	return func(d time.Duration) PeriodicTimer {
+		return &clockTimer{clock.NewTimer(d)}
+	}
+}
+
+// clockTimer implements PeriodicTimer on top of a clock.Timer.
+type clockTimer struct {
+	timer clock.Timer
+}
+
+// Reset implements PeriodicTimer.
+func (t *clockTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
+
+// CountDown implements PeriodicTimer.
+func (t *clockTimer) CountDown() <-chan time.Time {
+	return t.timer.Chan()
+}
+
 // NewPeriodicWorker returns a worker that runs the given function continually
 // sleeping for sleepDuration in between each call, until Kill() is called
 // The stopCh argument will be closed when the worker is killed. The error returned
 // by the doWork function will be returned by the worker's Wait function.
 func NewPeriodicWorker(call PeriodicWorkerCall, period time.Duration, timerFunc NewTimerFunc) Worker {
+	return newPeriodicWorker(call, period, 0, timerFunc)
+}
+
+// NewDelayedPeriodicWorker returns a worker that runs the given function
+// like NewPeriodicWorker, except that the first call is made one period
+// after the worker starts rather than immediately.
+func NewDelayedPeriodicWorker(call PeriodicWorkerCall, period time.Duration, timerFunc NewTimerFunc) Worker {
+	return newPeriodicWorker(call, period, period, timerFunc)
+}
+
+func newPeriodicWorker(call PeriodicWorkerCall, period, delay time.Duration, timerFunc NewTimerFunc) Worker {
 	w := &periodicWorker{newTimer: timerFunc}
 	go func() {
 		defer w.tomb.Done()
-		w.tomb.Kill(w.run(call, period))
+		w.tomb.Kill(w.run(call, period, delay))
 	}()
 	return w
 }
 
-func (w *periodicWorker) run(call PeriodicWorkerCall, period time.Duration) error {
-	timer := w.newTimer(0)
+// run calls the function after the given delay, and then one period
+// after each call returns. The timer is reset after every call rather
+// than ticking, so a slow call or a jump in the clock never causes
+// calls to bunch up.
+func (w *periodicWorker) run(call PeriodicWorkerCall, period, delay time.Duration) error {
+	timer := w.newTimer(delay)
 	stop := w.tomb.Dying()
 	for {
 		select {
@@ -6,6 +6,7 @@ package worker
 import (
 	"time"
 
+	jujutesting "github.com/juju/testing"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/juju/testing"
@@ -138,3 +139,76 @@ func (s *periodicWorkerSuite) TestCallUntilKilled(c *gc.C) {
 	w.Kill()
 	c.Assert(w.Wait(), gc.Equals, nil)
 }
+
+func (s *periodicWorkerSuite) assertCalls(c *gc.C, calls <-chan struct{}, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-calls:
+		case <-time.After(testing.LongWait):
+			c.Fatalf("timed out waiting for call %d", i)
+		}
+	}
+	select {
+	case <-calls:
+		c.Fatalf("unexpected call")
+	case <-time.After(testing.ShortWait):
+	}
+}
+
+func (s *periodicWorkerSuite) TestClockTimerFunc(c *gc.C) {
+	clock := jujutesting.NewClock(time.Now())
+	calls := make(chan struct{}, 10)
+	doWork := func(_ <-chan struct{}) error {
+		calls <- struct{}{}
+		return nil
+	}
+
+	w := NewPeriodicWorker(doWork, defaultPeriod, NewClockTimerFunc(clock))
+	defer func() { c.Assert(Stop(w), gc.IsNil) }()
+	s.assertCalls(c, calls, 1)
+	for i := 0; i < 3; i++ {
+		err := clock.WaitAdvance(defaultPeriod, testing.LongWait, 1)
+		c.Assert(err, gc.IsNil)
+		s.assertCalls(c, calls, 1)
+	}
+}
+
+func (s *periodicWorkerSuite) TestDelayedFirstCall(c *gc.C) {
+	clock := jujutesting.NewClock(time.Now())
+	calls := make(chan struct{}, 10)
+	doWork := func(_ <-chan struct{}) error {
+		calls <- struct{}{}
+		return nil
+	}
+
+	w := NewDelayedPeriodicWorker(doWork, defaultPeriod, NewClockTimerFunc(clock))
+	defer func() { c.Assert(Stop(w), gc.IsNil) }()
+	s.assertCalls(c, calls, 0)
+	err := clock.WaitAdvance(defaultPeriod/2, testing.LongWait, 1)
+	c.Assert(err, gc.IsNil)
+	s.assertCalls(c, calls, 0)
+	err = clock.WaitAdvance(defaultPeriod/2, testing.LongWait, 1)
+	c.Assert(err, gc.IsNil)
+	s.assertCalls(c, calls, 1)
+}
+
+func (s *periodicWorkerSuite) TestErrorStopsWorker(c *gc.C) {
+	clock := jujutesting.NewClock(time.Now())
+	calls := make(chan struct{}, 10)
+	count := 0
+	doWork := func(_ <-chan struct{}) error {
+		calls <- struct{}{}
+		count++
+		if count == 2 {
+			return testError
+		}
+		return nil
+	}
+
+	w := NewPeriodicWorker(doWork, defaultPeriod, NewClockTimerFunc(clock))
+	s.assertCalls(c, calls, 1)
+	err := clock.WaitAdvance(defaultPeriod, testing.LongWait, 1)
+	c.Assert(err, gc.IsNil)
+	c.Assert(w.Wait(), gc.Equals, testError)
+	s.assertCalls(c, calls, 1)
+}
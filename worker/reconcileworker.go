@@ -0,0 +1,190 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils/clock"
+	"gopkg.in/tomb.v1"
+)
+
+// ReconcileFunc brings some part of the world in line with its desired
+// state. The stop channel is closed when the worker running it is killed;
+// a ReconcileFunc that returns early because of that should return ErrKilled.
+type ReconcileFunc func(stop <-chan struct{}) error
+
+// ReconcileConfig defines the operation of a ReconcileWorker.
+type ReconcileConfig struct {
+
+	// Reconcile is called to do the work.
+	Reconcile ReconcileFunc
+
+	// Trigger delivers a value whenever a reconcile may be needed.
+	// Closing it stops the worker with an error.
+	Trigger <-chan struct{}
+
+	// Clock is the worker's view of time.
+	Clock clock.Clock
+
+	// QuietPeriod is how long the worker waits after the first
+	// trigger of a burst before reconciling, so that the burst results
+	// in a single reconcile. Later triggers do not postpone it, so
+	// frequent triggers cannot hold off the reconcile indefinitely.
+	QuietPeriod time.Duration
+
+	// MinRetryDelay is how long the worker waits before retrying a
+	// failed reconcile. The delay doubles with each consecutive
+	// failure, up to MaxRetryDelay.
+	MinRetryDelay time.Duration
+
+	// MaxRetryDelay is the longest the worker waits before retrying
+	// a failed reconcile.
+	MaxRetryDelay time.Duration
+}
+
+// Validate returns an error if the configuration cannot be expected
+// to start a functional worker.
+func (config ReconcileConfig) Validate() error {
+	if config.Reconcile == nil {
+		return errors.NotValidf("nil Reconcile")
+	}
+	if config.Trigger == nil {
+		return errors.NotValidf("nil Trigger")
+	}
+	if config.Clock == nil {
+		return errors.NotValidf("nil Clock")
+	}
+	if config.QuietPeriod < 0 {
+		return errors.NotValidf("negative QuietPeriod")
+	}
+	if config.MinRetryDelay <= 0 {
+		return errors.NotValidf("non-positive MinRetryDelay")
+	}
+	if config.MaxRetryDelay < config.MinRetryDelay {
+		return errors.NotValidf("MaxRetryDelay less than MinRetryDelay")
+	}
+	return nil
+}
+
+// ReconcileWorker runs a ReconcileFunc when triggered. Triggers that
+// arrive within the quiet period after a trigger are coalesced into a
+// single reconcile, and failed reconciles are retried with exponential
+// backoff until one succeeds.
+type ReconcileWorker struct {
+	tomb   tomb.Tomb
+	config ReconcileConfig
+
+	// mu guards the fields below it.
+	mu            sync.Mutex
+	lastReconcile time.Time
+	lastErr       error
+	failures      int
+}
+
+// NewReconcileWorker returns a ReconcileWorker running with the
+// given configuration.
+func NewReconcileWorker(config ReconcileConfig) (*ReconcileWorker, error) {
+	if err := config.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	w := &ReconcileWorker{config: config}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.loop())
+	}()
+	return w, nil
+}
+
+func (w *ReconcileWorker) loop() error {
+	var (
+		timer <-chan time.Time
+		delay time.Duration
+	)
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case _, ok := <-w.config.Trigger:
+			if !ok {
+				return errors.New("reconcile trigger closed")
+			}
+			// A pending reconcile or retry will cover this
+			// trigger, so only start the quiet period when there
+			// isn't one; restarting it would let a steady stream
+			// of triggers postpone the reconcile forever.
+			if timer == nil {
+				timer = w.config.Clock.After(w.config.QuietPeriod)
+			}
+		case <-timer:
+			timer = nil
+			err := w.config.Reconcile(w.tomb.Dying())
+			if err == ErrKilled {
+				return tomb.ErrDying
+			}
+			w.record(err)
+			if err == nil {
+				delay = 0
+				continue
+			}
+			delay = w.nextRetryDelay(delay)
+			logger.Warningf("reconcile failed, retrying in %v: %v", delay, err)
+			timer = w.config.Clock.After(delay)
+		}
+	}
+}
+
+// nextRetryDelay returns the delay to wait after a failed reconcile,
+// given the delay waited after the previous one.
+func (w *ReconcileWorker) nextRetryDelay(delay time.Duration) time.Duration {
+	if delay == 0 {
+		return w.config.MinRetryDelay
+	}
+	delay *= 2
+	if delay > w.config.MaxRetryDelay {
+		delay = w.config.MaxRetryDelay
+	}
+	return delay
+}
+
+func (w *ReconcileWorker) record(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastReconcile = w.config.Clock.Now()
+	w.lastErr = err
+	if err != nil {
+		w.failures++
+	} else {
+		w.failures = 0
+	}
+}
+
+// Report returns the time and outcome of the latest reconcile, for
+// use by the dependency engine and introspection.
+func (w *ReconcileWorker) Report() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	report := map[string]interface{}{
+		"consecutive-failures": w.failures,
+	}
+	if !w.lastReconcile.IsZero() {
+		report["last-reconcile"] = w.lastReconcile
+	}
+	if w.lastErr != nil {
+		report["last-error"] = w.lastErr.Error()
+	}
+	return report
+}
+
+// Kill is part of the Worker interface.
+func (w *ReconcileWorker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+// Wait is part of the Worker interface.
+func (w *ReconcileWorker) Wait() error {
+	return w.tomb.Wait()
+}
@@ -0,0 +1,295 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package worker
+
+import (
+	"errors"
+	"time"
+
+	jujutesting "github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/testing"
+)
+
+type reconcileWorkerSuite struct {
+	testing.BaseSuite
+
+	clock   *jujutesting.Clock
+	trigger chan struct{}
+	calls   chan struct{}
+	errs    []error
+}
+
+var _ = gc.Suite(&reconcileWorkerSuite{})
+
+const (
+	quietPeriod   = time.Second
+	minRetryDelay = 10 * time.Second
+	maxRetryDelay = 30 * time.Second
+)
+
+func (s *reconcileWorkerSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.clock = jujutesting.NewClock(time.Now())
+	s.trigger = make(chan struct{})
+	s.calls = make(chan struct{}, 10)
+	s.errs = nil
+}
+
+func (s *reconcileWorkerSuite) config() ReconcileConfig {
+	return ReconcileConfig{
+		Reconcile: func(<-chan struct{}) error {
+			var err error
+			if len(s.errs) > 0 {
+				err, s.errs = s.errs[0], s.errs[1:]
+			}
+			s.calls <- struct{}{}
+			return err
+		},
+		Trigger:       s.trigger,
+		Clock:         s.clock,
+		QuietPeriod:   quietPeriod,
+		MinRetryDelay: minRetryDelay,
+		MaxRetryDelay: maxRetryDelay,
+	}
+}
+
+func (s *reconcileWorkerSuite) newWorker(c *gc.C) *ReconcileWorker {
+	w, err := NewReconcileWorker(s.config())
+	c.Assert(err, jc.ErrorIsNil)
+	s.AddCleanup(func(c *gc.C) {
+		c.Check(Stop(w), jc.ErrorIsNil)
+	})
+	return w
+}
+
+// sendTrigger triggers the worker and waits for it to start the
+// quiet period.
+func (s *reconcileWorkerSuite) sendTrigger(c *gc.C) {
+	s.sendTriggerNoAlarm(c)
+	s.waitAlarm(c)
+}
+
+func (s *reconcileWorkerSuite) sendTriggerNoAlarm(c *gc.C) {
+	select {
+	case s.trigger <- struct{}{}:
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out sending trigger")
+	}
+}
+
+func (s *reconcileWorkerSuite) waitAlarm(c *gc.C) {
+	select {
+	case <-s.clock.Alarms():
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for timer")
+	}
+}
+
+func (s *reconcileWorkerSuite) assertCalled(c *gc.C) {
+	select {
+	case <-s.calls:
+	case <-time.After(testing.LongWait):
+		c.Fatalf("reconcile not called")
+	}
+}
+
+func (s *reconcileWorkerSuite) assertNotCalled(c *gc.C) {
+	select {
+	case <-s.calls:
+		c.Fatalf("unexpected reconcile")
+	case <-time.After(testing.ShortWait):
+	}
+}
+
+// waitReport waits until check accepts the worker's report, as the
+// worker records a reconcile only after it returns.
+func waitReport(c *gc.C, w *ReconcileWorker, check func(map[string]interface{}) bool) map[string]interface{} {
+	var report map[string]interface{}
+	for a := testing.LongAttempt.Start(); a.Next(); {
+		report = w.Report()
+		if check(report) {
+			return report
+		}
+	}
+	c.Fatalf("unexpected report %v", report)
+	return nil
+}
+
+func (s *reconcileWorkerSuite) TestValidate(c *gc.C) {
+	for i, test := range []struct {
+		mutate func(*ReconcileConfig)
+		err    string
+	}{{
+		func(cfg *ReconcileConfig) { cfg.Reconcile = nil },
+		"nil Reconcile not valid",
+	}, {
+		func(cfg *ReconcileConfig) { cfg.Trigger = nil },
+		"nil Trigger not valid",
+	}, {
+		func(cfg *ReconcileConfig) { cfg.Clock = nil },
+		"nil Clock not valid",
+	}, {
+		func(cfg *ReconcileConfig) { cfg.QuietPeriod = -time.Second },
+		"negative QuietPeriod not valid",
+	}, {
+		func(cfg *ReconcileConfig) { cfg.MinRetryDelay = 0 },
+		"non-positive MinRetryDelay not valid",
+	}, {
+		func(cfg *ReconcileConfig) { cfg.MaxRetryDelay = time.Second },
+		"MaxRetryDelay less than MinRetryDelay not valid",
+	}} {
+		c.Logf("test %d", i)
+		config := s.config()
+		test.mutate(&config)
+		w, err := NewReconcileWorker(config)
+		c.Check(w, gc.IsNil)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *reconcileWorkerSuite) TestDebounce(c *gc.C) {
+	s.newWorker(c)
+
+	// Triggers within the quiet period are coalesced.
+	s.sendTrigger(c)
+	s.clock.Advance(quietPeriod / 2)
+	s.sendTriggerNoAlarm(c)
+	s.sendTriggerNoAlarm(c)
+	s.assertNotCalled(c)
+
+	s.clock.Advance(quietPeriod / 2)
+	s.assertCalled(c)
+	s.assertNotCalled(c)
+}
+
+func (s *reconcileWorkerSuite) TestFrequentTriggersDoNotPostponeReconcile(c *gc.C) {
+	s.newWorker(c)
+
+	// A steady stream of triggers reconciles once per quiet period,
+	// measured from the first trigger of each burst.
+	for i := 0; i < 3; i++ {
+		c.Logf("burst %d", i)
+		s.sendTrigger(c)
+		for j := 0; j < 3; j++ {
+			s.clock.Advance(quietPeriod / 4)
+			s.sendTriggerNoAlarm(c)
+		}
+		s.assertNotCalled(c)
+		s.clock.Advance(quietPeriod / 4)
+		s.assertCalled(c)
+	}
+	s.assertNotCalled(c)
+}
+
+func (s *reconcileWorkerSuite) TestNoTriggerNoReconcile(c *gc.C) {
+	s.newWorker(c)
+	s.clock.Advance(time.Hour)
+	s.assertNotCalled(c)
+}
+
+func (s *reconcileWorkerSuite) TestRetryWithBackoff(c *gc.C) {
+	s.errs = []error{
+		errors.New("one"),
+		errors.New("two"),
+		errors.New("three"),
+		errors.New("four"),
+	}
+	w := s.newWorker(c)
+
+	s.sendTrigger(c)
+	s.clock.Advance(quietPeriod)
+	s.assertCalled(c)
+	s.waitAlarm(c)
+
+	// The first retry waits for the minimum delay.
+	s.clock.Advance(minRetryDelay)
+	s.assertCalled(c)
+	s.waitAlarm(c)
+
+	// The delay then doubles...
+	s.clock.Advance(minRetryDelay)
+	s.assertNotCalled(c)
+	s.clock.Advance(minRetryDelay)
+	s.assertCalled(c)
+	s.waitAlarm(c)
+
+	// ...up to the maximum.
+	s.clock.Advance(maxRetryDelay - time.Nanosecond)
+	s.assertNotCalled(c)
+	c.Assert(w.Report(), jc.DeepEquals, map[string]interface{}{
+		"consecutive-failures": 3,
+		"last-reconcile":       s.clock.Now().Add(-maxRetryDelay + time.Nanosecond),
+		"last-error":           "three",
+	})
+
+	// A trigger during backoff doesn't postpone the retry.
+	s.sendTriggerNoAlarm(c)
+	s.clock.Advance(time.Nanosecond)
+	s.assertCalled(c)
+	s.waitAlarm(c)
+	s.clock.Advance(maxRetryDelay)
+	s.assertCalled(c)
+
+	waitReport(c, w, func(report map[string]interface{}) bool {
+		return report["consecutive-failures"] == 0
+	})
+	c.Assert(w.Report(), jc.DeepEquals, map[string]interface{}{
+		"consecutive-failures": 0,
+		"last-reconcile":       s.clock.Now(),
+	})
+}
+
+func (s *reconcileWorkerSuite) TestReportInitial(c *gc.C) {
+	w := s.newWorker(c)
+	c.Assert(w.Report(), jc.DeepEquals, map[string]interface{}{
+		"consecutive-failures": 0,
+	})
+}
+
+func (s *reconcileWorkerSuite) TestReportSuccess(c *gc.C) {
+	w := s.newWorker(c)
+	s.sendTrigger(c)
+	s.clock.Advance(quietPeriod)
+	s.assertCalled(c)
+
+	report := waitReport(c, w, func(report map[string]interface{}) bool {
+		_, ok := report["last-reconcile"]
+		return ok
+	})
+	c.Assert(report, jc.DeepEquals, map[string]interface{}{
+		"consecutive-failures": 0,
+		"last-reconcile":       s.clock.Now(),
+	})
+}
+
+func (s *reconcileWorkerSuite) TestKill(c *gc.C) {
+	w, err := NewReconcileWorker(s.config())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(Stop(w), jc.ErrorIsNil)
+}
+
+func (s *reconcileWorkerSuite) TestKillDuringReconcile(c *gc.C) {
+	config := s.config()
+	config.Reconcile = func(stop <-chan struct{}) error {
+		s.calls <- struct{}{}
+		<-stop
+		return ErrKilled
+	}
+	w, err := NewReconcileWorker(config)
+	c.Assert(err, jc.ErrorIsNil)
+	s.sendTrigger(c)
+	s.clock.Advance(quietPeriod)
+	s.assertCalled(c)
+	c.Assert(Stop(w), jc.ErrorIsNil)
+}
+
+func (s *reconcileWorkerSuite) TestTriggerClosed(c *gc.C) {
+	w, err := NewReconcileWorker(s.config())
+	c.Assert(err, jc.ErrorIsNil)
+	close(s.trigger)
+	c.Assert(w.Wait(), gc.ErrorMatches, "reconcile trigger closed")
+}
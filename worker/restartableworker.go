@@ -0,0 +1,186 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils/clock"
+	"gopkg.in/tomb.v1"
+)
+
+// BackoffPolicy defines how a restartable worker waits between attempts
+// to run its function, and which errors stop it for good.
+type BackoffPolicy struct {
+
+	// Clock is the worker's view of time.
+	Clock clock.Clock
+
+	// InitialDelay is how long the worker waits before the first
+	// restart. The delay doubles with each consecutive restart, up to
+	// MaxDelay.
+	InitialDelay time.Duration
+
+	// MaxDelay is the longest the worker waits before a restart.
+	MaxDelay time.Duration
+
+	// IsFatal reports whether an error returned by the function should
+	// stop the worker rather than restart the function. If it is nil,
+	// every error causes a restart.
+	IsFatal func(error) bool
+
+	// StableAfter is how long the function must run before failing
+	// for the worker to consider it stable, resetting its restart
+	// count and delay. If it is zero, they are never reset.
+	StableAfter time.Duration
+}
+
+// Validate returns an error if the policy cannot be expected to drive
+// a functional worker.
+func (policy BackoffPolicy) Validate() error {
+	if policy.Clock == nil {
+		return errors.NotValidf("nil Clock")
+	}
+	if policy.InitialDelay <= 0 {
+		return errors.NotValidf("non-positive InitialDelay")
+	}
+	if policy.MaxDelay < policy.InitialDelay {
+		return errors.NotValidf("MaxDelay less than InitialDelay")
+	}
+	if policy.StableAfter < 0 {
+		return errors.NotValidf("negative StableAfter")
+	}
+	return nil
+}
+
+// RestartableWorker is the worker returned by NewRestartableWorker.
+type RestartableWorker interface {
+	Worker
+
+	// Restarts returns how many times the function has been
+	// restarted, since the worker started or since the function last
+	// ran for the policy's StableAfter. A high count means the
+	// function is flapping.
+	Restarts() int
+
+	// LastError returns the error that caused the most recent
+	// restart, or nil if the function has not been restarted.
+	LastError() error
+}
+
+// restartableWorker implements the worker returned by NewRestartableWorker.
+type restartableWorker struct {
+	tomb   tomb.Tomb
+	doWork func(stopCh <-chan struct{}) error
+	policy BackoffPolicy
+
+	// mu guards the fields below, which are read by Restarts and
+	// LastError while the worker runs.
+	mu       sync.Mutex
+	restarts int
+	lastErr  error
+}
+
+// NewRestartableWorker returns a worker that runs the given function,
+// and runs it again, after a delay set by the policy, whenever it fails
+// with an error the policy does not consider fatal. The stopCh argument
+// is closed when the worker is killed, which also cuts short any delay.
+//
+// The worker stops when the function returns nil or ErrKilled, in which
+// case Wait returns nil, or when it returns a fatal error, which Wait
+// then returns.
+func NewRestartableWorker(doWork func(stopCh <-chan struct{}) error, policy BackoffPolicy) (RestartableWorker, error) {
+	if doWork == nil {
+		return nil, errors.NotValidf("nil doWork")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	w := &restartableWorker{
+		doWork: doWork,
+		policy: policy,
+	}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.loop())
+	}()
+	return w, nil
+}
+
+func (w *restartableWorker) loop() error {
+	var delay time.Duration
+	for {
+		started := w.policy.Clock.Now()
+		err := w.doWork(w.tomb.Dying())
+		switch {
+		case err == nil, err == ErrKilled:
+			return nil
+		case w.policy.IsFatal != nil && w.policy.IsFatal(err):
+			return err
+		}
+		stable := w.policy.StableAfter > 0 && w.policy.Clock.Now().Sub(started) >= w.policy.StableAfter
+		if stable {
+			delay = 0
+		}
+		w.recordRestart(err, stable)
+		delay = w.nextDelay(delay)
+		logger.Warningf("restarting in %v after error: %v", delay, err)
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case <-w.policy.Clock.After(delay):
+		}
+	}
+}
+
+// recordRestart counts a restart caused by err, after resetting the
+// count if the function ran for long enough to be considered stable.
+func (w *restartableWorker) recordRestart(err error, stable bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if stable {
+		w.restarts = 0
+	}
+	w.restarts++
+	w.lastErr = err
+}
+
+// Restarts is part of the RestartableWorker interface.
+func (w *restartableWorker) Restarts() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.restarts
+}
+
+// LastError is part of the RestartableWorker interface.
+func (w *restartableWorker) LastError() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastErr
+}
+
+// nextDelay returns the delay to wait before a restart, given the delay
+// waited before the previous one.
+func (w *restartableWorker) nextDelay(delay time.Duration) time.Duration {
+	if delay == 0 {
+		return w.policy.InitialDelay
+	}
+	delay *= 2
+	if delay > w.policy.MaxDelay {
+		delay = w.policy.MaxDelay
+	}
+	return delay
+}
+
+// Kill is part of the Worker interface.
+func (w *restartableWorker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+// Wait is part of the Worker interface.
+func (w *restartableWorker) Wait() error {
+	return w.tomb.Wait()
+}
@@ -0,0 +1,276 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package worker
+
+import (
+	"errors"
+	"time"
+
+	jujutesting "github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/testing"
+)
+
+type restartableWorkerSuite struct {
+	testing.BaseSuite
+
+	clock *jujutesting.Clock
+	calls chan struct{}
+	errs  []error
+}
+
+var _ = gc.Suite(&restartableWorkerSuite{})
+
+const (
+	initialDelay = time.Second
+	maxDelay     = 5 * time.Second
+)
+
+var errFatal = errors.New("fatal")
+
+func (s *restartableWorkerSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.clock = jujutesting.NewClock(time.Now())
+	s.calls = make(chan struct{}, 10)
+	s.errs = nil
+}
+
+func (s *restartableWorkerSuite) policy() BackoffPolicy {
+	return BackoffPolicy{
+		Clock:        s.clock,
+		InitialDelay: initialDelay,
+		MaxDelay:     maxDelay,
+		IsFatal: func(err error) bool {
+			return err == errFatal
+		},
+	}
+}
+
+// doWork returns the next of s.errs, or blocks until stopped once
+// they have all been returned.
+func (s *restartableWorkerSuite) doWork(stopCh <-chan struct{}) error {
+	s.calls <- struct{}{}
+	if len(s.errs) == 0 {
+		<-stopCh
+		return ErrKilled
+	}
+	var err error
+	err, s.errs = s.errs[0], s.errs[1:]
+	return err
+}
+
+func (s *restartableWorkerSuite) newWorker(c *gc.C) RestartableWorker {
+	w, err := NewRestartableWorker(s.doWork, s.policy())
+	c.Assert(err, jc.ErrorIsNil)
+	s.AddCleanup(func(c *gc.C) {
+		c.Check(Stop(w), jc.ErrorIsNil)
+	})
+	return w
+}
+
+func (s *restartableWorkerSuite) waitAlarm(c *gc.C) {
+	select {
+	case <-s.clock.Alarms():
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for timer")
+	}
+}
+
+func (s *restartableWorkerSuite) assertCalled(c *gc.C) {
+	select {
+	case <-s.calls:
+	case <-time.After(testing.LongWait):
+		c.Fatalf("doWork not called")
+	}
+}
+
+func (s *restartableWorkerSuite) assertNotCalled(c *gc.C) {
+	select {
+	case <-s.calls:
+		c.Fatalf("unexpected doWork call")
+	case <-time.After(testing.ShortWait):
+	}
+}
+
+func (s *restartableWorkerSuite) TestValidate(c *gc.C) {
+	for i, test := range []struct {
+		mutate func(*BackoffPolicy)
+		err    string
+	}{{
+		func(policy *BackoffPolicy) { policy.Clock = nil },
+		"nil Clock not valid",
+	}, {
+		func(policy *BackoffPolicy) { policy.InitialDelay = 0 },
+		"non-positive InitialDelay not valid",
+	}, {
+		func(policy *BackoffPolicy) { policy.MaxDelay = initialDelay / 2 },
+		"MaxDelay less than InitialDelay not valid",
+	}, {
+		func(policy *BackoffPolicy) { policy.StableAfter = -time.Second },
+		"negative StableAfter not valid",
+	}} {
+		c.Logf("test %d", i)
+		policy := s.policy()
+		test.mutate(&policy)
+		w, err := NewRestartableWorker(s.doWork, policy)
+		c.Check(w, gc.IsNil)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *restartableWorkerSuite) TestNilDoWork(c *gc.C) {
+	w, err := NewRestartableWorker(nil, s.policy())
+	c.Check(w, gc.IsNil)
+	c.Check(err, gc.ErrorMatches, "nil doWork not valid")
+}
+
+func (s *restartableWorkerSuite) TestBackoffSchedule(c *gc.C) {
+	s.errs = []error{
+		errors.New("one"),
+		errors.New("two"),
+		errors.New("three"),
+		errors.New("four"),
+		errors.New("five"),
+	}
+	s.newWorker(c)
+	s.assertCalled(c)
+
+	// The delay starts at the initial delay, then doubles up to the
+	// maximum.
+	for _, delay := range []time.Duration{
+		initialDelay,
+		2 * initialDelay,
+		4 * initialDelay,
+		maxDelay,
+		maxDelay,
+	} {
+		s.waitAlarm(c)
+		s.clock.Advance(delay - time.Nanosecond)
+		s.assertNotCalled(c)
+		s.clock.Advance(time.Nanosecond)
+		s.assertCalled(c)
+	}
+}
+
+func (s *restartableWorkerSuite) TestFatalError(c *gc.C) {
+	s.errs = []error{errors.New("one"), errFatal}
+	w, err := NewRestartableWorker(s.doWork, s.policy())
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertCalled(c)
+	s.waitAlarm(c)
+	s.clock.Advance(initialDelay)
+	s.assertCalled(c)
+	c.Assert(w.Wait(), gc.Equals, errFatal)
+	s.assertNotCalled(c)
+}
+
+func (s *restartableWorkerSuite) TestNilStopsWorker(c *gc.C) {
+	s.errs = []error{nil}
+	w, err := NewRestartableWorker(s.doWork, s.policy())
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertCalled(c)
+	c.Assert(w.Wait(), jc.ErrorIsNil)
+	s.assertNotCalled(c)
+}
+
+func (s *restartableWorkerSuite) TestKillInterruptsBackoff(c *gc.C) {
+	s.errs = []error{errors.New("one")}
+	w, err := NewRestartableWorker(s.doWork, s.policy())
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertCalled(c)
+	s.waitAlarm(c)
+
+	// The clock is never advanced, so the worker can only stop if
+	// Kill interrupts the delay.
+	w.Kill()
+	result := make(chan error, 1)
+	go func() {
+		result <- w.Wait()
+	}()
+	select {
+	case err := <-result:
+		c.Assert(err, jc.ErrorIsNil)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("worker did not stop")
+	}
+	s.assertNotCalled(c)
+}
+
+func (s *restartableWorkerSuite) TestKillWhileRunning(c *gc.C) {
+	w := s.newWorker(c)
+	s.assertCalled(c)
+	c.Assert(Stop(w), jc.ErrorIsNil)
+}
+
+func (s *restartableWorkerSuite) TestRestarts(c *gc.C) {
+	s.errs = []error{errors.New("one"), errors.New("two")}
+	w := s.newWorker(c)
+	s.assertCalled(c)
+
+	s.waitAlarm(c)
+	c.Assert(w.Restarts(), gc.Equals, 1)
+	c.Assert(w.LastError(), gc.ErrorMatches, "one")
+	s.clock.Advance(initialDelay)
+	s.assertCalled(c)
+
+	s.waitAlarm(c)
+	c.Assert(w.Restarts(), gc.Equals, 2)
+	c.Assert(w.LastError(), gc.ErrorMatches, "two")
+}
+
+func (s *restartableWorkerSuite) TestNoRestarts(c *gc.C) {
+	w := s.newWorker(c)
+	s.assertCalled(c)
+	c.Assert(w.Restarts(), gc.Equals, 0)
+	c.Assert(w.LastError(), gc.IsNil)
+}
+
+func (s *restartableWorkerSuite) TestRestartsResetAfterStableRun(c *gc.C) {
+	const stableAfter = time.Minute
+	policy := s.policy()
+	policy.StableAfter = stableAfter
+	runs := 0
+	w, err := NewRestartableWorker(func(stopCh <-chan struct{}) error {
+		s.calls <- struct{}{}
+		runs++
+		switch runs {
+		case 1:
+			return errors.New("one")
+		case 2:
+			// Fail again, but only after running for long
+			// enough to be considered stable.
+			select {
+			case <-stopCh:
+				return ErrKilled
+			case <-s.clock.After(stableAfter):
+			}
+			return errors.New("two")
+		}
+		<-stopCh
+		return ErrKilled
+	}, policy)
+	c.Assert(err, jc.ErrorIsNil)
+	defer func() {
+		c.Check(Stop(w), jc.ErrorIsNil)
+	}()
+	s.assertCalled(c)
+	s.waitAlarm(c)
+	c.Assert(w.Restarts(), gc.Equals, 1)
+	s.clock.Advance(initialDelay)
+	s.assertCalled(c)
+
+	// The second run lasts long enough to reset the count, and the
+	// delay starts again from the initial delay.
+	s.waitAlarm(c)
+	s.clock.Advance(stableAfter)
+	s.waitAlarm(c)
+	c.Assert(w.Restarts(), gc.Equals, 1)
+	c.Assert(w.LastError(), gc.ErrorMatches, "two")
+	s.clock.Advance(initialDelay - time.Nanosecond)
+	s.assertNotCalled(c)
+	s.clock.Advance(time.Nanosecond)
+	s.assertCalled(c)
+}
@@ -3,17 +3,92 @@
 
 package worker
 
-import "gopkg.in/tomb.v1"
+import (
+	"context"
+	"runtime/debug"
 
-// simpleWorker implements the worker returned by NewSimpleWorker.
+	"github.com/juju/errors"
+	"gopkg.in/tomb.v1"
+)
+
+// simpleWorker implements the worker returned by NewSimpleWorker,
+// NewSimpleWorkerContext and NewSafeWorker.
 type simpleWorker struct {
 	tomb tomb.Tomb
 }
 
+// SimpleWorker is the worker returned by NewSimpleWorker,
+// NewSimpleWorkerContext and NewSafeWorker.
+type SimpleWorker interface {
+	Worker
+
+	// Dying returns a channel that is closed when the worker is
+	// killed, or when its function returns an error. For
+	// NewSimpleWorker and NewSafeWorker it is the same channel as the
+	// one given to the function, so goroutines started by the function
+	// can select on it without the function having to pass the channel
+	// on. Once closed, it stays closed.
+	Dying() <-chan struct{}
+
+	// WaitContext waits for the worker to complete, like Wait, but
+	// gives up and returns the context's error if the context is done
+	// first. The worker is not killed in that case.
+	WaitContext(ctx context.Context) error
+}
+
 // NewSimpleWorker returns a worker that runs the given function.  The
 // stopCh argument will be closed when the worker is killed. The error returned
 // by the doWork function will be returned by the worker's Wait function.
-func NewSimpleWorker(doWork func(stopCh <-chan struct{}) error) Worker {
+func NewSimpleWorker(doWork func(stopCh <-chan struct{}) error) SimpleWorker {
+	return newSimpleWorker(doWork)
+}
+
+// NewSimpleWorkerContext returns a worker that runs the given function,
+// like NewSimpleWorker, but with a context that is cancelled when the
+// worker is killed, so the function can pass cancellation on to the
+// calls it makes. The error returned by the doWork function will be
+// returned by the worker's Wait function, except that the context's
+// error is not reported when it is returned after the worker was killed.
+func NewSimpleWorkerContext(doWork func(ctx context.Context) error) SimpleWorker {
+	return newSimpleWorker(func(stopCh <-chan struct{}) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-stopCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		err := doWork(ctx)
+		select {
+		case <-stopCh:
+			if err == ctx.Err() {
+				return nil
+			}
+		default:
+		}
+		return err
+	})
+}
+
+// NewSafeWorker returns a worker that runs the given function, like
+// NewSimpleWorker, except that if the function panics the panic is
+// recovered and returned, with the stack where it happened, as an
+// error from the worker's Wait function. This lets whatever runs the
+// worker restart it rather than the whole process crashing.
+func NewSafeWorker(doWork func(stopCh <-chan struct{}) error) SimpleWorker {
+	return newSimpleWorker(func(stopCh <-chan struct{}) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.Errorf("panic: %v\n%s", r, debug.Stack())
+			}
+		}()
+		return doWork(stopCh)
+	})
+}
+
+func newSimpleWorker(doWork func(stopCh <-chan struct{}) error) *simpleWorker {
 	w := &simpleWorker{}
 	go func() {
 		defer w.tomb.Done()
@@ -23,7 +98,9 @@ func NewSimpleWorker(doWork func(stopCh <-chan struct{}) error) Worker {
 }
 
 // Kill implements Worker.Kill() and will close the channel given to the doWork
-// function.
+// function. It may be called at any time, from any goroutine, any number of
+// times: the tomb records the kill even before doWork starts, so the channel
+// doWork receives is already closed in that case.
 func (w *simpleWorker) Kill() {
 	w.tomb.Kill(nil)
 }
@@ -33,3 +110,18 @@ func (w *simpleWorker) Kill() {
 func (w *simpleWorker) Wait() error {
 	return w.tomb.Wait()
 }
+
+// WaitContext implements SimpleWorker.WaitContext().
+func (w *simpleWorker) WaitContext(ctx context.Context) error {
+	select {
+	case <-w.tomb.Dead():
+		return w.tomb.Wait()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dying implements SimpleWorker.Dying().
+func (w *simpleWorker) Dying() <-chan struct{} {
+	return w.tomb.Dying()
+}
@@ -4,7 +4,10 @@
 package worker
 
 import (
+	"context"
 	"errors"
+	"sync"
+	"time"
 
 	gc "gopkg.in/check.v1"
 
@@ -50,3 +53,266 @@ func (s *simpleWorkerSuite) TestKill(c *gc.C) {
 	// test we can kill again without a panic
 	w.Kill()
 }
+
+func (s *simpleWorkerSuite) TestKillBeforeStart(c *gc.C) {
+	started := make(chan struct{})
+	doWork := func(stopCh <-chan struct{}) error {
+		<-started
+		select {
+		case <-stopCh:
+			return nil
+		default:
+			return errors.New("kill lost")
+		}
+	}
+
+	w := NewSimpleWorker(doWork)
+	w.Kill()
+	close(started)
+	c.Assert(w.Wait(), gc.IsNil)
+}
+
+func (s *simpleWorkerSuite) TestConcurrentKillWait(c *gc.C) {
+	const workers = 50
+	const goroutines = 10
+	for i := 0; i < workers; i++ {
+		w := NewSimpleWorker(func(stopCh <-chan struct{}) error {
+			<-stopCh
+			return nil
+		})
+		var wg sync.WaitGroup
+		errs := make(chan error, goroutines)
+		for j := 0; j < goroutines; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.Kill()
+				w.Kill()
+				errs <- w.Wait()
+			}()
+		}
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(testing.LongWait):
+			c.Fatalf("worker %d did not stop", i)
+		}
+		close(errs)
+		for err := range errs {
+			c.Assert(err, gc.IsNil)
+		}
+	}
+}
+
+func (s *simpleWorkerSuite) TestDying(c *gc.C) {
+	const helpers = 5
+	started := make(chan struct{}, helpers)
+	stopped := make(chan struct{}, helpers)
+	var w SimpleWorker
+	ready := make(chan struct{})
+	w = NewSimpleWorker(func(stopCh <-chan struct{}) error {
+		<-ready
+		for i := 0; i < helpers; i++ {
+			go func() {
+				started <- struct{}{}
+				<-w.Dying()
+				stopped <- struct{}{}
+			}()
+		}
+		<-stopCh
+		return nil
+	})
+	close(ready)
+	for i := 0; i < helpers; i++ {
+		select {
+		case <-started:
+		case <-time.After(testing.LongWait):
+			c.Fatalf("helper %d did not start", i)
+		}
+	}
+	select {
+	case <-w.Dying():
+		c.Fatalf("worker dying before it was killed")
+	case <-stopped:
+		c.Fatalf("helper stopped before the worker was killed")
+	case <-time.After(testing.ShortWait):
+	}
+
+	w.Kill()
+	for i := 0; i < helpers; i++ {
+		select {
+		case <-stopped:
+		case <-time.After(testing.LongWait):
+			c.Fatalf("helper %d did not stop", i)
+		}
+	}
+	c.Assert(w.Wait(), gc.IsNil)
+
+	// Dying stays closed after the worker is dead, and killing it
+	// again does not close it twice.
+	w.Kill()
+	select {
+	case <-w.Dying():
+	default:
+		c.Fatalf("Dying not closed after worker died")
+	}
+}
+
+func (s *simpleWorkerSuite) TestDyingOnError(c *gc.C) {
+	w := NewSimpleWorker(func(<-chan struct{}) error {
+		return testError
+	})
+	c.Assert(w.Wait(), gc.Equals, testError)
+	select {
+	case <-w.Dying():
+	default:
+		c.Fatalf("Dying not closed after worker failed")
+	}
+}
+
+func (s *simpleWorkerSuite) TestWaitContextFinishes(c *gc.C) {
+	w := NewSimpleWorker(func(stopCh <-chan struct{}) error {
+		<-stopCh
+		return testError
+	})
+	w.Kill()
+	ctx, cancel := context.WithTimeout(context.Background(), testing.LongWait)
+	defer cancel()
+	c.Assert(w.WaitContext(ctx), gc.Equals, testError)
+}
+
+func (s *simpleWorkerSuite) TestWaitContextTimesOut(c *gc.C) {
+	w := NewSimpleWorker(func(stopCh <-chan struct{}) error {
+		<-stopCh
+		return testError
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), testing.ShortWait)
+	defer cancel()
+	c.Assert(w.WaitContext(ctx), gc.Equals, context.DeadlineExceeded)
+
+	// The worker is still running.
+	select {
+	case <-w.Dying():
+		c.Fatalf("worker killed by WaitContext")
+	default:
+	}
+	w.Kill()
+	c.Assert(w.Wait(), gc.Equals, testError)
+}
+
+func (s *simpleWorkerSuite) TestContextWait(c *gc.C) {
+	doWork := func(context.Context) error {
+		return testError
+	}
+
+	w := NewSimpleWorkerContext(doWork)
+	c.Assert(w.Wait(), gc.Equals, testError)
+}
+
+func (s *simpleWorkerSuite) TestContextKill(c *gc.C) {
+	doWork := func(ctx context.Context) error {
+		<-ctx.Done()
+		return testError
+	}
+
+	w := NewSimpleWorkerContext(doWork)
+	w.Kill()
+	c.Assert(w.Wait(), gc.Equals, testError)
+
+	// test we can kill again without a panic
+	w.Kill()
+}
+
+func (s *simpleWorkerSuite) TestContextKillCancelled(c *gc.C) {
+	doWork := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	w := NewSimpleWorkerContext(doWork)
+	w.Kill()
+	c.Assert(w.Wait(), gc.IsNil)
+}
+
+func (s *simpleWorkerSuite) TestContextWaitContext(c *gc.C) {
+	doWork := func(ctx context.Context) error {
+		<-ctx.Done()
+		return testError
+	}
+
+	w := NewSimpleWorkerContext(doWork)
+	ctx, cancel := context.WithTimeout(context.Background(), testing.ShortWait)
+	defer cancel()
+	c.Assert(w.WaitContext(ctx), gc.Equals, context.DeadlineExceeded)
+
+	w.Kill()
+	select {
+	case <-w.Dying():
+	default:
+		c.Fatalf("Dying not closed after worker killed")
+	}
+	c.Assert(w.Wait(), gc.Equals, testError)
+}
+
+func (s *simpleWorkerSuite) TestContextCancelledAfterReturn(c *gc.C) {
+	ctxCh := make(chan context.Context, 1)
+	doWork := func(ctx context.Context) error {
+		ctxCh <- ctx
+		return nil
+	}
+
+	w := NewSimpleWorkerContext(doWork)
+	c.Assert(w.Wait(), gc.IsNil)
+	ctx := <-ctxCh
+	c.Assert(ctx.Err(), gc.Equals, context.Canceled)
+}
+
+func (s *simpleWorkerSuite) TestSafeWorkerPanic(c *gc.C) {
+	doWork := func(_ <-chan struct{}) error {
+		panic("oh noes")
+	}
+
+	w := NewSafeWorker(doWork)
+	err := w.Wait()
+	c.Assert(err, gc.ErrorMatches, `(?s)panic: oh noes\n.*simpleworker_test.go.*`)
+}
+
+func (s *simpleWorkerSuite) TestSafeWorkerWait(c *gc.C) {
+	doWork := func(_ <-chan struct{}) error {
+		return testError
+	}
+
+	w := NewSafeWorker(doWork)
+	c.Assert(w.Wait(), gc.Equals, testError)
+}
+
+func (s *simpleWorkerSuite) TestSafeWorkerKill(c *gc.C) {
+	doWork := func(stopCh <-chan struct{}) error {
+		<-stopCh
+		return nil
+	}
+
+	w := NewSafeWorker(doWork)
+	w.Kill()
+	c.Assert(w.Wait(), gc.IsNil)
+}
+
+func (s *simpleWorkerSuite) TestSafeWorkerDyingOnPanic(c *gc.C) {
+	doWork := func(_ <-chan struct{}) error {
+		panic("oh noes")
+	}
+
+	w := NewSafeWorker(doWork)
+	ctx, cancel := context.WithTimeout(context.Background(), testing.LongWait)
+	defer cancel()
+	c.Assert(w.WaitContext(ctx), gc.ErrorMatches, `(?s)panic: oh noes\n.*`)
+	select {
+	case <-w.Dying():
+	default:
+		c.Fatalf("Dying not closed after worker panicked")
+	}
+}
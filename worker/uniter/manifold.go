@@ -4,6 +4,8 @@
 package uniter
 
 import (
+	"time"
+
 	"github.com/juju/errors"
 	"github.com/juju/utils/clock"
 	"gopkg.in/juju/names.v2"
@@ -88,6 +90,10 @@ func Manifold(config ManifoldConfig) dependency.Manifold {
 			if !ok {
 				return nil, errors.Errorf("expected a unit tag, got %v", tag)
 			}
+			operationTimeout, operationTimeouts, err := readOperationTimeouts(agentConfig)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
 			uniterFacade := uniter.NewState(apiConn, unitTag)
 			uniter, err := NewUniter(&UniterParams{
 				UniterFacade:         uniterFacade,
@@ -102,6 +108,10 @@ func Manifold(config ManifoldConfig) dependency.Manifold {
 				NewOperationExecutor: operation.NewExecutor,
 				TranslateResolverErr: config.TranslateResolverErr,
 				Clock:                manifoldConfig.Clock,
+
+				RelationChangedWindow: relationChangedWindow,
+				OperationTimeout:      operationTimeout,
+				OperationTimeouts:     operationTimeouts,
 			})
 			if err != nil {
 				return nil, errors.Trace(err)
@@ -111,6 +121,35 @@ func Manifold(config ManifoldConfig) dependency.Manifold {
 	}
 }
 
+// readOperationTimeouts returns the hook and action timeouts held in the
+// agent config. Hooks and actions are not timed out unless the config
+// says so.
+func readOperationTimeouts(agentConfig agent.Config) (time.Duration, map[operation.Kind]time.Duration, error) {
+	var timeout time.Duration
+	if s := agentConfig.Value(agent.UnitOperationTimeout); s != "" {
+		var err error
+		if timeout, err = time.ParseDuration(s); err != nil {
+			return 0, nil, errors.Errorf("invalid operation timeout: %q", s)
+		}
+	}
+	timeouts := make(map[operation.Kind]time.Duration)
+	for kind, key := range map[operation.Kind]string{
+		operation.RunHook:   agent.UnitHookTimeout,
+		operation.RunAction: agent.UnitActionTimeout,
+	} {
+		s := agentConfig.Value(key)
+		if s == "" {
+			continue
+		}
+		kindTimeout, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, nil, errors.Errorf("invalid %s timeout: %q", kind, s)
+		}
+		timeouts[kind] = kindTimeout
+	}
+	return timeout, timeouts, nil
+}
+
 // TranslateFortressErrors turns errors returned by dependent
 // manifolds due to fortress lockdown (i.e. model migration) into an
 // error which causes the resolver loop to be restarted. When this
@@ -0,0 +1,97 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package operation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils/clock"
+	"gopkg.in/juju/charm.v6-unstable/hooks"
+
+	"github.com/juju/juju/worker/uniter/hook"
+)
+
+// relationUnitKey identifies the remote unit a relation-changed hook
+// is run for.
+type relationUnitKey struct {
+	relationId int
+	remoteUnit string
+}
+
+// relationChangedCoalescer limits relation-changed hooks to one per
+// remote unit per window. A hook asked for within the window of the
+// previous one for the same remote unit is held until the window has
+// passed, and then run once with the latest settings version, so that
+// all the settings changes made in the meantime are collapsed into it.
+type relationChangedCoalescer struct {
+	clock         clock.Clock
+	window        time.Duration
+	abort         <-chan struct{}
+	changeVersion func(relationId int, remoteUnit string) (int64, bool)
+
+	mu      sync.Mutex
+	lastRun map[relationUnitKey]time.Time
+}
+
+func newRelationChangedCoalescer(
+	clock clock.Clock,
+	window time.Duration,
+	abort <-chan struct{},
+	changeVersion func(relationId int, remoteUnit string) (int64, bool),
+) *relationChangedCoalescer {
+	return &relationChangedCoalescer{
+		clock:         clock,
+		window:        window,
+		abort:         abort,
+		changeVersion: changeVersion,
+		lastRun:       make(map[relationUnitKey]time.Time),
+	}
+}
+
+// coalesce returns the hook info a relation-changed hook should be run
+// with, once the window since the previous one for the same remote
+// unit has passed. Other hooks are returned unchanged straight away.
+func (c *relationChangedCoalescer) coalesce(info hook.Info) (hook.Info, error) {
+	if info.Kind != hooks.RelationChanged {
+		return info, nil
+	}
+	key := relationUnitKey{info.RelationId, info.RemoteUnit}
+	c.mu.Lock()
+	last, ok := c.lastRun[key]
+	c.mu.Unlock()
+	if ok {
+		if wait := last.Add(c.window).Sub(c.clock.Now()); wait > 0 {
+			select {
+			case <-c.clock.After(wait):
+			case <-c.abort:
+				return hook.Info{}, errors.Errorf("aborted waiting to run %s for %s", info.Kind, info.RemoteUnit)
+			}
+			if c.changeVersion != nil {
+				// The settings may have changed again while the
+				// hook was held; run it for the latest ones.
+				if version, ok := c.changeVersion(info.RelationId, info.RemoteUnit); ok && version > info.ChangeVersion {
+					info.ChangeVersion = version
+				}
+			}
+		}
+	}
+	c.record(key)
+	return info, nil
+}
+
+// record notes that a relation-changed hook is about to be run for the
+// given remote unit, and forgets those whose window has passed.
+func (c *relationChangedCoalescer) record(key relationUnitKey) {
+	now := c.clock.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, last := range c.lastRun {
+		if now.Sub(last) >= c.window {
+			delete(c.lastRun, k)
+		}
+	}
+	c.lastRun[key] = now
+}
@@ -16,6 +16,8 @@ var (
 	ErrNeedsReboot            = errors.New("reboot request issued")
 	ErrHookFailed             = errors.New("hook failed")
 	ErrCannotAcceptLeadership = errors.New("cannot accept leadership")
+	ErrFenced                 = errors.New("unit is fenced")
+	ErrAlreadyFenced          = errors.New("unit is already fenced")
 )
 
 type deployConflictError struct {
@@ -30,13 +30,15 @@ type executor struct {
 	file               *StateFile
 	state              *State
 	acquireMachineLock func() (mutex.Releaser, error)
+	logger             opLogger
 }
 
 // NewExecutor returns an Executor which takes its starting state from the
 // supplied path, and records state changes there. If no state file exists,
 // the executor's starting state will include a queued Install hook, for
-// the charm identified by the supplied func.
-func NewExecutor(stateFilePath string, getInstallCharm func() (*corecharm.URL, error), acquireLock func() (mutex.Releaser, error)) (Executor, error) {
+// the charm identified by the supplied func. The messages it logs are
+// labelled with the given unit name.
+func NewExecutor(unitName, stateFilePath string, getInstallCharm func() (*corecharm.URL, error), acquireLock func() (mutex.Releaser, error)) (Executor, error) {
 	file := NewStateFile(stateFilePath)
 	state, err := file.Read()
 	if err == ErrNoStateFile {
@@ -56,6 +58,7 @@ func NewExecutor(stateFilePath string, getInstallCharm func() (*corecharm.URL, e
 		file:               file,
 		state:              state,
 		acquireMachineLock: acquireLock,
+		logger:             newOpLogger(unitName, "executor"),
 	}, nil
 }
 
@@ -66,14 +69,14 @@ func (x *executor) State() State {
 
 // Run is part of the Executor interface.
 func (x *executor) Run(op Operation) error {
-	logger.Debugf("running operation %v", op)
+	x.logger.Debugf("running operation %v", op)
 
 	if op.NeedsGlobalMachineLock() {
 		releaser, err := x.acquireMachineLock()
 		if err != nil {
 			return errors.Annotate(err, "could not acquire lock")
 		}
-		defer logger.Debugf("lock released")
+		defer x.logger.Debugf("lock released")
 		defer releaser.Release()
 	}
 
@@ -91,20 +94,20 @@ func (x *executor) Run(op Operation) error {
 
 // Skip is part of the Executor interface.
 func (x *executor) Skip(op Operation) error {
-	logger.Debugf("skipping operation %v", op)
+	x.logger.Debugf("skipping operation %v", op)
 	return x.do(op, stepCommit)
 }
 
 func (x *executor) do(op Operation, step executorStep) (err error) {
 	message := step.message(op)
-	logger.Debugf(message)
+	x.logger.Debugf("%s", message)
 	newState, firstErr := step.run(op, *x.state)
 	if newState != nil {
 		writeErr := x.writeState(*newState)
 		if firstErr == nil {
 			firstErr = writeErr
 		} else if writeErr != nil {
-			logger.Errorf("after %s: %v", message, writeErr)
+			x.logger.Errorf("after %s: %v", message, writeErr)
 		}
 	}
 	return errors.Annotatef(firstErr, message)
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/juju/errors"
+	"github.com/juju/loggo"
 	"github.com/juju/mutex"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
@@ -44,14 +45,14 @@ func (s *NewExecutorSuite) path(path string) string {
 }
 
 func (s *NewExecutorSuite) TestNewExecutorNoFileNoCharm(c *gc.C) {
-	executor, err := operation.NewExecutor(s.path("missing"), failGetInstallCharm, failAcquireLock)
+	executor, err := operation.NewExecutor("u/0", s.path("missing"), failGetInstallCharm, failAcquireLock)
 	c.Assert(executor, gc.IsNil)
 	c.Assert(err, gc.ErrorMatches, "lol!")
 }
 
 func (s *NewExecutorSuite) TestNewExecutorInvalidFile(c *gc.C) {
 	ft.File{"existing", "", 0666}.Create(c, s.basePath)
-	executor, err := operation.NewExecutor(s.path("existing"), failGetInstallCharm, failAcquireLock)
+	executor, err := operation.NewExecutor("u/0", s.path("existing"), failGetInstallCharm, failAcquireLock)
 	c.Assert(executor, gc.IsNil)
 	c.Assert(err, gc.ErrorMatches, `cannot read ".*": invalid operation state: .*`)
 }
@@ -61,7 +62,7 @@ func (s *NewExecutorSuite) TestNewExecutorNoFile(c *gc.C) {
 	getInstallCharm := func() (*corecharm.URL, error) {
 		return charmURL, nil
 	}
-	executor, err := operation.NewExecutor(s.path("missing"), getInstallCharm, failAcquireLock)
+	executor, err := operation.NewExecutor("u/0", s.path("missing"), getInstallCharm, failAcquireLock)
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(executor.State(), gc.DeepEquals, operation.State{
 		Kind:     operation.Install,
@@ -81,7 +82,7 @@ started: true
 op: continue
 opstep: pending
 `[1:], 0666}.Create(c, s.basePath)
-	executor, err := operation.NewExecutor(s.path("existing"), failGetInstallCharm, failAcquireLock)
+	executor, err := operation.NewExecutor("u/0", s.path("existing"), failGetInstallCharm, failAcquireLock)
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(executor.State(), gc.DeepEquals, operation.State{
 		Kind:    operation.Continue,
@@ -106,7 +107,7 @@ func newExecutor(c *gc.C, st *operation.State) (operation.Executor, string) {
 	path := filepath.Join(c.MkDir(), "state")
 	err := operation.NewStateFile(path).Write(st)
 	c.Assert(err, jc.ErrorIsNil)
-	executor, err := operation.NewExecutor(path, failGetInstallCharm, failAcquireLock)
+	executor, err := operation.NewExecutor("u/0", path, failGetInstallCharm, failAcquireLock)
 	c.Assert(err, jc.ErrorIsNil)
 	return executor, path
 }
@@ -138,6 +139,42 @@ func (s *ExecutorSuite) TestSucceedNoStateChanges(c *gc.C) {
 	c.Assert(executor.State(), gc.DeepEquals, initialState)
 }
 
+func (s *ExecutorSuite) TestRunLogsWithUnit(c *gc.C) {
+	var logWriter loggo.TestWriter
+	c.Assert(loggo.RegisterWriter("executor-tests", &logWriter), jc.ErrorIsNil)
+	defer func() {
+		loggo.RemoveWriter("executor-tests")
+		logWriter.Clear()
+	}()
+	logger := loggo.GetLogger("juju.worker.uniter.operation")
+	defer logger.SetLogLevel(logger.LogLevel())
+	logger.SetLogLevel(loggo.DEBUG)
+
+	initialState := justInstalledState()
+	executor, _ := newExecutor(c, &initialState)
+	op := &mockOperation{
+		prepare: newStep(nil, nil),
+		execute: newStep(nil, nil),
+		commit:  newStep(nil, nil),
+	}
+	err := executor.Run(op)
+	c.Assert(err, jc.ErrorIsNil)
+
+	var messages []string
+	for _, entry := range logWriter.Log() {
+		if entry.Module == "juju.worker.uniter.operation" {
+			c.Check(entry.Filename, gc.Matches, ".*executor.go")
+			messages = append(messages, entry.Message)
+		}
+	}
+	c.Assert(messages, jc.DeepEquals, []string{
+		`[unit=u/0 op=executor] running operation mock operation`,
+		`[unit=u/0 op=executor] preparing operation "mock operation"`,
+		`[unit=u/0 op=executor] executing operation "mock operation"`,
+		`[unit=u/0 op=executor] committing operation "mock operation"`,
+	})
+}
+
 func (s *ExecutorSuite) TestSucceedWithStateChanges(c *gc.C) {
 	initialState := justInstalledState()
 	executor, statePath := newExecutor(c, &initialState)
@@ -331,7 +368,7 @@ func (s *ExecutorSuite) initLockTest(c *gc.C, lockFunc func() (mutex.Releaser, e
 	statePath := filepath.Join(c.MkDir(), "state")
 	err := operation.NewStateFile(statePath).Write(&initialState)
 	c.Assert(err, jc.ErrorIsNil)
-	executor, err := operation.NewExecutor(statePath, failGetInstallCharm, lockFunc)
+	executor, err := operation.NewExecutor("u/0", statePath, failGetInstallCharm, lockFunc)
 	c.Assert(err, jc.ErrorIsNil)
 
 	return executor
@@ -4,7 +4,11 @@
 package operation
 
 import (
+	"sync"
+	"time"
+
 	"github.com/juju/errors"
+	"github.com/juju/utils/clock"
 	corecharm "gopkg.in/juju/charm.v6-unstable"
 	"gopkg.in/juju/names.v2"
 
@@ -20,22 +24,113 @@ type FactoryParams struct {
 	Callbacks      Callbacks
 	Abort          <-chan struct{}
 	MetricSpoolDir string
+
+	// UnitName is the name of the unit the operations are run for. It
+	// labels the messages the operations log.
+	UnitName string
+
+	// Clock is used to measure operation timeouts. It defaults to
+	// the wall clock.
+	Clock clock.Clock
+
+	// OperationTimeout, if positive, is the longest a run-hook or
+	// run-action operation may run. When it is exceeded, the hook or
+	// action process is killed: a hook is then treated as failed,
+	// which puts the unit into an error state, and an action is
+	// recorded as failed.
+	OperationTimeout time.Duration
+
+	// OperationTimeouts overrides OperationTimeout for the given
+	// kinds of operation, RunHook or RunAction. A value that is not
+	// positive disables the timeout for that kind.
+	OperationTimeouts map[Kind]time.Duration
+
+	// RelationChangedWindow, if positive, coalesces relation-changed
+	// hooks: a hook for a remote unit asked for within this window of
+	// the previous one for the same unit is held until the window has
+	// passed, and then run once for the latest settings. It is
+	// measured with Clock.
+	RelationChangedWindow time.Duration
+
+	// RelationChangeVersion, if set, returns the latest settings
+	// version of the given remote unit in the given relation. A held
+	// relation-changed hook is run for that version.
+	RelationChangeVersion func(relationId int, remoteUnit string) (int64, bool)
+
+	// FenceReason is the reason the unit was fenced, as recorded in
+	// the operation state, or empty if it is not fenced. The factory
+	// starts out fenced for that reason, so that a fence outlives a
+	// restart of the uniter.
+	FenceReason string
 }
 
 // NewFactory returns a Factory that creates Operations backed by the supplied
 // parameters.
 func NewFactory(params FactoryParams) Factory {
-	return &factory{
-		config: params,
+	clk := params.Clock
+	if clk == nil {
+		clk = clock.WallClock
+	}
+	f := &factory{
+		config:      params,
+		clock:       clk,
+		fenceReason: params.FenceReason,
+	}
+	if params.RelationChangedWindow > 0 {
+		f.coalescer = newRelationChangedCoalescer(
+			clk, params.RelationChangedWindow, params.Abort, params.RelationChangeVersion,
+		)
 	}
+	return f
 }
 
 type factory struct {
-	config FactoryParams
+	config    FactoryParams
+	clock     clock.Clock
+	coalescer *relationChangedCoalescer
+
+	// mu guards fenceReason.
+	mu          sync.Mutex
+	fenceReason string
+}
+
+// setFence records the reason the factory is fenced; an empty reason
+// unfences it.
+func (f *factory) setFence(reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fenceReason = reason
+}
+
+// checkFence returns an error if the factory is fenced, and so must
+// not create operations that change the unit.
+func (f *factory) checkFence() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fenceReason != "" {
+		return errors.Annotate(ErrFenced, f.fenceReason)
+	}
+	return nil
+}
+
+// newLogger returns a logger for an operation of the given kind.
+func (f *factory) newLogger(kind string) opLogger {
+	return newOpLogger(f.config.UnitName, kind)
+}
+
+// timeout returns the longest the given kind of operation may run.
+func (f *factory) timeout(kind Kind) time.Duration {
+	if timeout, ok := f.config.OperationTimeouts[kind]; ok {
+		return timeout
+	}
+	return f.config.OperationTimeout
 }
 
 // newDeploy is the common code for creating arbitrary deploy operations.
 func (f *factory) newDeploy(kind Kind, charmURL *corecharm.URL, revert, resolved bool) (Operation, error) {
+	if err := f.checkFence(); err != nil {
+		return nil, err
+	}
 	if charmURL == nil {
 		return nil, errors.New("charm url required")
 	} else if kind != Install && kind != Upgrade {
@@ -74,6 +169,25 @@ func (f *factory) NewResolvedUpgrade(charmURL *corecharm.URL) (Operation, error)
 
 // NewRunHook is part of the Factory interface.
 func (f *factory) NewRunHook(hookInfo hook.Info) (Operation, error) {
+	hookOp, err := f.newRunHook(hookInfo)
+	if err != nil {
+		return nil, err
+	}
+	if f.coalescer != nil {
+		info, err := f.coalescer.coalesce(hookOp.info)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		hookOp.info = info
+	}
+	return hookOp, nil
+}
+
+// newRunHook is the common code for creating run and skip hook operations.
+func (f *factory) newRunHook(hookInfo hook.Info) (*runHook, error) {
+	if err := f.checkFence(); err != nil {
+		return nil, err
+	}
 	if err := hookInfo.Validate(); err != nil {
 		return nil, err
 	}
@@ -81,12 +195,15 @@ func (f *factory) NewRunHook(hookInfo hook.Info) (Operation, error) {
 		info:          hookInfo,
 		callbacks:     f.config.Callbacks,
 		runnerFactory: f.config.RunnerFactory,
+		clock:         f.clock,
+		timeout:       f.timeout(RunHook),
+		logger:        f.newLogger(string(RunHook)),
 	}, nil
 }
 
 // NewSkipHook is part of the Factory interface.
 func (f *factory) NewSkipHook(hookInfo hook.Info) (Operation, error) {
-	hookOp, err := f.NewRunHook(hookInfo)
+	hookOp, err := f.newRunHook(hookInfo)
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +212,9 @@ func (f *factory) NewSkipHook(hookInfo hook.Info) (Operation, error) {
 
 // NewAction is part of the Factory interface.
 func (f *factory) NewAction(actionId string) (Operation, error) {
+	if err := f.checkFence(); err != nil {
+		return nil, err
+	}
 	if !names.IsValidAction(actionId) {
 		return nil, errors.Errorf("invalid action id %q", actionId)
 	}
@@ -102,6 +222,9 @@ func (f *factory) NewAction(actionId string) (Operation, error) {
 		actionId:      actionId,
 		callbacks:     f.config.Callbacks,
 		runnerFactory: f.config.RunnerFactory,
+		clock:         f.clock,
+		timeout:       f.timeout(RunAction),
+		logger:        f.newLogger(string(RunAction)),
 	}, nil
 }
 
@@ -118,6 +241,9 @@ func (f *factory) NewFailAction(actionId string) (Operation, error) {
 
 // NewCommands is part of the Factory interface.
 func (f *factory) NewCommands(args CommandArgs, sendResponse CommandResponseFunc) (Operation, error) {
+	if err := f.checkFence(); err != nil {
+		return nil, err
+	}
 	if args.Commands == "" {
 		return nil, errors.New("commands required")
 	} else if sendResponse == nil {
@@ -135,15 +261,53 @@ func (f *factory) NewCommands(args CommandArgs, sendResponse CommandResponseFunc
 		sendResponse:  sendResponse,
 		callbacks:     f.config.Callbacks,
 		runnerFactory: f.config.RunnerFactory,
+		logger:        f.newLogger("run-commands"),
 	}, nil
 }
 
 // NewResignLeadership is part of the Factory interface.
 func (f *factory) NewResignLeadership() (Operation, error) {
-	return &resignLeadership{}, nil
+	return &resignLeadership{
+		logger: f.newLogger("resign-leadership"),
+	}, nil
 }
 
 // NewAcceptLeadership is part of the Factory interface.
 func (f *factory) NewAcceptLeadership() (Operation, error) {
+	if err := f.checkFence(); err != nil {
+		return nil, err
+	}
 	return &acceptLeadership{}, nil
 }
+
+// NewFence is part of the Factory interface.
+func (f *factory) NewFence(reason string) (Operation, error) {
+	if reason == "" {
+		return nil, errors.New("fence reason required")
+	}
+	// A unit that is already fenced, for example because its model
+	// is being migrated, must be unfenced before it can be fenced
+	// again, so that one reason cannot silently replace another.
+	f.mu.Lock()
+	fenceReason := f.fenceReason
+	f.mu.Unlock()
+	if fenceReason != "" {
+		return nil, errors.Annotate(ErrAlreadyFenced, fenceReason)
+	}
+	return &fence{
+		reason:  reason,
+		factory: f,
+	}, nil
+}
+
+// NewRebootOperation is part of the Factory interface.
+func (f *factory) NewRebootOperation() (Operation, error) {
+	return &reboot{}, nil
+}
+
+// NewUnfence is part of the Factory interface.
+func (f *factory) NewUnfence() (Operation, error) {
+	return &unfence{
+		factory: f,
+	}, nil
+}
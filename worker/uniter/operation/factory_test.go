@@ -4,6 +4,10 @@
 package operation_test
 
 import (
+	"path/filepath"
+	"time"
+
+	"github.com/juju/errors"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
 	utilexec "github.com/juju/utils/exec"
@@ -11,6 +15,7 @@ import (
 	corecharm "gopkg.in/juju/charm.v6-unstable"
 	"gopkg.in/juju/charm.v6-unstable/hooks"
 
+	coretesting "github.com/juju/juju/testing"
 	"github.com/juju/juju/worker/uniter/hook"
 	"github.com/juju/juju/worker/uniter/operation"
 )
@@ -210,3 +215,693 @@ func (s *FactorySuite) TestNewResignLeadershipString(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(op.String(), gc.Equals, "resign leadership")
 }
+
+func (s *FactorySuite) testNewLeaderHook(c *gc.C, kind hooks.Kind) {
+	runnerFactory := NewRunHookRunnerFactory(nil)
+	callbacks := &ExecuteHookCallbacks{
+		PrepareHookCallbacks:    NewPrepareHookCallbacks(),
+		MockNotifyHookCompleted: &MockNotify{},
+		MockNotifyHookFailed:    &MockNotify{},
+	}
+	factory := operation.NewFactory(operation.FactoryParams{
+		RunnerFactory: runnerFactory,
+		Callbacks:     callbacks,
+	})
+	op, err := factory.NewRunHook(hook.Info{Kind: kind})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(op.String(), gc.Equals, "run "+string(kind)+" hook")
+	c.Check(op.NeedsGlobalMachineLock(), jc.IsTrue)
+
+	midState, err := op.Prepare(operation.State{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(midState, gc.DeepEquals, &operation.State{
+		Kind: operation.RunHook,
+		Step: operation.Pending,
+		Hook: &hook.Info{Kind: kind},
+	})
+	c.Check(*callbacks.MockPrepareHook.gotHook, gc.DeepEquals, hook.Info{Kind: kind})
+	c.Check(*runnerFactory.MockNewHookRunner.gotHook, gc.DeepEquals, hook.Info{Kind: kind})
+
+	newState, err := op.Execute(*midState)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(newState, gc.DeepEquals, &operation.State{
+		Kind: operation.RunHook,
+		Step: operation.Done,
+		Hook: &hook.Info{Kind: kind},
+	})
+	c.Check(*runnerFactory.MockNewHookRunner.runner.MockRunHook.gotName, gc.Equals, "some-hook-name")
+	c.Check(callbacks.executingMessage, gc.Equals, "running some-hook-name hook")
+	c.Check(*callbacks.MockNotifyHookCompleted.gotName, gc.Equals, "some-hook-name")
+}
+
+func (s *FactorySuite) TestNewRunHookLeaderElected(c *gc.C) {
+	s.testNewLeaderHook(c, hooks.LeaderElected)
+}
+
+func (s *FactorySuite) TestNewRunHookLeaderDeposed(c *gc.C) {
+	s.testNewLeaderHook(c, hooks.LeaderDeposed)
+}
+
+func (s *FactorySuite) TestNewSkipHookLeaderElectedString(c *gc.C) {
+	op, err := s.factory.NewSkipHook(hook.Info{Kind: hooks.LeaderElected})
+	c.Check(err, jc.ErrorIsNil)
+	c.Check(op.String(), gc.Equals, "skip run leader-elected hook")
+}
+
+func (s *FactorySuite) newTimeoutFactory(
+	clock *testing.Clock, timeouts map[operation.Kind]time.Duration, runnerFactory *MockRunnerFactory, callbacks operation.Callbacks,
+) operation.Factory {
+	return operation.NewFactory(operation.FactoryParams{
+		Callbacks:         callbacks,
+		RunnerFactory:     runnerFactory,
+		Clock:             clock,
+		OperationTimeout:  time.Minute,
+		OperationTimeouts: timeouts,
+	})
+}
+
+// newHangingHookRunnerFactory returns a runner factory whose hooks run
+// until their process is killed.
+func newHangingHookRunnerFactory(proc *MockHookProcess) *MockRunnerFactory {
+	runnerFactory := NewRunHookRunnerFactory(errors.New("signal: killed"))
+	runnerFactory.MockNewHookRunner.runner.MockRunHook.wait = proc.killed
+	runnerFactory.MockNewHookRunner.runner.context.(*MockContext).process = proc
+	return runnerFactory
+}
+
+func (s *FactorySuite) TestRunHookTimeoutAbortsHook(c *gc.C) {
+	clock := testing.NewClock(time.Time{})
+	proc := NewMockHookProcess()
+	runnerFactory := newHangingHookRunnerFactory(proc)
+	callbacks := &ExecuteHookCallbacks{
+		PrepareHookCallbacks:    NewPrepareHookCallbacks(),
+		MockNotifyHookCompleted: &MockNotify{},
+		MockNotifyHookFailed:    &MockNotify{},
+	}
+	factory := s.newTimeoutFactory(clock, nil, runnerFactory, callbacks)
+
+	op, err := factory.NewRunHook(hook.Info{Kind: hooks.ConfigChanged})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = op.Prepare(operation.State{})
+	c.Assert(err, jc.ErrorIsNil)
+
+	type result struct {
+		state *operation.State
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		state, err := op.Execute(operation.State{})
+		done <- result{state, err}
+	}()
+	err = clock.WaitAdvance(time.Minute, coretesting.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+
+	select {
+	case r := <-done:
+		c.Check(r.state, gc.IsNil)
+		c.Check(r.err, gc.Equals, operation.ErrHookFailed)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out hook was not aborted")
+	}
+	select {
+	case <-proc.killed:
+	default:
+		c.Fatalf("hook process not killed")
+	}
+	c.Check(*callbacks.MockNotifyHookFailed.gotName, gc.Equals, "some-hook-name")
+	c.Check(callbacks.MockNotifyHookCompleted.gotName, gc.IsNil)
+}
+
+func (s *FactorySuite) TestRunHookTimeoutAfterProcessExited(c *gc.C) {
+	clock := testing.NewClock(time.Time{})
+	proc := NewMockHookProcess()
+	proc.exited = true
+	// The hook process has exited, but the context is still being
+	// flushed when the timeout expires.
+	flushed := make(chan struct{})
+	runnerFactory := NewRunHookRunnerFactory(nil)
+	runnerFactory.MockNewHookRunner.runner.MockRunHook.wait = flushed
+	runnerFactory.MockNewHookRunner.runner.context.(*MockContext).process = proc
+	callbacks := &ExecuteHookCallbacks{
+		PrepareHookCallbacks:    NewPrepareHookCallbacks(),
+		MockNotifyHookCompleted: &MockNotify{},
+		MockNotifyHookFailed:    &MockNotify{},
+	}
+	factory := s.newTimeoutFactory(clock, nil, runnerFactory, callbacks)
+
+	op, err := factory.NewRunHook(hook.Info{Kind: hooks.ConfigChanged})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = op.Prepare(operation.State{})
+	c.Assert(err, jc.ErrorIsNil)
+
+	type result struct {
+		state *operation.State
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		state, err := op.Execute(operation.State{})
+		done <- result{state, err}
+	}()
+	err = clock.WaitAdvance(time.Minute, coretesting.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	select {
+	case <-done:
+		c.Fatalf("hook finished before its context was flushed")
+	case <-time.After(coretesting.ShortWait):
+	}
+	close(flushed)
+
+	select {
+	case r := <-done:
+		c.Assert(r.err, jc.ErrorIsNil)
+		c.Check(r.state.Step, gc.Equals, operation.Done)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("hook did not finish")
+	}
+	c.Check(callbacks.MockNotifyHookFailed.gotName, gc.IsNil)
+}
+
+func (s *FactorySuite) TestRunHookTimeoutAbandonsStuckHook(c *gc.C) {
+	clock := testing.NewClock(time.Time{})
+	proc := NewMockHookProcess()
+	// The hook never returns, even once its process is killed, as
+	// when a child of the process keeps its output open.
+	stuck := make(chan struct{})
+	defer close(stuck)
+	runnerFactory := NewRunHookRunnerFactory(nil)
+	runnerFactory.MockNewHookRunner.runner.MockRunHook.wait = stuck
+	runnerFactory.MockNewHookRunner.runner.context.(*MockContext).process = proc
+	callbacks := &ExecuteHookCallbacks{
+		PrepareHookCallbacks:    NewPrepareHookCallbacks(),
+		MockNotifyHookCompleted: &MockNotify{},
+		MockNotifyHookFailed:    &MockNotify{},
+	}
+	factory := s.newTimeoutFactory(clock, nil, runnerFactory, callbacks)
+
+	op, err := factory.NewRunHook(hook.Info{Kind: hooks.ConfigChanged})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = op.Prepare(operation.State{})
+	c.Assert(err, jc.ErrorIsNil)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := op.Execute(operation.State{})
+		done <- err
+	}()
+	err = clock.WaitAdvance(time.Minute, coretesting.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	// Wait for the grace period and the kill retry.
+	err = clock.WaitAdvance(time.Hour, coretesting.LongWait, 2)
+	c.Assert(err, jc.ErrorIsNil)
+
+	select {
+	case err := <-done:
+		c.Check(err, gc.Equals, operation.ErrHookFailed)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("stuck hook was not abandoned")
+	}
+	c.Check(*callbacks.MockNotifyHookFailed.gotName, gc.Equals, "some-hook-name")
+	// A late flush by the abandoned hook must not write anything.
+	c.Check(runnerFactory.MockNewHookRunner.runner.context.(*MockContext).discarded, jc.IsTrue)
+}
+
+func (s *FactorySuite) TestRunHookTimeoutOverriddenPerKind(c *gc.C) {
+	clock := testing.NewClock(time.Time{})
+	proc := NewMockHookProcess()
+	runnerFactory := newHangingHookRunnerFactory(proc)
+	callbacks := &ExecuteHookCallbacks{
+		PrepareHookCallbacks:    NewPrepareHookCallbacks(),
+		MockNotifyHookCompleted: &MockNotify{},
+		MockNotifyHookFailed:    &MockNotify{},
+	}
+	factory := s.newTimeoutFactory(clock, map[operation.Kind]time.Duration{
+		operation.RunHook: 0,
+	}, runnerFactory, callbacks)
+
+	op, err := factory.NewRunHook(hook.Info{Kind: hooks.ConfigChanged})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = op.Prepare(operation.State{})
+	c.Assert(err, jc.ErrorIsNil)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := op.Execute(operation.State{})
+		done <- err
+	}()
+	// Without a timeout, the hook is left to run.
+	clock.Advance(time.Hour)
+	select {
+	case <-done:
+		c.Fatalf("hook aborted without a timeout")
+	case <-time.After(coretesting.ShortWait):
+	}
+	proc.Kill()
+	select {
+	case err := <-done:
+		c.Check(err, gc.Equals, operation.ErrHookFailed)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("hook did not finish")
+	}
+}
+
+func (s *FactorySuite) TestRunActionTimeoutAbortsAction(c *gc.C) {
+	clock := testing.NewClock(time.Time{})
+	proc := NewMockHookProcess()
+	runnerFactory := NewRunActionRunnerFactory(nil)
+	runnerFactory.MockNewActionRunner.runner.MockRunAction.wait = proc.killed
+	runnerFactory.MockNewActionRunner.runner.context.(*MockContext).process = proc
+	callbacks := &RunActionCallbacks{}
+	factory := s.newTimeoutFactory(clock, map[operation.Kind]time.Duration{
+		operation.RunAction: time.Second,
+	}, runnerFactory, callbacks)
+
+	op, err := factory.NewAction(someActionId)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = op.Prepare(operation.State{})
+	c.Assert(err, jc.ErrorIsNil)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := op.Execute(operation.State{})
+		done <- err
+	}()
+	err = clock.WaitAdvance(time.Second, coretesting.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+
+	select {
+	case err := <-done:
+		c.Check(err, jc.ErrorIsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out action was not aborted")
+	}
+	select {
+	case <-proc.killed:
+	default:
+		c.Fatalf("action process not killed")
+	}
+}
+
+func (s *FactorySuite) TestNewFenceError(c *gc.C) {
+	op, err := s.factory.NewFence("")
+	c.Check(op, gc.IsNil)
+	c.Check(err, gc.ErrorMatches, "fence reason required")
+}
+
+func (s *FactorySuite) TestNewFenceString(c *gc.C) {
+	op, err := s.factory.NewFence("migration in progress")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(op.String(), gc.Equals, "fence (migration in progress)")
+}
+
+func (s *FactorySuite) TestNewUnfenceString(c *gc.C) {
+	op, err := s.factory.NewUnfence()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(op.String(), gc.Equals, "unfence")
+}
+
+func (s *FactorySuite) runOperation(c *gc.C, op operation.Operation, state operation.State) operation.State {
+	newState, err := op.Prepare(state)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(newState, gc.IsNil)
+	newState, err = op.Execute(state)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(newState, gc.IsNil)
+	newState, err = op.Commit(state)
+	c.Assert(err, jc.ErrorIsNil)
+	if newState == nil {
+		return state
+	}
+	return *newState
+}
+
+func (s *FactorySuite) checkMutatingOperations(c *gc.C, expectErr string) {
+	charmURL := corecharm.MustParseURL("cs:quantal/wordpress-1")
+	newOps := map[string]func() (operation.Operation, error){
+		"install": func() (operation.Operation, error) {
+			return s.factory.NewInstall(charmURL)
+		},
+		"upgrade": func() (operation.Operation, error) {
+			return s.factory.NewUpgrade(charmURL)
+		},
+		"run hook": func() (operation.Operation, error) {
+			return s.factory.NewRunHook(hook.Info{Kind: hooks.Install})
+		},
+		"skip hook": func() (operation.Operation, error) {
+			return s.factory.NewSkipHook(hook.Info{Kind: hooks.Install})
+		},
+		"action": func() (operation.Operation, error) {
+			return s.factory.NewAction(someActionId)
+		},
+		"commands": func() (operation.Operation, error) {
+			return s.factory.NewCommands(commandArgs("anything", -1, ""), panicSendResponse)
+		},
+		"accept leadership": func() (operation.Operation, error) {
+			return s.factory.NewAcceptLeadership()
+		},
+	}
+	for name, newOp := range newOps {
+		c.Logf("checking %s", name)
+		op, err := newOp()
+		if expectErr == "" {
+			c.Check(err, jc.ErrorIsNil)
+			c.Check(op, gc.NotNil)
+			continue
+		}
+		c.Check(op, gc.IsNil)
+		c.Check(err, gc.ErrorMatches, expectErr)
+		c.Check(errors.Cause(err), gc.Equals, operation.ErrFenced)
+	}
+}
+
+func (s *FactorySuite) TestFenceBlocksMutatingOperations(c *gc.C) {
+	op, err := s.factory.NewFence("migration in progress")
+	c.Assert(err, jc.ErrorIsNil)
+	state := s.runOperation(c, op, operation.State{Kind: operation.Continue})
+	c.Check(state.FenceReason, gc.Equals, "migration in progress")
+
+	s.checkMutatingOperations(c, "migration in progress: unit is fenced")
+
+	// Operations that do not change the unit are still available.
+	_, err = s.factory.NewFailAction(someActionId)
+	c.Check(err, jc.ErrorIsNil)
+	_, err = s.factory.NewResignLeadership()
+	c.Check(err, jc.ErrorIsNil)
+}
+
+func (s *FactorySuite) TestFenceTakesEffectOnCommit(c *gc.C) {
+	op, err := s.factory.NewFence("migration in progress")
+	c.Assert(err, jc.ErrorIsNil)
+	state := operation.State{Kind: operation.Continue}
+	_, err = op.Prepare(state)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = op.Execute(state)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Until the fence is committed, the factory is not fenced.
+	s.checkMutatingOperations(c, "")
+
+	newState, err := op.Commit(state)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(newState.FenceReason, gc.Equals, "migration in progress")
+	s.checkMutatingOperations(c, "migration in progress: unit is fenced")
+}
+
+func (s *FactorySuite) TestUnfenceRestoresOperations(c *gc.C) {
+	op, err := s.factory.NewFence("migration in progress")
+	c.Assert(err, jc.ErrorIsNil)
+	state := s.runOperation(c, op, operation.State{Kind: operation.Continue})
+
+	op, err = s.factory.NewUnfence()
+	c.Assert(err, jc.ErrorIsNil)
+	state = s.runOperation(c, op, state)
+	c.Check(state.FenceReason, gc.Equals, "")
+
+	s.checkMutatingOperations(c, "")
+}
+
+func (s *FactorySuite) TestFenceRestoredFromState(c *gc.C) {
+	s.factory = operation.NewFactory(operation.FactoryParams{
+		FenceReason: "migration in progress",
+	})
+	s.checkMutatingOperations(c, "migration in progress: unit is fenced")
+
+	op, err := s.factory.NewUnfence()
+	c.Assert(err, jc.ErrorIsNil)
+	state := s.runOperation(c, op, operation.State{
+		Kind:        operation.Continue,
+		FenceReason: "migration in progress",
+	})
+	c.Check(state.FenceReason, gc.Equals, "")
+	s.checkMutatingOperations(c, "")
+}
+
+func (s *FactorySuite) TestFenceRefusedWhenAlreadyFenced(c *gc.C) {
+	op, err := s.factory.NewFence("migration in progress")
+	c.Assert(err, jc.ErrorIsNil)
+	state := s.runOperation(c, op, operation.State{Kind: operation.Continue})
+
+	op, err = s.factory.NewFence("something else")
+	c.Check(op, gc.IsNil)
+	c.Check(err, gc.ErrorMatches, "migration in progress: unit is already fenced")
+	c.Check(errors.Cause(err), gc.Equals, operation.ErrAlreadyFenced)
+
+	// Once unfenced, the unit can be fenced again.
+	op, err = s.factory.NewUnfence()
+	c.Assert(err, jc.ErrorIsNil)
+	state = s.runOperation(c, op, state)
+	op, err = s.factory.NewFence("something else")
+	c.Assert(err, jc.ErrorIsNil)
+	state = s.runOperation(c, op, state)
+	c.Check(state.FenceReason, gc.Equals, "something else")
+}
+
+func (s *FactorySuite) TestFenceRefusedWhenFencedInState(c *gc.C) {
+	s.factory = operation.NewFactory(operation.FactoryParams{
+		FenceReason: "migration in progress",
+	})
+	op, err := s.factory.NewFence("migration in progress")
+	c.Check(op, gc.IsNil)
+	c.Check(errors.Cause(err), gc.Equals, operation.ErrAlreadyFenced)
+}
+
+func (s *FactorySuite) TestFenceDrainsInFlightOperations(c *gc.C) {
+	callbacks := &ExecuteHookCallbacks{
+		PrepareHookCallbacks:    NewPrepareHookCallbacks(),
+		MockNotifyHookCompleted: &MockNotify{},
+		MockNotifyHookFailed:    &MockNotify{},
+	}
+	factory := operation.NewFactory(operation.FactoryParams{
+		RunnerFactory: NewRunHookRunnerFactory(nil),
+		Callbacks:     callbacks,
+	})
+	hookOp, err := factory.NewRunHook(hook.Info{Kind: hooks.ConfigChanged})
+	c.Assert(err, jc.ErrorIsNil)
+	midState, err := hookOp.Prepare(operation.State{Kind: operation.Continue})
+	c.Assert(err, jc.ErrorIsNil)
+
+	op, err := factory.NewFence("migration in progress")
+	c.Assert(err, jc.ErrorIsNil)
+	s.runOperation(c, op, operation.State{Kind: operation.Continue})
+
+	// The hook that was already under way can finish...
+	_, err = hookOp.Execute(*midState)
+	c.Check(err, jc.ErrorIsNil)
+
+	// ...but no new hooks can start.
+	_, err = factory.NewRunHook(hook.Info{Kind: hooks.ConfigChanged})
+	c.Check(errors.Cause(err), gc.Equals, operation.ErrFenced)
+}
+
+func (s *FactorySuite) TestNewRebootOperationString(c *gc.C) {
+	op, err := s.factory.NewRebootOperation()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(op.String(), gc.Equals, "reboot")
+	c.Assert(op.NeedsGlobalMachineLock(), jc.IsFalse)
+}
+
+func (s *FactorySuite) TestRebootOperationRecordsReboot(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "state")
+	err := operation.NewStateFile(path).Write(&operation.State{Kind: operation.Continue, Step: operation.Pending})
+	c.Assert(err, jc.ErrorIsNil)
+	executor, err := operation.NewExecutor("u/0", path, failGetInstallCharm, failAcquireLock)
+	c.Assert(err, jc.ErrorIsNil)
+
+	op, err := s.factory.NewRebootOperation()
+	c.Assert(err, jc.ErrorIsNil)
+	err = executor.Run(op)
+	c.Assert(errors.Cause(err), gc.Equals, operation.ErrNeedsReboot)
+	c.Assert(executor.State().RebootRequired, jc.IsTrue)
+	state, err := operation.NewStateFile(path).Read()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(state.RebootRequired, jc.IsTrue)
+
+	// Once the machine has rebooted, running the operation again
+	// only clears the record.
+	executor, err = operation.NewExecutor("u/0", path, failGetInstallCharm, failAcquireLock)
+	c.Assert(err, jc.ErrorIsNil)
+	err = executor.Run(op)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(executor.State(), jc.DeepEquals, operation.State{Kind: operation.Continue, Step: operation.Pending})
+}
+
+func (s *FactorySuite) TestRebootOperationAfterReboot(c *gc.C) {
+	op, err := s.factory.NewRebootOperation()
+	c.Assert(err, jc.ErrorIsNil)
+	state := operation.State{Kind: operation.Continue, RebootRequired: true}
+	newState, err := op.Prepare(state)
+	c.Assert(err, gc.Equals, operation.ErrSkipExecute)
+	c.Assert(newState, gc.IsNil)
+
+	newState, err = op.Commit(state)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(*newState, jc.DeepEquals, operation.State{Kind: operation.Continue})
+
+	// Committing it yet again changes nothing.
+	newState, err = op.Commit(*newState)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(newState, gc.IsNil)
+}
+
+func (s *FactorySuite) newCoalescingFactory(
+	clock *testing.Clock, abort <-chan struct{}, versions map[string]int64,
+) (operation.Factory, *PrepareHookCallbacks) {
+	callbacks := NewPrepareHookCallbacks()
+	factory := operation.NewFactory(operation.FactoryParams{
+		Callbacks:             callbacks,
+		RunnerFactory:         NewRunHookRunnerFactory(nil),
+		Abort:                 abort,
+		Clock:                 clock,
+		RelationChangedWindow: time.Second,
+		RelationChangeVersion: func(relationId int, remoteUnit string) (int64, bool) {
+			version, ok := versions[remoteUnit]
+			return version, ok
+		},
+	})
+	return factory, callbacks
+}
+
+func relationChanged(remoteUnit string, changeVersion int64) hook.Info {
+	return hook.Info{
+		Kind:          hooks.RelationChanged,
+		RelationId:    123,
+		RemoteUnit:    remoteUnit,
+		ChangeVersion: changeVersion,
+	}
+}
+
+type newOpResult struct {
+	op  operation.Operation
+	err error
+}
+
+// newRunHookAsync calls NewRunHook in the background, so that the test
+// can advance the clock while the hook is held.
+func newRunHookAsync(factory operation.Factory, info hook.Info) <-chan newOpResult {
+	result := make(chan newOpResult, 1)
+	go func() {
+		op, err := factory.NewRunHook(info)
+		result <- newOpResult{op, err}
+	}()
+	return result
+}
+
+func waitNewOpResult(c *gc.C, result <-chan newOpResult) newOpResult {
+	select {
+	case r := <-result:
+		return r
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for NewRunHook")
+	}
+	panic("unreachable")
+}
+
+func assertNotHeld(c *gc.C, factory operation.Factory, info hook.Info) {
+	select {
+	case r := <-newRunHookAsync(factory, info):
+		c.Assert(r.err, jc.ErrorIsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("%s for %s was held", info.Kind, info.RemoteUnit)
+	}
+}
+
+func (s *FactorySuite) TestNewRunHookCoalescesRelationChanged(c *gc.C) {
+	clock := testing.NewClock(time.Time{})
+	versions := map[string]int64{"foo/22": 1}
+	factory, callbacks := s.newCoalescingFactory(clock, nil, versions)
+
+	_, err := factory.NewRunHook(relationChanged("foo/22", 1))
+	c.Assert(err, jc.ErrorIsNil)
+	clock.Advance(100 * time.Millisecond)
+	result := newRunHookAsync(factory, relationChanged("foo/22", 2))
+
+	// The settings keep changing while the hook is held...
+	err = clock.WaitAdvance(100*time.Millisecond, coretesting.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	versions["foo/22"] = 5
+	select {
+	case <-result:
+		c.Fatalf("relation-changed ran within the window")
+	case <-time.After(coretesting.ShortWait):
+	}
+
+	// ...and it runs once when the window has passed, for the
+	// latest settings.
+	clock.Advance(800 * time.Millisecond)
+	r := waitNewOpResult(c, result)
+	c.Assert(r.err, jc.ErrorIsNil)
+	c.Check(r.op.String(), gc.Equals, "run relation-changed (123; foo/22) hook")
+	_, err = r.op.Prepare(operation.State{})
+	c.Assert(err, jc.ErrorIsNil)
+	expect := relationChanged("foo/22", 5)
+	c.Check(callbacks.MockPrepareHook.gotHook, gc.DeepEquals, &expect)
+}
+
+func (s *FactorySuite) TestNewRunHookCoalescesKeepsRequestedVersion(c *gc.C) {
+	clock := testing.NewClock(time.Time{})
+	factory, callbacks := s.newCoalescingFactory(clock, nil, map[string]int64{"foo/22": 1})
+
+	_, err := factory.NewRunHook(relationChanged("foo/22", 1))
+	c.Assert(err, jc.ErrorIsNil)
+	result := newRunHookAsync(factory, relationChanged("foo/22", 2))
+	err = clock.WaitAdvance(time.Second, coretesting.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// An older version from the remote state never replaces the
+	// requested one.
+	r := waitNewOpResult(c, result)
+	c.Assert(r.err, jc.ErrorIsNil)
+	_, err = r.op.Prepare(operation.State{})
+	c.Assert(err, jc.ErrorIsNil)
+	expect := relationChanged("foo/22", 2)
+	c.Check(callbacks.MockPrepareHook.gotHook, gc.DeepEquals, &expect)
+}
+
+func (s *FactorySuite) TestNewRunHookCoalescesPerRemoteUnit(c *gc.C) {
+	clock := testing.NewClock(time.Time{})
+	factory, _ := s.newCoalescingFactory(clock, nil, nil)
+
+	assertNotHeld(c, factory, relationChanged("foo/1", 1))
+	assertNotHeld(c, factory, relationChanged("foo/2", 1))
+	info := relationChanged("foo/1", 1)
+	info.RelationId = 456
+	assertNotHeld(c, factory, info)
+}
+
+func (s *FactorySuite) TestNewRunHookDoesNotHoldOutsideWindow(c *gc.C) {
+	clock := testing.NewClock(time.Time{})
+	factory, _ := s.newCoalescingFactory(clock, nil, nil)
+
+	assertNotHeld(c, factory, relationChanged("foo/22", 1))
+	clock.Advance(time.Second)
+	assertNotHeld(c, factory, relationChanged("foo/22", 2))
+}
+
+func (s *FactorySuite) TestNewRunHookDoesNotHoldOtherHooks(c *gc.C) {
+	clock := testing.NewClock(time.Time{})
+	factory, _ := s.newCoalescingFactory(clock, nil, nil)
+
+	assertNotHeld(c, factory, relationChanged("foo/22", 1))
+	assertNotHeld(c, factory, hook.Info{
+		Kind:       hooks.RelationDeparted,
+		RelationId: 123,
+		RemoteUnit: "foo/22",
+	})
+	_, err := factory.NewSkipHook(relationChanged("foo/22", 2))
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *FactorySuite) TestNewRunHookAbortedWhileHeld(c *gc.C) {
+	clock := testing.NewClock(time.Time{})
+	abort := make(chan struct{})
+	factory, _ := s.newCoalescingFactory(clock, abort, nil)
+
+	_, err := factory.NewRunHook(relationChanged("foo/22", 1))
+	c.Assert(err, jc.ErrorIsNil)
+	result := newRunHookAsync(factory, relationChanged("foo/22", 2))
+	close(abort)
+	r := waitNewOpResult(c, result)
+	c.Assert(r.op, gc.IsNil)
+	c.Assert(r.err, gc.ErrorMatches, "aborted waiting to run relation-changed for foo/22")
+}
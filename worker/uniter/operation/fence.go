@@ -0,0 +1,86 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package operation
+
+import (
+	"fmt"
+)
+
+// fence is an operation that stops the factory producing mutating
+// operations, for example while the unit's model is being migrated.
+//
+// A unit moves between two states. Unfenced, State.FenceReason is
+// empty and the factory produces every operation. A fence records its
+// reason in State.FenceReason, after which the factory refuses to
+// produce operations that change the unit, and refuses another fence;
+// operations already under way are left to finish. An unfence clears
+// State.FenceReason and returns the unit to the unfenced state.
+type fence struct {
+	reason  string
+	factory *factory
+
+	DoesNotRequireMachineLock
+}
+
+// String is part of the Operation interface.
+func (op *fence) String() string {
+	return fmt.Sprintf("fence (%s)", op.reason)
+}
+
+// Prepare is part of the Operation interface.
+func (op *fence) Prepare(state State) (*State, error) {
+	return nil, nil
+}
+
+// Execute is part of the Operation interface.
+func (op *fence) Execute(state State) (*State, error) {
+	return nil, nil
+}
+
+// Commit is part of the Operation interface. The factory is fenced
+// here, alongside the state change, rather than in Execute, so that
+// a fence that fails before it is committed leaves the factory and
+// the recorded state in agreement.
+func (op *fence) Commit(state State) (*State, error) {
+	op.factory.setFence(op.reason)
+	if state.FenceReason == op.reason {
+		return nil, nil
+	}
+	state.FenceReason = op.reason
+	return &state, nil
+}
+
+// unfence is an operation that lets the factory resume producing
+// mutating operations once the reason for fencing has gone away.
+type unfence struct {
+	factory *factory
+
+	DoesNotRequireMachineLock
+}
+
+// String is part of the Operation interface.
+func (op *unfence) String() string {
+	return "unfence"
+}
+
+// Prepare is part of the Operation interface.
+func (op *unfence) Prepare(state State) (*State, error) {
+	return nil, nil
+}
+
+// Execute is part of the Operation interface.
+func (op *unfence) Execute(state State) (*State, error) {
+	return nil, nil
+}
+
+// Commit is part of the Operation interface. Like fence, it updates
+// the factory alongside the state change.
+func (op *unfence) Commit(state State) (*State, error) {
+	op.factory.setFence("")
+	if state.FenceReason == "" {
+		return nil, nil
+	}
+	state.FenceReason = ""
+	return &state, nil
+}
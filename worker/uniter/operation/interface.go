@@ -105,6 +105,21 @@ type Factory interface {
 	// NewResignLeadership creates an operation to ensure the uniter does not
 	// act as service leader.
 	NewResignLeadership() (Operation, error)
+
+	// NewFence creates an operation that stops the factory creating
+	// operations that change the unit, until an unfence operation runs.
+	// It returns ErrAlreadyFenced if the unit is already fenced.
+	NewFence(reason string) (Operation, error)
+
+	// NewUnfence creates an operation that lets the factory resume
+	// creating operations that change the unit.
+	NewUnfence() (Operation, error)
+
+	// NewRebootOperation creates an operation that records that the
+	// machine must reboot and fails with ErrNeedsReboot, so that the
+	// uniter reboots it. Run again after the reboot, it only clears
+	// that record.
+	NewRebootOperation() (Operation, error)
 }
 
 // CommandArgs stores the arguments for a Command operation.
@@ -61,6 +61,9 @@ func (al *acceptLeadership) checkState(state State) error {
 }
 
 type resignLeadership struct {
+	// logger labels the messages logged for the operation.
+	logger opLogger
+
 	DoesNotRequireMachineLock
 }
 
@@ -112,7 +115,7 @@ func (rl *resignLeadership) Execute(state State) (*State, error) {
 	// I *think* it will stay, because the state-writing behaviour will stay
 	// very different (ie just write `.Leader = false` and don't step on pre-
 	// queued hooks).
-	logger.Warningf("we should run a leader-deposed hook here, but we can't yet")
+	rl.logger.Warningf("we should run a leader-deposed hook here, but we can't yet")
 	return nil, nil
 }
 
@@ -0,0 +1,61 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package operation
+
+import (
+	"fmt"
+
+	"github.com/juju/loggo"
+)
+
+// opLogger writes to the package logger on behalf of a single operation,
+// labelling each message with the unit and the kind of operation, so that
+// the messages logged while preparing, executing and committing it can be
+// told apart from those of other operations and units. Its zero value
+// logs with empty labels.
+type opLogger struct {
+	unit string
+	kind string
+}
+
+// newOpLogger returns an opLogger for an operation of the given kind run
+// by the given unit.
+func newOpLogger(unit, kind string) opLogger {
+	return opLogger{unit: unit, kind: kind}
+}
+
+// logf logs a labelled message at the given level. The call depth is
+// that of the caller of the level-specific method below.
+func (l opLogger) logf(level loggo.Level, format string, args ...interface{}) {
+	if !logger.IsLevelEnabled(level) {
+		return
+	}
+	message := fmt.Sprintf(format, args...)
+	logger.LogCallf(3, level, "[unit=%s op=%s] %s", l.unit, l.kind, message)
+}
+
+// Errorf logs a message at the ERROR level.
+func (l opLogger) Errorf(format string, args ...interface{}) {
+	l.logf(loggo.ERROR, format, args...)
+}
+
+// Warningf logs a message at the WARNING level.
+func (l opLogger) Warningf(format string, args ...interface{}) {
+	l.logf(loggo.WARNING, format, args...)
+}
+
+// Infof logs a message at the INFO level.
+func (l opLogger) Infof(format string, args ...interface{}) {
+	l.logf(loggo.INFO, format, args...)
+}
+
+// Debugf logs a message at the DEBUG level.
+func (l opLogger) Debugf(format string, args ...interface{}) {
+	l.logf(loggo.DEBUG, format, args...)
+}
+
+// Tracef logs a message at the TRACE level.
+func (l opLogger) Tracef(format string, args ...interface{}) {
+	l.logf(loggo.TRACE, format, args...)
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package operation
+
+import (
+	"github.com/juju/juju/worker/uniter/runner/context"
+)
+
+// checkRebootRequest checks whether err, returned by running a hook or
+// commands, asks for the machine to reboot. If it does, it returns
+// ErrNeedsReboot, in response to which the uniter runs a reboot
+// operation, and whether the hook should be run again after the
+// reboot.
+func checkRebootRequest(err error) (rebootErr error, requeue bool) {
+	switch err {
+	case context.ErrRequeueAndReboot:
+		return ErrNeedsReboot, true
+	case context.ErrReboot:
+		return ErrNeedsReboot, false
+	}
+	return nil, false
+}
+
+// reboot is an operation that records that the machine must reboot,
+// and asks the uniter to reboot it. Its state transitions are:
+//
+//   - Prepare skips execution if a reboot has already been recorded,
+//     which means the operation is running again after the reboot;
+//   - Execute records that a reboot is required and fails with
+//     ErrNeedsReboot, which the uniter turns into a machine reboot;
+//   - Commit, which only runs once the machine has rebooted, clears
+//     the record.
+type reboot struct {
+	DoesNotRequireMachineLock
+}
+
+// String is part of the Operation interface.
+func (op *reboot) String() string {
+	return "reboot"
+}
+
+// Prepare is part of the Operation interface.
+func (op *reboot) Prepare(state State) (*State, error) {
+	if state.RebootRequired {
+		return nil, ErrSkipExecute
+	}
+	return nil, nil
+}
+
+// Execute is part of the Operation interface.
+func (op *reboot) Execute(state State) (*State, error) {
+	state.RebootRequired = true
+	return &state, ErrNeedsReboot
+}
+
+// Commit is part of the Operation interface.
+func (op *reboot) Commit(state State) (*State, error) {
+	if !state.RebootRequired {
+		return nil, nil
+	}
+	state.RebootRequired = false
+	return &state, nil
+}
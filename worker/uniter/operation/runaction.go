@@ -5,8 +5,10 @@ package operation
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/juju/errors"
+	"github.com/juju/utils/clock"
 
 	"github.com/juju/juju/worker/uniter/runner"
 )
@@ -17,6 +19,13 @@ type runAction struct {
 	callbacks     Callbacks
 	runnerFactory runner.Factory
 
+	// clock and timeout limit how long the action may run.
+	clock   clock.Clock
+	timeout time.Duration
+
+	// logger labels the messages logged for the action.
+	logger opLogger
+
 	name   string
 	runner runner.Runner
 
@@ -72,7 +81,13 @@ func (ra *runAction) Execute(state State) (*State, error) {
 		return nil, err
 	}
 
-	err := ra.runner.RunAction(ra.name)
+	timedOut, err := runWithTimeout(ra.logger, ra.clock, ra.timeout, ra.runner.Context(), func() error {
+		return ra.runner.RunAction(ra.name)
+	})
+	if timedOut {
+		// The runner records the killed action as failed.
+		ra.logger.Errorf("action %q timed out after %v", ra.name, ra.timeout)
+	}
 	if err != nil {
 		// This indicates an actual error -- an action merely failing should
 		// be handled inside the Runner, and returned as nil.
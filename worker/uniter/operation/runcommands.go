@@ -19,6 +19,9 @@ type runCommands struct {
 	callbacks     Callbacks
 	runnerFactory runner.Factory
 
+	// logger labels the messages logged for the commands.
+	logger opLogger
+
 	runner runner.Runner
 
 	RequiresMachineLock
@@ -61,22 +64,20 @@ func (rc *runCommands) Prepare(state State) (*State, error) {
 // state change.
 // Execute is part of the Operation interface.
 func (rc *runCommands) Execute(state State) (*State, error) {
-	logger.Tracef("run commands: %s", rc)
+	rc.logger.Tracef("run commands: %s", rc)
 	if err := rc.callbacks.SetExecutingStatus("running commands"); err != nil {
 		return nil, errors.Trace(err)
 	}
 
 	response, err := rc.runner.RunCommands(rc.args.Commands)
-	switch err {
-	case context.ErrRequeueAndReboot:
-		logger.Warningf("cannot requeue external commands")
-		fallthrough
-	case context.ErrReboot:
+	if rebootErr, requeue := checkRebootRequest(err); rebootErr != nil {
+		if requeue {
+			rc.logger.Warningf("cannot requeue external commands")
+		}
 		rc.sendResponse(response, nil)
-		err = ErrNeedsReboot
-	default:
-		rc.sendResponse(response, err)
+		return nil, rebootErr
 	}
+	rc.sendResponse(response, err)
 	return nil, err
 }
 
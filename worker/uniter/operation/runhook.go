@@ -5,8 +5,10 @@ package operation
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/juju/errors"
+	"github.com/juju/utils/clock"
 	"gopkg.in/juju/charm.v6-unstable/hooks"
 
 	"github.com/juju/juju/status"
@@ -22,6 +24,13 @@ type runHook struct {
 	callbacks     Callbacks
 	runnerFactory runner.Factory
 
+	// clock and timeout limit how long the hook may run.
+	clock   clock.Clock
+	timeout time.Duration
+
+	// logger labels the messages logged for the hook.
+	logger opLogger
+
 	name   string
 	runner runner.Runner
 
@@ -91,29 +100,37 @@ func (rh *runHook) Execute(state State) (*State, error) {
 	ranHook := true
 	step := Done
 
-	err := rh.runner.RunHook(rh.name)
+	timedOut, err := runWithTimeout(rh.logger, rh.clock, rh.timeout, rh.runner.Context(), func() error {
+		return rh.runner.RunHook(rh.name)
+	})
+	if timedOut {
+		rh.logger.Errorf("hook %q timed out after %v", rh.name, rh.timeout)
+		rh.callbacks.NotifyHookFailed(rh.name, rh.runner.Context())
+		return nil, ErrHookFailed
+	}
 	cause := errors.Cause(err)
+	rebootErr, requeue := checkRebootRequest(cause)
 	switch {
 	case context.IsMissingHookError(cause):
 		ranHook = false
 		err = nil
-	case cause == context.ErrRequeueAndReboot:
-		step = Queued
-		fallthrough
-	case cause == context.ErrReboot:
-		err = ErrNeedsReboot
+	case rebootErr != nil:
+		if requeue {
+			step = Queued
+		}
+		err = rebootErr
 	case err == nil:
 	default:
-		logger.Errorf("hook %q failed: %v", rh.name, err)
+		rh.logger.Errorf("hook %q failed: %v", rh.name, err)
 		rh.callbacks.NotifyHookFailed(rh.name, rh.runner.Context())
 		return nil, ErrHookFailed
 	}
 
 	if ranHook {
-		logger.Infof("ran %q hook", rh.name)
+		rh.logger.Infof("ran %q hook", rh.name)
 		rh.callbacks.NotifyHookCompleted(rh.name, rh.runner.Context())
 	} else {
-		logger.Infof("skipped %q hook (missing)", rh.name)
+		rh.logger.Infof("skipped %q hook (missing)", rh.name)
 	}
 
 	var hasRunStatusSet bool
@@ -144,7 +161,7 @@ func (rh *runHook) beforeHook() error {
 		})
 	}
 	if err != nil {
-		logger.Errorf("error updating workload status before %v hook: %v", rh.info.Kind, err)
+		rh.logger.Errorf("error updating workload status before %v hook: %v", rh.info.Kind, err)
 		return err
 	}
 	return nil
@@ -167,7 +184,7 @@ func (rh *runHook) afterHook(state State) (bool, error) {
 		if hasRunStatusSet {
 			break
 		}
-		logger.Debugf("unit %v has started but has not yet set status", ctx.UnitName())
+		rh.logger.Debugf("unit %v has started but has not yet set status", ctx.UnitName())
 		// We've finished the start hook and the charm has not updated its
 		// own status so we'll set it to unknown.
 		err = rh.runner.Context().SetUnitStatus(jujuc.StatusInfo{
@@ -175,7 +192,7 @@ func (rh *runHook) afterHook(state State) (bool, error) {
 		})
 	}
 	if err != nil {
-		logger.Errorf("error updating workload status after %v hook: %v", rh.info.Kind, err)
+		rh.logger.Errorf("error updating workload status after %v hook: %v", rh.info.Kind, err)
 		return false, err
 	}
 	return hasRunStatusSet, nil
@@ -5,6 +5,7 @@ package operation_test
 
 import (
 	"github.com/juju/errors"
+	"github.com/juju/loggo"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
@@ -272,6 +273,44 @@ func (s *RunHookSuite) TestExecuteOtherError(c *gc.C) {
 	c.Assert(callbacks.MockNotifyHookCompleted.gotName, gc.IsNil)
 }
 
+func (s *RunHookSuite) TestExecuteLogsWithUnitAndKind(c *gc.C) {
+	var logWriter loggo.TestWriter
+	c.Assert(loggo.RegisterWriter("runhook-tests", &logWriter), jc.ErrorIsNil)
+	defer func() {
+		loggo.RemoveWriter("runhook-tests")
+		logWriter.Clear()
+	}()
+
+	runnerFactory := NewRunHookRunnerFactory(errors.New("graaargh"))
+	callbacks := &ExecuteHookCallbacks{
+		PrepareHookCallbacks:    NewPrepareHookCallbacks(),
+		MockNotifyHookCompleted: &MockNotify{},
+		MockNotifyHookFailed:    &MockNotify{},
+	}
+	factory := operation.NewFactory(operation.FactoryParams{
+		RunnerFactory: runnerFactory,
+		Callbacks:     callbacks,
+		UnitName:      "mysql/0",
+	})
+	op, err := factory.NewRunHook(hook.Info{Kind: hooks.ConfigChanged})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = op.Prepare(operation.State{})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = op.Execute(operation.State{})
+	c.Assert(err, gc.Equals, operation.ErrHookFailed)
+
+	var found bool
+	for _, entry := range logWriter.Log() {
+		if entry.Module != "juju.worker.uniter.operation" || entry.Level != loggo.ERROR {
+			continue
+		}
+		c.Check(entry.Message, gc.Equals, `[unit=mysql/0 op=run-hook] hook "some-hook-name" failed: graaargh`)
+		c.Check(entry.Filename, gc.Matches, ".*runhook.go")
+		found = true
+	}
+	c.Assert(found, jc.IsTrue)
+}
+
 func (s *RunHookSuite) testExecuteSuccess(
 	c *gc.C, before, after operation.State, setStatusCalled bool,
 ) {
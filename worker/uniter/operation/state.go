@@ -72,6 +72,17 @@ type State struct {
 	// the status-set hook tool.
 	StatusSet bool `yaml:"status-set"`
 
+	// FenceReason holds the reason given to the last fence operation
+	// that completed, unless a more recent unfence operation has
+	// completed. While fenced, the uniter must not run operations that
+	// change the unit.
+	FenceReason string `yaml:"fence-reason,omitempty"`
+
+	// RebootRequired indicates whether a reboot operation has asked
+	// for the machine to reboot, and has not yet completed after it
+	// did.
+	RebootRequired bool `yaml:"reboot-required,omitempty"`
+
 	// Kind indicates the current operation.
 	Kind Kind `yaml:"op"`
 
@@ -0,0 +1,82 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package operation
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils/clock"
+
+	"github.com/juju/juju/worker/uniter/runner"
+)
+
+const (
+	// killRetryDelay is how long runWithTimeout waits for a killed
+	// process to die before trying to kill it again.
+	killRetryDelay = 100 * time.Millisecond
+
+	// killGracePeriod is how long runWithTimeout waits for run to
+	// return once the process has been killed. A child of the process
+	// that outlives it may hold its output open, so run might never
+	// return.
+	killGracePeriod = 30 * time.Second
+)
+
+// runWithTimeout calls run, which runs a hook or action process in the
+// given context. If run has not returned when the timeout expires, the
+// context's process is killed, repeatedly until run returns, and
+// runWithTimeout reports that it timed out. If the process had already
+// exited, run is finishing off, for example by flushing the context,
+// and it is waited for without reporting a timeout.
+//
+// Once the process has been killed, run is given killGracePeriod to
+// return, so that the context is flushed even after a timeout. If it
+// has not returned by then, the context is discarded, so that a late
+// Flush by run writes nothing, and run is abandoned and an error is
+// returned.
+// A timeout that is not positive means there is no limit. Messages are
+// logged to the given operation's logger.
+func runWithTimeout(logger opLogger, clk clock.Clock, timeout time.Duration, ctx runner.Context, run func() error) (bool, error) {
+	if timeout <= 0 {
+		return false, run()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- run()
+	}()
+	select {
+	case err := <-done:
+		return false, err
+	case <-clk.After(timeout):
+	}
+	logger.Errorf("operation exceeded its %v timeout; killing it", timeout)
+	var gracePeriod <-chan time.Time
+	for {
+		// The process may not have been started yet, so keep trying
+		// until run returns.
+		if proc := ctx.GetProcess(); proc != nil {
+			err := proc.Kill()
+			switch {
+			case err == nil && gracePeriod == nil:
+				gracePeriod = clk.After(killGracePeriod)
+			case err != nil && gracePeriod == nil:
+				// The process exited before it could be killed.
+				logger.Debugf("process already finished: %v", err)
+				return false, <-done
+			case err != nil:
+				logger.Debugf("kill returned: %v", err)
+			}
+		}
+		select {
+		case err := <-done:
+			return gracePeriod != nil, err
+		case <-gracePeriod:
+			logger.Errorf("operation did not finish within %v of being killed; abandoning it", killGracePeriod)
+			ctx.Discard()
+			return true, errors.Errorf("process did not exit within %v of being killed", killGracePeriod)
+		case <-clk.After(killRetryDelay):
+		}
+	}
+}
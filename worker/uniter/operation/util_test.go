@@ -4,6 +4,8 @@
 package operation_test
 
 import (
+	"sync"
+
 	"github.com/juju/errors"
 	"github.com/juju/testing"
 	utilexec "github.com/juju/utils/exec"
@@ -282,6 +284,43 @@ type MockContext struct {
 	actionData      *context.ActionData
 	setStatusCalled bool
 	status          jujuc.StatusInfo
+	process         *MockHookProcess
+	discarded       bool
+}
+
+func (mock *MockContext) Discard() {
+	mock.discarded = true
+}
+
+func (mock *MockContext) GetProcess() context.HookProcess {
+	if mock.process == nil {
+		return nil
+	}
+	return mock.process
+}
+
+// MockHookProcess is a hook process whose Kill closes killed. If it
+// has exited, Kill fails as it does for a real process that has.
+type MockHookProcess struct {
+	killed chan struct{}
+	once   sync.Once
+	exited bool
+}
+
+func NewMockHookProcess() *MockHookProcess {
+	return &MockHookProcess{killed: make(chan struct{})}
+}
+
+func (p *MockHookProcess) Pid() int {
+	return 123
+}
+
+func (p *MockHookProcess) Kill() error {
+	if p.exited {
+		return errors.New("os: process already finished")
+	}
+	p.once.Do(func() { close(p.killed) })
+	return nil
 }
 
 func (mock *MockContext) ActionData() (*context.ActionData, error) {
@@ -321,10 +360,14 @@ func (mock *MockContext) Prepare() error {
 type MockRunAction struct {
 	gotName *string
 	err     error
+	wait    <-chan struct{}
 }
 
 func (mock *MockRunAction) Call(actionName string) error {
 	mock.gotName = &actionName
+	if mock.wait != nil {
+		<-mock.wait
+	}
 	return mock.err
 }
 
@@ -343,10 +386,14 @@ type MockRunHook struct {
 	gotName         *string
 	err             error
 	setStatusCalled bool
+	wait            <-chan struct{}
 }
 
 func (mock *MockRunHook) Call(hookName string) error {
 	mock.gotName = &hookName
+	if mock.wait != nil {
+		<-mock.wait
+	}
 	return mock.err
 }
 
@@ -179,6 +179,10 @@ type HookContext struct {
 	// like a juju-run command or a hook
 	process HookProcess
 
+	// discarded is true once the context has been given up on, and
+	// must no longer be flushed.
+	discarded bool
+
 	// rebootPriority tells us when the hook wants to reboot. If rebootPriority is jujuc.RebootNow
 	// the hook will be killed and requeued
 	rebootPriority jujuc.RebootPriority
@@ -274,6 +278,13 @@ func (ctx *HookContext) SetProcess(process HookProcess) {
 	ctx.process = process
 }
 
+// Discard implements the Context interface.
+func (ctx *HookContext) Discard() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	ctx.discarded = true
+}
+
 func (ctx *HookContext) Id() string {
 	return ctx.id
 }
@@ -639,6 +650,13 @@ func (ctx *HookContext) Prepare() error {
 
 // Flush implements the Context interface.
 func (ctx *HookContext) Flush(process string, ctxErr error) (err error) {
+	mutex.Lock()
+	discarded := ctx.discarded
+	mutex.Unlock()
+	if discarded {
+		logger.Warningf("not flushing %q context, which was discarded", process)
+		return errors.Errorf("%s context discarded", process)
+	}
 	writeChanges := ctxErr == nil
 
 	// In the case of Actions, handle any errors using finalizeAction.
@@ -91,6 +91,24 @@ func (s *FlushContextSuite) TestRunHookRelationFlushingSuccess(c *gc.C) {
 	})
 }
 
+func (s *FlushContextSuite) TestFlushAfterDiscardWritesNothing(c *gc.C) {
+	ctx := s.context(c)
+
+	relCtx0, err := ctx.Relation(0)
+	c.Assert(err, jc.ErrorIsNil)
+	node0, err := relCtx0.Settings()
+	c.Assert(err, jc.ErrorIsNil)
+	node0.Set("foo", "1")
+
+	ctx.Discard()
+	err = ctx.Flush("some badge", nil)
+	c.Assert(err, gc.ErrorMatches, "some badge context discarded")
+
+	settings0, err := s.relunits[0].ReadSettings("u/0")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(settings0, gc.DeepEquals, map[string]interface{}{"relation-name": "db0"})
+}
+
 func (s *FlushContextSuite) TestRunHookOpensAndClosesPendingPorts(c *gc.C) {
 	// Initially, no port ranges are open on the unit or its machine.
 	unitRanges, err := s.unit.OpenedPorts()
@@ -50,11 +50,18 @@ type Context interface {
 	HookVars(paths context.Paths) ([]string, error)
 	ActionData() (*context.ActionData, error)
 	SetProcess(process context.HookProcess)
+	GetProcess() context.HookProcess
 	HasExecutionSetUnitStatus() bool
 	ResetExecutionSetUnitStatus()
 
 	Prepare() error
 	Flush(badge string, failure error) error
+
+	// Discard stops any later Flush from writing the context's
+	// changes. It is used when the process running in the context is
+	// abandoned, so that a late Flush cannot race with the operations
+	// that follow.
+	Discard()
 }
 
 // NewRunner returns a Runner backed by the supplied context and paths.
@@ -152,6 +152,7 @@ type MockContext struct {
 	actionParamsErr error
 	actionResults   map[string]interface{}
 	expectPid       int
+	process         context.HookProcess
 	flushBadge      string
 	flushFailure    error
 	flushResult     error
@@ -174,8 +175,15 @@ func (ctx *MockContext) ActionData() (*context.ActionData, error) {
 
 func (ctx *MockContext) SetProcess(process context.HookProcess) {
 	ctx.expectPid = process.Pid()
+	ctx.process = process
 }
 
+func (ctx *MockContext) GetProcess() context.HookProcess {
+	return ctx.process
+}
+
+func (ctx *MockContext) Discard() {}
+
 func (ctx *MockContext) Prepare() error {
 	return nil
 }
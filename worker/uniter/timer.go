@@ -10,6 +10,10 @@ import (
 const (
 	// interval at which the unit's status should be polled
 	statusPollInterval = 5 * time.Minute
+
+	// relationChangedWindow is the shortest interval between
+	// relation-changed hooks for the same relation member.
+	relationChangedWindow = 2 * time.Second
 )
 
 // updateStatusSignal returns a time channel that fires after a given interval.
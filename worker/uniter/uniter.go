@@ -98,6 +98,20 @@ type Uniter struct {
 	// hookRetryStrategy represents configuration for hook retries
 	hookRetryStrategy params.RetryStrategy
 
+	// relationChangedWindow is the shortest interval between
+	// relation-changed hooks for the same relation member. See
+	// operation.FactoryParams.
+	relationChangedWindow time.Duration
+
+	// remoteStateSnapshot returns the remote state as last seen by
+	// the current remote state watcher.
+	remoteStateSnapshot func() remotestate.Snapshot
+
+	// operationTimeout and operationTimeouts limit how long hooks
+	// and actions may run. See operation.FactoryParams.
+	operationTimeout  time.Duration
+	operationTimeouts map[operation.Kind]time.Duration
+
 	// downloader is the downloader that should be used to get the charm
 	// archive.
 	downloader charm.Downloader
@@ -117,13 +131,22 @@ type UniterParams struct {
 	NewOperationExecutor NewExecutorFunc
 	TranslateResolverErr func(error) error
 	Clock                clock.Clock
+	// RelationChangedWindow, if positive, is the shortest interval
+	// between relation-changed hooks for the same relation member, so
+	// that a burst of settings changes runs relation-changed once with
+	// the latest settings.
+	RelationChangedWindow time.Duration
+	// OperationTimeout and OperationTimeouts limit how long hooks and
+	// actions may run. See operation.FactoryParams.
+	OperationTimeout  time.Duration
+	OperationTimeouts map[operation.Kind]time.Duration
 	// TODO (mattyw, wallyworld, fwereade) Having the observer here make this approach a bit more legitimate, but it isn't.
 	// the observer is only a stop gap to be used in tests. A better approach would be to have the uniter tests start hooks
 	// that write to files, and have the tests watch the output to know that hooks have finished.
 	Observer UniterExecutionObserver
 }
 
-type NewExecutorFunc func(string, func() (*corecharm.URL, error), func() (mutex.Releaser, error)) (operation.Executor, error)
+type NewExecutorFunc func(string, string, func() (*corecharm.URL, error), func() (mutex.Releaser, error)) (operation.Executor, error)
 
 // NewUniter creates a new Uniter which will install, run, and upgrade
 // a charm on behalf of the unit with the given unitTag, by executing
@@ -147,6 +170,10 @@ func NewUniter(uniterParams *UniterParams) (*Uniter, error) {
 		observer:             uniterParams.Observer,
 		clock:                uniterParams.Clock,
 		downloader:           uniterParams.Downloader,
+
+		relationChangedWindow: uniterParams.RelationChangedWindow,
+		operationTimeout:      uniterParams.OperationTimeout,
+		operationTimeouts:     uniterParams.OperationTimeouts,
 	}
 	err := catacomb.Invoke(catacomb.Plan{
 		Site: &u.catacomb,
@@ -166,6 +193,14 @@ func (u *Uniter) loop(unitTag names.UnitTag) (err error) {
 	}
 	logger.Infof("unit %q started", u.unit)
 
+	// If a reboot was recorded before the uniter stopped, the machine
+	// has rebooted since, so the record can be cleared.
+	if u.operationExecutor.State().RebootRequired {
+		if err := u.reboot(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
 	// Install is a special case, as it must run before there
 	// is any remote state, and before the remote state watcher
 	// is started.
@@ -267,6 +302,12 @@ func (u *Uniter) loop(unitTag names.UnitTag) (err error) {
 		return setAgentStatus(u, status.Idle, "", nil)
 	}
 
+	u.remoteStateSnapshot = func() remotestate.Snapshot {
+		watcherMu.Lock()
+		defer watcherMu.Unlock()
+		return watcher.Snapshot()
+	}
+
 	clearResolved := func() error {
 		if err := u.unit.ClearResolved(); err != nil {
 			return errors.Trace(err)
@@ -328,7 +369,7 @@ func (u *Uniter) loop(unitTag names.UnitTag) (err error) {
 			case resolver.ErrLoopAborted:
 				err = u.catacomb.ErrDying()
 			case operation.ErrNeedsReboot:
-				err = worker.ErrRebootMachine
+				err = u.reboot()
 			case operation.ErrHookFailed:
 				// Loop back around. The resolver can tell that it is in
 				// an error state by inspecting the operation state.
@@ -396,6 +437,21 @@ func (u *Uniter) terminate() error {
 	}
 }
 
+// relationChangeVersion returns the latest settings version of the
+// given member of the given relation, as last seen by the remote state
+// watcher.
+func (u *Uniter) relationChangeVersion(relationId int, remoteUnit string) (int64, bool) {
+	if u.remoteStateSnapshot == nil {
+		return 0, false
+	}
+	relation, ok := u.remoteStateSnapshot().Relations[relationId]
+	if !ok {
+		return 0, false
+	}
+	version, ok := relation.Members[remoteUnit]
+	return version, ok
+}
+
 func (u *Uniter) init(unitTag names.UnitTag) (err error) {
 	u.unit, err = u.st.Unit(unitTag)
 	if err != nil {
@@ -470,19 +526,28 @@ func (u *Uniter) init(unitTag names.UnitTag) (err error) {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	operationExecutor, err := u.newOperationExecutor(unitTag.Id(), u.paths.State.OperationsFile, u.getServiceCharmURL, u.acquireExecutionLock)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	u.operationExecutor = operationExecutor
+
 	u.operationFactory = operation.NewFactory(operation.FactoryParams{
 		Deployer:       deployer,
 		RunnerFactory:  runnerFactory,
 		Callbacks:      &operationCallbacks{u},
 		Abort:          u.catacomb.Dying(),
 		MetricSpoolDir: u.paths.GetMetricsSpoolDir(),
-	})
+		UnitName:       unitTag.Id(),
+		Clock:          u.clock,
+		FenceReason:    operationExecutor.State().FenceReason,
 
-	operationExecutor, err := u.newOperationExecutor(u.paths.State.OperationsFile, u.getServiceCharmURL, u.acquireExecutionLock)
-	if err != nil {
-		return errors.Trace(err)
-	}
-	u.operationExecutor = operationExecutor
+		OperationTimeout:  u.operationTimeout,
+		OperationTimeouts: u.operationTimeouts,
+
+		RelationChangedWindow: u.relationChangedWindow,
+		RelationChangeVersion: u.relationChangeVersion,
+	})
 
 	logger.Debugf("starting juju-run listener on unix:%s", u.paths.Runtime.JujuRunSocket)
 	commandRunner, err := NewChannelCommandRunner(ChannelCommandRunnerConfig{
@@ -511,6 +576,25 @@ func (u *Uniter) init(unitTag names.UnitTag) (err error) {
 	return nil
 }
 
+// reboot runs a reboot operation. If the machine has yet to reboot
+// since a hook or command asked it to, the operation records that it
+// must and reboot returns worker.ErrRebootMachine, which makes the
+// agent reboot it; otherwise the record is cleared.
+func (u *Uniter) reboot() error {
+	op, err := u.operationFactory.NewRebootOperation()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	switch err := u.operationExecutor.Run(op); errors.Cause(err) {
+	case nil:
+		return nil
+	case operation.ErrNeedsReboot:
+		return worker.ErrRebootMachine
+	default:
+		return errors.Trace(err)
+	}
+}
+
 func (u *Uniter) Kill() {
 	u.catacomb.Kill(nil)
 }
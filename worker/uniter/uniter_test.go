@@ -200,8 +200,8 @@ func (m *noopExecutor) Run(op operation.Operation) error {
 }
 
 func (s *UniterSuite) TestUniterStartupStatus(c *gc.C) {
-	executorFunc := func(stateFilePath string, getInstallCharm func() (*corecharm.URL, error), acquireLock func() (mutex.Releaser, error)) (operation.Executor, error) {
-		e, err := operation.NewExecutor(stateFilePath, getInstallCharm, acquireLock)
+	executorFunc := func(unitName, stateFilePath string, getInstallCharm func() (*corecharm.URL, error), acquireLock func() (mutex.Releaser, error)) (operation.Executor, error) {
+		e, err := operation.NewExecutor(unitName, stateFilePath, getInstallCharm, acquireLock)
 		c.Assert(err, jc.ErrorIsNil)
 		return &mockExecutor{e}, nil
 	}
@@ -1641,6 +1641,7 @@ func (s *UniterSuite) TestRebootFinishesHook(c *gc.C) {
 			waitAddresses{},
 			waitUniterDead{err: "machine needs to reboot"},
 			waitHooks{"install"},
+			verifyRebootRequired{true},
 			startUniter{},
 			waitUnitAgent{
 				status: status.Idle,
@@ -1650,6 +1651,7 @@ func (s *UniterSuite) TestRebootFinishesHook(c *gc.C) {
 				status:       status.Unknown,
 			},
 			waitHooks{"leader-elected", "config-changed", "start"},
+			verifyRebootRequired{false},
 		)})
 }
 
@@ -1929,8 +1931,8 @@ func (m *mockExecutor) Run(op operation.Operation) error {
 }
 
 func (s *UniterSuite) TestOperationErrorReported(c *gc.C) {
-	executorFunc := func(stateFilePath string, getInstallCharm func() (*corecharm.URL, error), acquireLock func() (mutex.Releaser, error)) (operation.Executor, error) {
-		e, err := operation.NewExecutor(stateFilePath, getInstallCharm, acquireLock)
+	executorFunc := func(unitName, stateFilePath string, getInstallCharm func() (*corecharm.URL, error), acquireLock func() (mutex.Releaser, error)) (operation.Executor, error) {
+		e, err := operation.NewExecutor(unitName, stateFilePath, getInstallCharm, acquireLock)
 		c.Assert(err, jc.ErrorIsNil)
 		return &mockExecutor{e}, nil
 	}
@@ -1950,8 +1952,8 @@ func (s *UniterSuite) TestOperationErrorReported(c *gc.C) {
 }
 
 func (s *UniterSuite) TestTranslateResolverError(c *gc.C) {
-	executorFunc := func(stateFilePath string, getInstallCharm func() (*corecharm.URL, error), acquireLock func() (mutex.Releaser, error)) (operation.Executor, error) {
-		e, err := operation.NewExecutor(stateFilePath, getInstallCharm, acquireLock)
+	executorFunc := func(unitName, stateFilePath string, getInstallCharm func() (*corecharm.URL, error), acquireLock func() (mutex.Releaser, error)) (operation.Executor, error) {
+		e, err := operation.NewExecutor(unitName, stateFilePath, getInstallCharm, acquireLock)
 		c.Assert(err, jc.ErrorIsNil)
 		return &mockExecutor{e}, nil
 	}
@@ -679,6 +679,19 @@ func downloadDir(ctx *context) string {
 	return filepath.Join(paths.State.BundlesDir, "downloads")
 }
 
+// verifyRebootRequired checks whether the uniter's operation state
+// records that the machine must reboot.
+type verifyRebootRequired struct {
+	required bool
+}
+
+func (s verifyRebootRequired) step(c *gc.C, ctx *context) {
+	paths := uniter.NewPaths(ctx.dataDir, ctx.unit.UnitTag())
+	state, err := operation.NewStateFile(paths.State.OperationsFile).Read()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(state.RebootRequired, gc.Equals, s.required)
+}
+
 type quickStart struct {
 	minion bool
 }